@@ -2,7 +2,10 @@ module github.com/polliard/gitignore
 
 go 1.23.0
 
-require github.com/mark3labs/mcp-go v0.44.0
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/mark3labs/mcp-go v0.44.0
+)
 
 require (
 	github.com/bahlo/generic-list-go v0.2.0 // indirect
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/polliard/gitignore/src/pkg/config"
+	"github.com/polliard/gitignore/src/pkg/gitignore"
+)
+
+func TestSameTemplateSection(t *testing.T) {
+	manager := gitignore.NewManager(t.TempDir())
+	for _, name := range []string{"ignored/go", "go", "Global/macOS", "Go"} {
+		if err := manager.Add(name, "x\n"); err != nil {
+			t.Fatalf("Add(%s) error = %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		name, sectionName, want string
+	}{
+		{"Go", "Go", "Go"},        // the section itself wins
+		{"go", "Toptal/go", "go"}, // otherwise the first same-named section
+		{"macos", "macos", "Global/macOS"},
+		{"Rust", "Rust", ""},
+	}
+	for _, tt := range tests {
+		got, err := sameTemplateSection(manager, tt.name, tt.sectionName)
+		if err != nil {
+			t.Fatalf("sameTemplateSection(%s) error = %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("sameTemplateSection(%s, %s) = %q, want %q", tt.name, tt.sectionName, got, tt.want)
+		}
+	}
+}
+
+func TestAddPrefix(t *testing.T) {
+	templates := t.TempDir()
+	if err := os.WriteFile(filepath.Join(templates, "Node.gitignore"), []byte("node_modules/\n/dist\n!keep.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.LocalTemplatesPath = templates
+	cfg.Offline = true
+	cfg.CacheDir = ""
+
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	saved := globals
+	t.Cleanup(func() { globals = saved })
+	globals.path = path
+
+	if _, err := cmdAddTo(io.Discard, cfg, "node", addOptions{}); err != nil {
+		t.Fatalf("cmdAddTo(node) error = %v", err)
+	}
+	res, err := cmdAddTo(io.Discard, cfg, "node", addOptions{prefix: "./frontend/"})
+	if err != nil {
+		t.Fatalf("cmdAddTo(node --prefix) error = %v", err)
+	}
+	if res.Section != "Node (frontend/)" || !res.Created {
+		t.Errorf("cmdAddTo(node --prefix) = %+v, want a new section 'Node (frontend/)'", res)
+	}
+
+	manager := gitignore.NewManagerWithPath(path)
+	if body, _ := manager.GetSection("Node"); body != "node_modules/\n/dist\n!keep.log\n" {
+		t.Errorf("unscoped section = %q", body)
+	}
+	if body, _ := manager.GetSection("Node (frontend/)"); body != "/frontend/**/node_modules/\n/frontend/dist\n!/frontend/**/keep.log\n" {
+		t.Errorf("scoped section = %q", body)
+	}
+
+	if _, err := cmdAddTo(io.Discard, cfg, "node", addOptions{prefix: "../up"}); err == nil {
+		t.Error("cmdAddTo() with prefix '../up' should fail")
+	}
+}
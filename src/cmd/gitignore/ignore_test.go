@@ -0,0 +1,108 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitPatternArg(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"*.log"}, []string{"*.log"}},
+		{[]string{"*.log, tmp/"}, []string{"*.log", "tmp/"}},
+		{[]string{"*.log\r\n\n  build/\n*.o,*.a\n"}, []string{"*.log", "build/", "*.o,*.a"}},
+		// Comments in a pasted block are skipped, commas in them included
+		{[]string{"# foo, bar\n*.tmp\n  # indented\n"}, []string{"*.tmp"}},
+		// Escaped trailing spaces are part of the pattern
+		{[]string{"foo\\ \nbar  \n"}, []string{"foo\\ ", "bar"}},
+		{[]string{"foo\\ , bar"}, []string{"foo\\ ", "bar"}},
+		// Several arguments are taken literally
+		{[]string{"a,b", "c"}, []string{"a,b", "c"}},
+	}
+	for _, tt := range tests {
+		got := splitPatternArg(tt.args)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitPatternArg(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreEscapedTrailingSpace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	saved := globals
+	t.Cleanup(func() { globals = saved })
+	globals.path = path
+
+	if _, err := cmdIgnoreTo(io.Discard, splitPatternArg([]string{"foo\\ \n"}), false, false); err != nil {
+		t.Fatalf("cmdIgnoreTo() error = %v", err)
+	}
+	res, err := cmdIgnoreTo(io.Discard, []string{"foo\\ "}, false, false)
+	if err != nil {
+		t.Fatalf("cmdIgnoreTo() error = %v", err)
+	}
+	if strings.Join(res.Skipped, ",") != "foo\\ " {
+		t.Errorf("second cmdIgnoreTo() = %+v, want foo\\  skipped", res)
+	}
+
+	if _, err := cmdRemoveTo(io.Discard, []string{"foo\\ "}, "", false, false); err != nil {
+		t.Fatalf("cmdRemoveTo() error = %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if strings.Contains(string(content), "foo") {
+		t.Errorf("pattern not removed:\n%s", content)
+	}
+}
+
+func TestIgnoreRemoveDryRunOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	saved := globals
+	t.Cleanup(func() { globals = saved })
+	globals.path = path
+
+	var buf strings.Builder
+	if _, err := cmdIgnoreTo(&buf, []string{"*.log"}, false, true); err != nil {
+		t.Fatalf("cmdIgnoreTo() error = %v", err)
+	}
+	if out := buf.String(); !strings.HasPrefix(out, "Would add '*.log' to .gitignore\n") || strings.Contains(out, "Added") {
+		t.Errorf("cmdIgnoreTo() dry run output = %q", out)
+	}
+
+	if _, err := cmdIgnoreTo(io.Discard, []string{"*.log"}, false, false); err != nil {
+		t.Fatalf("cmdIgnoreTo() error = %v", err)
+	}
+	buf.Reset()
+	if _, err := cmdRemoveTo(&buf, []string{"*.log"}, "", false, true); err != nil {
+		t.Fatalf("cmdRemoveTo() error = %v", err)
+	}
+	if out := buf.String(); !strings.HasPrefix(out, "Would remove '*.log' from .gitignore\n") || strings.Contains(out, "Removed") {
+		t.Errorf("cmdRemoveTo() dry run output = %q", out)
+	}
+}
+
+func TestIgnoreRemoveResults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	saved := globals
+	t.Cleanup(func() { globals = saved })
+	globals.path = path
+
+	res, err := cmdIgnoreTo(io.Discard, []string{"*.log", "tmp/", "*.log"}, false, false)
+	if err != nil {
+		t.Fatalf("cmdIgnoreTo() error = %v", err)
+	}
+	if res.Path != path || strings.Join(res.Changed, ",") != "*.log,tmp/" || strings.Join(res.Skipped, ",") != "*.log" {
+		t.Errorf("cmdIgnoreTo() = %+v", res)
+	}
+
+	res, err = cmdRemoveTo(io.Discard, []string{"tmp/", "missing"}, "", false, false)
+	if err != nil {
+		t.Fatalf("cmdRemoveTo() error = %v", err)
+	}
+	if strings.Join(res.Changed, ",") != "tmp/" || strings.Join(res.Skipped, ",") != "missing" {
+		t.Errorf("cmdRemoveTo() = %+v", res)
+	}
+}
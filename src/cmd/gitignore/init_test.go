@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/polliard/gitignore/src/pkg/source"
+)
+
+func TestPrefetchTemplates(t *testing.T) {
+	templates := make(map[string]string)
+	var types []string
+	for i := 0; i < 3*initFetchWorkers; i++ {
+		name := fmt.Sprintf("T%d", i)
+		templates[name] = "# " + name + "\n"
+		types = append(types, name)
+	}
+	types = append(types, "missing")
+
+	sm, err := source.NewSourceManager(t.TempDir(), "", false,
+		source.WithOffline(true),
+		source.WithSources(source.NewMemorySource("memory", templates)),
+	)
+	if err != nil {
+		t.Fatalf("NewSourceManager() error = %v", err)
+	}
+
+	results := prefetchTemplates(sm, types, false)
+	if len(results) != len(types) {
+		t.Fatalf("prefetchTemplates() returned %d results, want %d", len(results), len(types))
+	}
+	for name, want := range templates {
+		got := results[name]
+		if got.err != nil || got.content != want {
+			t.Errorf("results[%s] = %q, %v; want %q", name, got.content, got.err, want)
+		}
+	}
+	if results["missing"].err == nil {
+		t.Error("results[missing] should carry the lookup error")
+	}
+}
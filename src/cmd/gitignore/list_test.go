@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polliard/gitignore/src/pkg/config"
+	"github.com/polliard/gitignore/src/pkg/source"
+)
+
+func TestSourceNamesRemoteOnlyWithoutRemotes(t *testing.T) {
+	sm, err := source.NewSourceManager(t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("NewSourceManager() error = %v", err)
+	}
+	names, err := listOptions{remoteOnly: true}.sourceNames(sm)
+	if err == nil || !strings.Contains(err.Error(), "no remote sources") {
+		t.Errorf("sourceNames() = %v, %v; want an error, not every source", names, err)
+	}
+}
+
+func TestMatchesSearch(t *testing.T) {
+	tests := []struct {
+		name        string
+		displayPath string
+		pattern     string
+		want        bool
+	}{
+		{"category glob", "github/global/macos", "global/*", true},
+		{"category glob needs the category", "github/go", "global/*", false},
+		{"glob matches a trailing part", "github/community/javascript/nodejs", "*js*", true},
+		{"glob with source", "toptal/rust", "toptal/r*", true},
+		{"substring", "toptal/rust", "us", true},
+		{"substring across parts", "github/global/macos", "global/mac", true},
+		{"substring is case-insensitive", "github/global/macos", "MacOS", true},
+		{"glob is case-insensitive", "github/global/macos", "GLOBAL/*", true},
+		{"no match", "github/go", "python", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchesSearch(tt.displayPath, tt.pattern)
+			if err != nil {
+				t.Fatalf("matchesSearch(%q, %q) error = %v", tt.displayPath, tt.pattern, err)
+			}
+			if got != tt.want {
+				t.Errorf("matchesSearch(%q, %q) = %v, want %v", tt.displayPath, tt.pattern, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := matchesSearch("github/go", "[go"); err == nil {
+		t.Error("matchesSearch() should reject an invalid glob")
+	}
+}
+
+func TestWriteListTree(t *testing.T) {
+	paths := []string{
+		"github:owner/repo/community/golang/hugo",
+		"github:owner/repo/global/macos",
+		"github:owner/repo/go",
+		"local/go",
+	}
+	sources := map[string]string{
+		"github:owner/repo/community/golang/hugo": "github:owner/Repo",
+		"github:owner/repo/global/macos":          "github:owner/Repo",
+		"github:owner/repo/go":                    "github:owner/Repo",
+		"local/go":                                "local",
+	}
+	selected := map[string]string{"local/go": "go"}
+
+	var buf bytes.Buffer
+	writeListTree(&buf, paths, sources, selected, true)
+
+	want := `github:owner/repo
+├── community
+│   └── golang
+│       └── hugo
+├── global
+│   └── macos
+└── go
+local
+└── go  (selected by 'add go')
+`
+	if buf.String() != want {
+		t.Errorf("writeListTree() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestFormatListPath(t *testing.T) {
+	withCategory := map[string]string{"source": "github", "category": "global", "name": "macos"}
+	noCategory := map[string]string{"source": "github", "name": "go"}
+	tests := []struct {
+		format string
+		values map[string]string
+		want   string
+	}{
+		{"{source}:{category}:{name}", withCategory, "github:global:macos"},
+		{"{source}:{category}:{name}", noCategory, "github:go"},
+		{"{name} [{source}/{category}]", noCategory, "go [github]"},
+		{"{category}", noCategory, ""},
+		{"{name}.gitignore", withCategory, "macos.gitignore"},
+	}
+	for _, tt := range tests {
+		if got := formatListPath(tt.format, tt.values); got != tt.want {
+			t.Errorf("formatListPath(%q, %v) = %q, want %q", tt.format, tt.values, got, tt.want)
+		}
+	}
+
+	for _, format := range []string{"{source}:{nmae}", "{name"} {
+		if err := checkListFormat(format); err == nil {
+			t.Errorf("checkListFormat(%q) should fail", format)
+		}
+	}
+}
+
+func TestListInstalled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	saved := globals
+	t.Cleanup(func() { globals = saved })
+	globals.path = path
+
+	content := "### START: Go\n# Added by gitignore from github/go on 2024-01-02\n*.exe\n### END: Go\n\n" +
+		"### START: Python\n*.pyc\n### END: Python\n\n### START: ignored/dist\ndist/\n### END: ignored/dist\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+
+	var buf bytes.Buffer
+	res, err := cmdListTo(&buf, cfg, listOptions{installed: true})
+	if err != nil {
+		t.Fatalf("cmdListTo(--installed) error = %v", err)
+	}
+	if len(res.Templates) != 2 || res.Templates[0].Path != "Go" || res.Templates[1].Path != "Python" {
+		t.Fatalf("cmdListTo(--installed) = %+v", res.Templates)
+	}
+	if res.Templates[0].Origin != "github/go" || res.Templates[0].Source != "github" {
+		t.Errorf("Go entry = %+v, want origin github/go from source github", res.Templates[0])
+	}
+	if want := "Go  (from github/go)\nPython\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if _, err := cmdListTo(&buf, cfg, listOptions{installed: true, search: "py*", json: true}); err != nil {
+		t.Fatalf("cmdListTo(--installed --json) error = %v", err)
+	}
+	var entries []ListEntry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+	if len(entries) != 1 || entries[0].Path != "Python" {
+		t.Errorf("JSON entries = %+v, want only Python", entries)
+	}
+
+	if _, err := cmdListTo(&buf, cfg, listOptions{installed: true, available: true}); err == nil {
+		t.Error("--installed with --available should fail")
+	}
+	if _, err := cmdListTo(&buf, cfg, listOptions{installed: true, tree: true}); err == nil || !strings.Contains(err.Error(), "--tree") {
+		t.Errorf("--installed with --tree error = %v", err)
+	}
+}
+
+func TestListAnnotateFollowsSourcePriority(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "Go.gitignore"), []byte("*.test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.LocalTemplatesPath = dir
+	cfg.Offline = true
+	cfg.SourcePriority = []string{"builtin", "local"}
+
+	var buf bytes.Buffer
+	res, err := cmdListTo(&buf, cfg, listOptions{search: "go", annotate: true})
+	if err != nil {
+		t.Fatalf("cmdListTo() error = %v", err)
+	}
+	selected := make(map[string]string)
+	for _, entry := range res.Templates {
+		selected[entry.Path] = entry.SelectedBy
+	}
+	if selected["builtin/go"] != "go" || selected["local/go"] != "" {
+		t.Errorf("selections = %v, want builtin/go selected by 'add go'", selected)
+	}
+	if !strings.Contains(buf.String(), "builtin/go  (selected by 'add go')") {
+		t.Errorf("output = %q, want builtin/go annotated", buf.String())
+	}
+}
@@ -1,25 +1,49 @@
+// Command gitignore manages .gitignore files from GitHub, Toptal, built-in
+// and local templates, on the command line or as an MCP server
+//
+// Each command's cmd*To function writes human output to an io.Writer and
+// returns a *Result value (ListResult, AddResult, ...) that the MCP handlers
+// and tests build on. These types belong to this binary, not to an
+// importable API; programs embedding gitignore should use the packages under
+// src/pkg instead
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
 	"runtime/debug"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/polliard/gitignore/src/pkg/config"
+	"github.com/polliard/gitignore/src/pkg/github"
 	"github.com/polliard/gitignore/src/pkg/gitignore"
+	"github.com/polliard/gitignore/src/pkg/logging"
 	"github.com/polliard/gitignore/src/pkg/source"
 )
 
 // version is set via ldflags at build time, or detected from module info
 var version = "dev"
 
+// buildTime is set via ldflags by the Makefile; otherwise the VCS commit time
+// from the build info is reported
+var buildTime = ""
+
 func getVersion() string {
 	// If version was set via ldflags, use it
 	if version != "dev" {
@@ -32,392 +56,3693 @@ func getVersion() string {
 	return version
 }
 
+// versionInfo is the build metadata printed by version --json
+type versionInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Date    string `json:"date"`
+	Go      string `json:"go"`
+}
+
+// getVersionInfo collects the version along with the VCS commit, build date
+// and Go version recorded in the binary's build info
+func getVersionInfo() versionInfo {
+	info := versionInfo{Version: getVersion(), Date: buildTime, Go: runtime.Version()}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		info.Go = build.GoVersion
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}
+
+// errNo is returned by commands that answer a question with their exit status
+// (such as has) when the answer is no; main exits with status 1 without
+// printing an error
+var errNo = errors.New("no")
+
 func main() {
 	if err := run(os.Args[1:]); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if !errors.Is(err, errNo) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		if errors.Is(err, gitignore.ErrSymlink) {
+			fmt.Fprintln(os.Stderr, "Set gitignore.follow-symlinks = true to write to the file it points to")
+		}
 		os.Exit(1)
 	}
 }
 
+// globalOptions holds flags that apply to every command
+type globalOptions struct {
+	path    string // explicit .gitignore file to operate on (--path)
+	config  string // config file to load instead of searching home (--config)
+	offline bool   // skip remote sources (--offline)
+	backup  bool   // back up .gitignore before each change (gitignore.backup)
+	follow  bool   // write through a symlinked .gitignore (gitignore.follow-symlinks)
+
+	timeout time.Duration   // deadline for the whole command (--timeout; 0 = none)
+	ctx     context.Context // network requests run under it; nil = context.Background
+
+	noWarnings bool // hide source failure warnings (--no-warnings, --quiet, -q)
+	exclude    bool // operate on .git/info/exclude instead of .gitignore (--exclude)
+
+	markers gitignore.Markers // section markers (gitignore.section.start-prefix/end-prefix)
+}
+
+// globals is populated by run before a command is dispatched
+var globals globalOptions
+
+// globalFlags are accepted anywhere on the command line; the value reports
+// whether the flag takes an argument
+var globalFlags = map[string]bool{
+	"--path":    true,
+	"--config":  true,
+	"--verbose": false,
+	"-v":        false,
+	"--debug":   false,
+	"--offline": false,
+	"--exclude": false,
+	"--timeout": true,
+
+	"--no-warnings": false,
+	"--quiet":       false,
+	"-q":            false,
+}
+
+// parseGlobalFlags removes global flags from args and records them in globals
+// Everything else, including command-specific flags, is returned unchanged
+// -v means --verbose, except on its own, where it still shows the version
+func parseGlobalFlags(args []string) ([]string, error) {
+	if len(args) == 1 && args[0] == "-v" {
+		return args, nil
+	}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		takesValue, ok := globalFlags[name]
+		if !ok {
+			rest = append(rest, arg)
+			continue
+		}
+		if takesValue && !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("flag %s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if !takesValue && hasValue {
+			return nil, fmt.Errorf("flag %s does not take a value", name)
+		}
+		switch name {
+		case "--path":
+			globals.path = value
+		case "--config":
+			globals.config = value
+		case "--verbose", "-v":
+			if logging.GetLevel() < logging.LevelVerbose {
+				logging.SetLevel(logging.LevelVerbose)
+			}
+		case "--debug":
+			logging.SetLevel(logging.LevelDebug)
+		case "--offline":
+			globals.offline = true
+		case "--exclude":
+			globals.exclude = true
+		case "--timeout":
+			timeout, err := time.ParseDuration(value)
+			if err != nil || timeout < 0 {
+				return nil, fmt.Errorf("invalid --timeout '%s': want a duration such as 30s or 2m", value)
+			}
+			globals.timeout = timeout
+		case "--no-warnings", "--quiet", "-q":
+			globals.noWarnings = true
+		}
+	}
+	return rest, nil
+}
+
+// loadConfig loads the configuration, from the --config file alone if one was
+// given (which must exist), otherwise from the usual files and environment
+func loadConfig() (*config.Config, error) {
+	if globals.config == "" {
+		return config.Load()
+	}
+	if _, err := os.Stat(globals.config); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("config file %s does not exist", globals.config)
+	}
+	return config.LoadFromPath(globals.config)
+}
+
+// newSourceManager creates a source manager from the configuration
+func newSourceManager(cfg *config.Config) (*source.SourceManager, error) {
+	sm, err := source.NewSourceManagerWithOrder(cfg.LocalTemplatesPath, templateURL(cfg), cfg.EnableToptal,
+		cfg.SourcePriority,
+		source.WithContext(commandContext()),
+		source.WithOffline(cfg.Offline),
+		source.WithGitHubContentAPI(cfg.GitHubContentAPI),
+		source.WithAliases(cfg.Aliases),
+		source.WithTemplateRef(cfg.TemplateRef),
+		source.WithTemplatePath(cfg.TemplatePath),
+		source.WithUserAgent(userAgent(cfg)),
+		source.WithHTTPProxy(cfg.Proxy()),
+		source.WithCacheDir(cfg.CacheDir),
+		source.WithBlocklist(cfg.Blocklist),
+	)
+	if err == nil {
+		sourceManagers.mu.Lock()
+		sourceManagers.list = append(sourceManagers.list, sm)
+		sourceManagers.mu.Unlock()
+	}
+	return sm, err
+}
+
+// sourceManagers records the source managers created for the command, so
+// that a --timeout can name the sources they were still waiting on
+var sourceManagers struct {
+	mu   sync.Mutex
+	list []*source.SourceManager
+}
+
+// commandContext returns the context network requests run under: the
+// --timeout deadline, if any
+func commandContext() context.Context {
+	if globals.ctx == nil {
+		return context.Background()
+	}
+	return globals.ctx
+}
+
+// pendingSources returns the sources, across every source manager created
+// for the command, whose requests were still in flight or were cut short by
+// the --timeout deadline
+func pendingSources() []string {
+	sourceManagers.mu.Lock()
+	defer sourceManagers.mu.Unlock()
+	var pending []string
+	seen := make(map[string]bool)
+	for _, sm := range sourceManagers.list {
+		for _, key := range sm.Pending() {
+			if !seen[key] {
+				seen[key] = true
+				pending = append(pending, key)
+			}
+		}
+	}
+	return pending
+}
+
+// originTemplate holds the repository chosen from the origin remote, looked
+// up once per process since checking that it exists costs a GitHub request
+var originTemplate struct {
+	once sync.Once
+	url  string // "" keeps the configured URL
+}
+
+// templateURL returns the repository URL(s) for GitHub sources: normally
+// gitignore.template.url, but with gitignore.template.url-from-remote and no
+// URL configured, the gitignore repository of the origin remote's owner
+func templateURL(cfg *config.Config) string {
+	if !cfg.TemplateURLRemote || cfg.TemplateURLSet() {
+		return cfg.TemplateURL
+	}
+	originTemplate.once.Do(func() {
+		originTemplate.url = originTemplateURL(cfg)
+	})
+	if originTemplate.url == "" {
+		return cfg.TemplateURL
+	}
+	return originTemplate.url
+}
+
+// originTemplateURL returns https://github.com/<owner>/gitignore for the
+// owner of the origin remote of the repository holding the target file, or
+// "" when there is no GitHub origin or that repository doesn't exist
+// Offline, the repository is assumed to exist, since it can't be checked
+func originTemplateURL(cfg *config.Config) string {
+	dir := "."
+	if globals.path != "" {
+		dir = filepath.Dir(globals.path)
+	}
+	remote, err := gitignore.FindOriginURL(dir)
+	if err != nil || remote == "" {
+		logging.Verbosef("template url: no origin remote found, using %s", cfg.TemplateURL)
+		return ""
+	}
+	candidate, err := github.OwnerTemplateURL(remote)
+	if err != nil {
+		logging.Verbosef("template url: origin %s is not on GitHub, using %s", remote, cfg.TemplateURL)
+		return ""
+	}
+	if cfg.Offline {
+		return candidate
+	}
+
+	client, err := github.NewClient(candidate)
+	if err != nil {
+		return ""
+	}
+	client.SetUserAgent(userAgent(cfg))
+	client.SetContext(commandContext())
+	if proxy := cfg.Proxy(); proxy != nil {
+		client.SetProxy(proxy)
+	}
+	exists, err := client.Exists()
+	switch {
+	case err != nil:
+		logging.Warnf("could not check %s: %v; using %s", candidate, err, cfg.TemplateURL)
+		return ""
+	case !exists:
+		logging.Verbosef("template url: %s not found, using %s", candidate, cfg.TemplateURL)
+		return ""
+	}
+	logging.Verbosef("template url: using %s from origin %s", candidate, remote)
+	return candidate
+}
+
+// userAgent returns the configured User-Agent, by default gitignore/<version>
+// Every GitHub and Toptal client the CLI creates is given this value, since
+// the libraries' github.DefaultUserAgent carries no version
+func userAgent(cfg *config.Config) string {
+	if cfg.UserAgent != "" {
+		return cfg.UserAgent
+	}
+	return "gitignore/" + getVersion()
+}
+
+// newManager returns a gitignore manager for the --path file if given, the
+// repository's .git/info/exclude with --exclude, otherwise for .gitignore in
+// the current directory
+func newManager(opts ...gitignore.Option) (*gitignore.Manager, error) {
+	if globals.path != "" && globals.exclude {
+		return nil, fmt.Errorf("--path and --exclude are mutually exclusive")
+	}
+
+	opts = append([]gitignore.Option{gitignore.WithFollowSymlinks(globals.follow)}, opts...)
+	var manager *gitignore.Manager
+	if globals.path != "" {
+		manager = gitignore.NewManagerWithPath(globals.path, opts...)
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current directory: %w", err)
+		}
+		if globals.exclude {
+			path, err := gitignore.FindExcludeFile(cwd)
+			if err != nil {
+				return nil, err
+			}
+			manager = gitignore.NewManagerWithPath(path, opts...)
+		} else {
+			manager = gitignore.NewManager(cwd, opts...)
+		}
+	}
+	manager.SetBackup(globals.backup)
+	if globals.markers != (gitignore.Markers{}) {
+		manager.SetMarkers(globals.markers)
+	}
+	return manager, nil
+}
+
 func run(args []string) error {
+	args, err := parseGlobalFlags(args)
+	if err != nil {
+		return err
+	}
+
 	if len(args) == 0 {
 		printUsage()
 		return nil
 	}
 
 	// Load configuration
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if globals.offline {
+		cfg.Offline = true
+	}
+	globals.backup = cfg.Backup
+	globals.follow = cfg.FollowSymlinks
+	if globals.markers, err = gitignore.NewMarkers(cfg.SectionStartPrefix, cfg.SectionEndPrefix); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if globals.timeout > 0 {
+		return runWithTimeout(cfg, args, globals.timeout)
+	}
+	return runCommand(cfg, args)
+}
+
+// runWithTimeout runs a command whose network requests are canceled once
+// the --timeout deadline passes; a command that failed, or whose requests
+// were cut short, then reports the sources it was still waiting on instead
+// of its own error
+func runWithTimeout(cfg *config.Config, args []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	globals.ctx = ctx
+	defer func() { globals.ctx = nil }()
+
+	err := runCommand(cfg, args)
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	pending := pendingSources()
+	if err == nil && len(pending) == 0 {
+		return nil
+	}
+	logging.Debugf("%s after timeout: %v", args[0], err)
+	if len(pending) > 0 {
+		return fmt.Errorf("%s timed out after %s; still waiting on %s", args[0], timeout, strings.Join(pending, ", "))
+	}
+	return fmt.Errorf("%s timed out after %s", args[0], timeout)
+}
 
+// runCommand dispatches a command once the global flags and config are
+// loaded
+func runCommand(cfg *config.Config, args []string) error {
 	// Parse command
 	cmd := args[0]
 
 	switch cmd {
 	case "--list", "-l", "list":
-		return cmdList(cfg, "")
+		_, flags, err := parseFlags(args[1:], listFlags)
+		if err != nil {
+			return err
+		}
+		return cmdList(cfg, newListOptions(flags))
+	case "categories":
+		return cmdCategories(cfg)
 	case "search", "-s":
-		if len(args) < 2 {
+		positional, flags, err := parseFlags(args[1:], listFlags)
+		if err != nil {
+			return err
+		}
+		if len(positional) < 1 {
 			return fmt.Errorf("usage: gitignore search <pattern>")
 		}
-		return cmdList(cfg, args[1])
+		opts := newListOptions(flags)
+		opts.search = positional[0]
+		return cmdList(cfg, opts)
 	case "add":
-		if len(args) < 2 {
+		positional, flags, err := parseFlags(args[1:], addFlags)
+		if err != nil {
+			return err
+		}
+		if flags["--after"] != "" && flags["--before"] != "" {
+			return fmt.Errorf("--after and --before cannot be used together")
+		}
+		if _, ok := flags["--dedupe"]; ok {
+			if _, ok := flags["--no-dedupe"]; ok {
+				return fmt.Errorf("--dedupe and --no-dedupe cannot be used together")
+			}
+		}
+		if err := checkAppendFlags(flags); err != nil {
+			return err
+		}
+		if prefix, ok := flags["--prefix"]; ok {
+			if _, err := gitignore.CleanPrefix(prefix); err != nil {
+				return err
+			}
+		}
+		if rawURL, ok := flags["--from-url"]; ok {
+			if len(positional) > 0 {
+				return fmt.Errorf("usage: gitignore add --from-url <url> [--name <name>]")
+			}
+			return cmdAddURL(cfg, rawURL, flags["--name"], newAddOptions(flags))
+		}
+		if _, ok := flags["--name"]; ok {
+			return fmt.Errorf("--name requires --from-url")
+		}
+		if len(positional) < 1 {
 			return fmt.Errorf("usage: gitignore add <type>")
 		}
-		return cmdAdd(cfg, args[1])
+		return cmdAdd(cfg, positional[0], newAddOptions(flags))
 	case "init":
-		return cmdInit(cfg)
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--suggest": false})
+		if err != nil {
+			return err
+		}
+		if _, suggest := flags["--suggest"]; suggest {
+			if len(positional) > 0 {
+				return fmt.Errorf("usage: gitignore init --suggest")
+			}
+			return cmdInitSuggest(cfg)
+		}
+		if len(positional) > 1 {
+			return fmt.Errorf("usage: gitignore init [preset] | init --suggest")
+		}
+		preset := ""
+		if len(positional) == 1 {
+			preset = positional[0]
+		}
+		return cmdInit(cfg, preset)
+	case "sync":
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--prune": false, "--dry-run": false})
+		if err != nil {
+			return err
+		}
+		if len(positional) > 0 {
+			return fmt.Errorf("usage: gitignore sync [--prune] [--dry-run]")
+		}
+		_, prune := flags["--prune"]
+		_, dryRun := flags["--dry-run"]
+		return cmdSync(cfg, prune, dryRun)
+	case "presets":
+		return cmdPresets(cfg)
+	case "config":
+		_, flags, err := parseFlags(args[1:], map[string]bool{"--json": false})
+		if err != nil {
+			return err
+		}
+		_, asJSON := flags["--json"]
+		return cmdConfig(cfg, asJSON)
 	case "delete", "rm":
-		if len(args) < 2 {
-			return fmt.Errorf("usage: gitignore delete <type>")
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--force": false, "--glob": false, "--dry-run": false})
+		if err != nil {
+			return err
 		}
-		return cmdDelete(args[1])
+		if len(positional) < 1 {
+			return fmt.Errorf("usage: gitignore delete <type> [--force] [--dry-run] | delete --glob <pattern> [--force]")
+		}
+		_, force := flags["--force"]
+		_, dryRun := flags["--dry-run"]
+		if _, glob := flags["--glob"]; glob {
+			if dryRun {
+				return fmt.Errorf("--dry-run cannot be used with --glob")
+			}
+			return cmdDeleteGlob(positional[0], force)
+		}
+		return cmdDelete(cfg, positional[0], force, dryRun)
 	case "ignore":
-		if len(args) < 2 {
-			return fmt.Errorf("usage: gitignore ignore <pattern> [pattern...]")
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--normalize": false, "--dry-run": false})
+		if err != nil {
+			return err
 		}
-		return cmdIgnore(args[1:])
+		if len(positional) < 1 {
+			return fmt.Errorf("usage: gitignore ignore <pattern> [pattern...] [--normalize] [--dry-run]")
+		}
+		_, normalize := flags["--normalize"]
+		_, dryRun := flags["--dry-run"]
+		return cmdIgnore(positional, normalize, dryRun)
 	case "remove":
-		if len(args) < 2 {
-			return fmt.Errorf("usage: gitignore remove <pattern> [pattern...]")
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--force": false, "--dry-run": false, "--section": true})
+		if err != nil {
+			return err
+		}
+		if len(positional) < 1 {
+			return fmt.Errorf("usage: gitignore remove <pattern> [pattern...] [--section <name>] [--force] [--dry-run]")
+		}
+		section, hasSection := flags["--section"]
+		if hasSection && section == "" {
+			return fmt.Errorf("--section requires a section name")
+		}
+		_, force := flags["--force"]
+		_, dryRun := flags["--dry-run"]
+		return cmdRemove(positional, section, force, dryRun)
+	case "sort":
+		return cmdSort(args[1:])
+	case "clean":
+		if len(args) > 1 {
+			return fmt.Errorf("usage: gitignore clean")
+		}
+		return cmdClean()
+	case "uninstall":
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--dry-run": false})
+		if err != nil {
+			return err
+		}
+		if len(positional) > 0 {
+			return fmt.Errorf("usage: gitignore uninstall [--dry-run]")
+		}
+		_, dryRun := flags["--dry-run"]
+		return cmdUninstall(dryRun)
+	case "diff":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: gitignore diff <type>")
+		}
+		return cmdDiff(cfg, args[1])
+	case "tidy":
+		if len(args) > 1 {
+			return fmt.Errorf("usage: gitignore tidy")
+		}
+		return cmdTidy()
+	case "new":
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--force": false, "--edit": false})
+		if err != nil {
+			return err
+		}
+		if len(positional) != 1 {
+			return fmt.Errorf("usage: gitignore new <name> [--force] [--edit]")
+		}
+		_, force := flags["--force"]
+		_, edit := flags["--edit"]
+		return cmdNew(cfg, positional[0], force, edit)
+	case "edit":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: gitignore edit <name>")
+		}
+		return cmdEdit(cfg, args[1])
+	case "sections":
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--sorted": false})
+		if err != nil {
+			return err
+		}
+		if len(positional) > 0 {
+			return fmt.Errorf("usage: gitignore sections [--sorted]")
+		}
+		_, sorted := flags["--sorted"]
+		return cmdSections(sorted)
+	case "which":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: gitignore which <type>")
+		}
+		return cmdWhich(cfg, args[1])
+	case "refresh-cache":
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--full": false, "--source": true})
+		if err != nil {
+			return err
+		}
+		if len(positional) > 0 {
+			return fmt.Errorf("usage: gitignore refresh-cache [--full] [--source <name>]")
+		}
+		_, full := flags["--full"]
+		return cmdRefreshCache(cfg, full, flags["--source"])
+	case "ls-remote":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: gitignore ls-remote <github-url>")
+		}
+		return cmdLsRemote(cfg, args[1])
+	case "stats":
+		if len(args) > 1 {
+			return fmt.Errorf("usage: gitignore stats")
+		}
+		return cmdStats()
+	case "check":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: gitignore check <path>")
 		}
-		return cmdRemove(args[1:])
+		return cmdCheck(args[1])
+	case "has":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: gitignore has <pattern>")
+		}
+		return cmdHas(args[1])
+	case "restore":
+		if len(args) > 1 {
+			return fmt.Errorf("usage: gitignore restore")
+		}
+		return cmdRestore()
+	case "move":
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--to": true})
+		if err != nil {
+			return err
+		}
+		to, ok := flags["--to"]
+		if len(positional) != 1 || !ok {
+			return fmt.Errorf("usage: gitignore move <section> --to <n>")
+		}
+		position, err := strconv.Atoi(to)
+		if err != nil {
+			return fmt.Errorf("invalid position '%s': must be a number", to)
+		}
+		return cmdMove(positional[0], position)
+	case "export":
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"-o": true, "--output": true})
+		if err != nil {
+			return err
+		}
+		if len(positional) > 0 {
+			return fmt.Errorf("usage: gitignore export [-o <file>]")
+		}
+		output := flags["-o"]
+		if v, ok := flags["--output"]; ok {
+			output = v
+		}
+		return cmdExport(output)
+	case "import":
+		positional, flags, err := parseFlags(args[1:], map[string]bool{"--detect": false})
+		if err != nil {
+			return err
+		}
+		if len(positional) > 1 {
+			return fmt.Errorf("usage: gitignore import [file] [--detect]")
+		}
+		file := ""
+		if len(positional) == 1 {
+			file = positional[0]
+		}
+		_, detect := flags["--detect"]
+		return cmdImport(file, detect)
 	case "serve":
-		return cmdServe()
+		return cmdServe(cfg)
 	case "--help", "-h", "help":
 		printUsage()
 		return nil
 	case "--version", "-v", "version":
-		fmt.Printf("gitignore version %s\n", getVersion())
-		return nil
+		_, flags, err := parseFlags(args[1:], map[string]bool{"--json": false})
+		if err != nil {
+			return err
+		}
+		_, asJSON := flags["--json"]
+		return cmdVersion(asJSON)
 	default:
 		return fmt.Errorf("unknown command: %s\nRun 'gitignore --help' for usage", cmd)
 	}
 }
 
-func cmdList(cfg *config.Config, searchPattern string) error {
-	return cmdListTo(os.Stdout, cfg, searchPattern)
+// parseFlags separates command arguments into positional arguments and flags
+// spec maps each accepted flag to whether it takes a value ("--name value" or
+// "--name=value"); boolean flags are stored with an empty value
+// Arguments after "--" are always treated as positional
+func parseFlags(args []string, spec map[string]bool) ([]string, map[string]string, error) {
+	var positional []string
+	flags := make(map[string]string)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		takesValue, known := spec[name]
+		if !known {
+			return nil, nil, fmt.Errorf("unknown flag: %s", name)
+		}
+		if !takesValue {
+			if hasValue {
+				return nil, nil, fmt.Errorf("flag %s does not take a value", name)
+			}
+			flags[name] = ""
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("flag %s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		flags[name] = value
+	}
+
+	return positional, flags, nil
+}
+
+// listFlags are the flags accepted by list and search
+var listFlags = map[string]bool{
+	"--annotate":      false,
+	"--available":     false,
+	"--category":      true,
+	"--count":         false,
+	"--format":        true,
+	"--installed":     false,
+	"--json":          false,
+	"--local-only":    false,
+	"--remote-only":   false,
+	"--source":        true,
+	"--tree":          false,
+	"--updated-since": true,
+}
+
+// listOptions controls the output of list and search
+type listOptions struct {
+	search     string // case-insensitive substring or glob filter
+	annotate   bool   // mark entries that 'add <name>' would select
+	category   string // only list templates in this category (and below it)
+	count      bool   // print only the number of matching paths
+	format     string // output path template, e.g. {source}:{category}:{name} ("" = slash-separated)
+	installed  bool   // list the sections in .gitignore instead of the catalog (--installed)
+	available  bool   // list the catalog, the default (--available)
+	json       bool   // print results as JSON
+	localOnly  bool   // only query the local source
+	remoteOnly bool   // only query remote sources
+	source     string // only query this source
+	tree       bool   // print results as a tree by source and category
+	updated    string // only list GitHub templates changed upstream since this date
+}
+
+// newListOptions builds listOptions from parsed list/search flags
+func newListOptions(flags map[string]string) listOptions {
+	_, annotate := flags["--annotate"]
+	_, count := flags["--count"]
+	_, asJSON := flags["--json"]
+	_, installed := flags["--installed"]
+	_, available := flags["--available"]
+	_, localOnly := flags["--local-only"]
+	_, remoteOnly := flags["--remote-only"]
+	_, tree := flags["--tree"]
+	return listOptions{
+		annotate:   annotate,
+		category:   flags["--category"],
+		count:      count,
+		format:     flags["--format"],
+		installed:  installed,
+		available:  available,
+		json:       asJSON,
+		localOnly:  localOnly,
+		remoteOnly: remoteOnly,
+		source:     flags["--source"],
+		tree:       tree,
+		updated:    flags["--updated-since"],
+	}
+}
+
+// sourceNames returns the sources to query for these options (nil means all)
+func (o listOptions) sourceNames(sm *source.SourceManager) ([]string, error) {
+	scopes := 0
+	for _, set := range []bool{o.localOnly, o.remoteOnly, o.source != ""} {
+		if set {
+			scopes++
+		}
+	}
+	if scopes > 1 {
+		return nil, fmt.Errorf("--local-only, --remote-only and --source are mutually exclusive")
+	}
+
+	switch {
+	case o.localOnly:
+		return []string{sm.LocalSource().Name()}, nil
+	case o.remoteOnly:
+		var names []string
+		for _, src := range sm.RemoteSources() {
+			names = append(names, sm.SourceKey(src))
+		}
+		if len(names) == 0 {
+			// nil would mean every source, local included
+			return nil, fmt.Errorf("--remote-only: no remote sources configured")
+		}
+		return names, nil
+	case o.source != "":
+		if !sm.HasSource(o.source) {
+			return nil, fmt.Errorf("unknown source: %s (configured: %s)", o.source, strings.Join(sm.SourceNames(), ", "))
+		}
+		return []string{o.source}, nil
+	}
+	return nil, nil
+}
+
+// sourceLocation returns where a source reads templates from (a directory or
+// URL), or "" if it has no location
+func sourceLocation(src source.Source) string {
+	switch s := src.(type) {
+	case *source.LocalSource:
+		return s.Dir()
+	case *source.ToptalSource:
+		return s.BaseURL()
+	case interface{ URL() string }:
+		return s.URL()
+	}
+	return ""
+}
+
+// writeNoTemplates explains an empty template list by showing where each
+// source looked and what happened there, so a wrong templates path or URL
+// is easy to spot
+func writeNoTemplates(w io.Writer, sm *source.SourceManager, filesBySource map[string]source.SourceResult) {
+	fmt.Fprintln(w, "No templates available. Sources checked:")
+	for _, src := range sm.AllSources() {
+		key := sm.SourceKey(src)
+		location := sourceLocation(src)
+
+		status := "no templates"
+		result, queried := filesBySource[key]
+		switch {
+		case !queried && sm.Offline() && src != source.Source(sm.LocalSource()):
+			status = "skipped, offline"
+		case !queried:
+			status = "not queried"
+		case result.Error != nil:
+			status = "failed"
+		}
+
+		if location == "" {
+			fmt.Fprintf(w, "  %s (%s)\n", key, status)
+			continue
+		}
+		fmt.Fprintf(w, "  %s: %s (%s)\n", key, location, status)
+	}
+}
+
+// listFailures returns a *source.SourcesError naming the sources that
+// failed to list, in priority order, or nil if none did
+// cmdListTo only reports it when nothing was found, since a failing source
+// may be what hid the templates
+func listFailures(sm *source.SourceManager, filesBySource map[string]source.SourceResult) error {
+	var failures []source.SourceError
+	for _, src := range sm.AllSources() {
+		key := sm.SourceKey(src)
+		if result, ok := filesBySource[key]; ok && result.Error != nil {
+			failures = append(failures, source.SourceError{Source: key, Err: result.Error})
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return &source.SourcesError{
+		Err:    fmt.Errorf("no templates found; %d of %d sources could not be listed", len(failures), len(filesBySource)),
+		Errors: failures,
+	}
+}
+
+func cmdList(cfg *config.Config, opts listOptions) error {
+	_, err := cmdListTo(os.Stdout, cfg, opts)
+	return err
+}
+
+// ListResult is the outcome of list and search
+type ListResult struct {
+	Templates []ListEntry `json:"templates"` // matching templates, sorted by path
+}
+
+func cmdListTo(w io.Writer, cfg *config.Config, opts listOptions) (ListResult, error) {
+	var res ListResult
+	searchPattern := opts.search
+	if opts.installed {
+		if opts.available {
+			return res, fmt.Errorf("--installed and --available are mutually exclusive")
+		}
+		return listInstalled(w, opts)
+	}
+
+	// Create source manager
+	sm, err := newSourceManager(cfg)
+	if err != nil {
+		return res, fmt.Errorf("failed to create source manager: %w", err)
+	}
+
+	names, err := opts.sourceNames(sm)
+	if err != nil {
+		return res, err
+	}
+	if opts.tree && (opts.json || opts.count) {
+		return res, fmt.Errorf("--tree cannot be combined with --json or --count")
+	}
+	if opts.format != "" {
+		if opts.tree || opts.json {
+			return res, fmt.Errorf("--format cannot be combined with --tree or --json")
+		}
+		if err := checkListFormat(opts.format); err != nil {
+			return res, err
+		}
+	}
+	var since time.Time
+	if opts.updated != "" {
+		if opts.tree {
+			return res, fmt.Errorf("--tree cannot be combined with --updated-since")
+		}
+		if sm.Offline() {
+			return res, fmt.Errorf("--updated-since needs network access to query GitHub commits")
+		}
+		if since, err = parseSince(opts.updated); err != nil {
+			return res, err
+		}
+	}
+
+	// Get all files grouped by source, querying only the requested sources
+	filesBySource, err := sm.ListBySourceFrom(names)
+	if err != nil {
+		return res, fmt.Errorf("failed to list templates: %w", err)
+	}
+	if opts.category != "" {
+		for key, result := range filesBySource {
+			result.Files = source.FilterCategory(result.Files, opts.category)
+			filesBySource[key] = result
+		}
+	}
+
+	// Build flat list of all template paths
+	var allPaths []string
+	var warnings []string
+	pathSources := make(map[string]string)            // path -> source key
+	pathFiles := make(map[string]source.TemplateFile) // path -> template
+
+	// Sources are processed in priority order, so the first path seen for a
+	// template name is the one 'add <name>' resolves to
+	selected := make(map[string]string) // path -> name that selects it
+	seenNames := make(map[string]bool)
+	markSelected := func(path, name string) {
+		nameLower := strings.ToLower(name)
+		if !seenNames[nameLower] {
+			seenNames[nameLower] = true
+			selected[path] = nameLower
+		}
+	}
+
+	// Process templates from every source, local included, in the order
+	// gitignore.source-priority gives
+	for _, src := range sm.AllSources() {
+		key := sm.SourceKey(src)
+		result, ok := filesBySource[key]
+		if !ok {
+			continue
+		}
+
+		if result.Error != nil {
+			msg := fmt.Sprintf("⚠️  %s: %v", formatSourceName(src.Name()), result.Error)
+			if src == source.Source(sm.LocalSource()) {
+				msg = fmt.Sprintf("⚠️  Local templates: %v (path: %s)", result.Error, sm.LocalSource().Dir())
+			} else if gs, ok := src.(interface{ URL() string }); ok && src.Name() == "github" {
+				msg += fmt.Sprintf(" (url: %s)", gs.URL())
+			}
+			warnings = append(warnings, msg)
+			continue
+		}
+
+		for _, file := range result.Files {
+			var path string
+			if file.Category == "" {
+				path = fmt.Sprintf("%s/%s", strings.ToLower(key), strings.ToLower(file.Name))
+			} else {
+				path = fmt.Sprintf("%s/%s/%s", strings.ToLower(key), strings.ToLower(file.Category), strings.ToLower(file.Name))
+			}
+			allPaths = append(allPaths, path)
+			pathSources[path] = key
+			pathFiles[path] = file
+			markSelected(path, file.Name)
+		}
+	}
+
+	// Sort all paths alphabetically
+	sort.Strings(allPaths)
+
+	// Filter by search pattern if provided
+	if searchPattern != "" {
+		var filtered []string
+		for _, p := range allPaths {
+			matched, err := matchesSearch(p, searchPattern)
+			if err != nil {
+				return res, err
+			}
+			if matched {
+				filtered = append(filtered, p)
+			}
+		}
+		allPaths = filtered
+	}
+
+	// Keep only GitHub templates changed since the given date
+	updated := make(map[string]time.Time) // path -> last commit
+	if opts.updated != "" {
+		var failed int
+		var firstErr error
+		allPaths, failed, firstErr = filterUpdatedSince(cfg, sm, allPaths, pathSources, pathFiles, since, updated)
+		if failed > 0 {
+			warnings = append(warnings, fmt.Sprintf("⚠️  Could not get the last commit of %d template(s): %v", failed, firstErr))
+		}
+	}
+
+	// Print warnings first (always to stderr, unless suppressed)
+	if globals.noWarnings {
+		warnings = nil
+	}
+	for _, warn := range warnings {
+		fmt.Fprintln(os.Stderr, warn)
+	}
+	if len(warnings) > 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	res.Templates = make([]ListEntry, 0, len(allPaths))
+	for _, p := range allPaths {
+		entry := ListEntry{Path: p, Source: pathSources[p], SelectedBy: selected[p]}
+		if date, ok := updated[p]; ok {
+			entry.Updated = date.UTC().Format(time.RFC3339)
+		}
+		res.Templates = append(res.Templates, entry)
+	}
+
+	if opts.json {
+		return res, writeListJSON(w, res.Templates, opts.count)
+	}
+
+	if opts.count {
+		fmt.Fprintln(w, len(allPaths))
+		return res, nil
+	}
+
+	// Print paths
+	if len(allPaths) == 0 {
+		if searchPattern != "" {
+			fmt.Fprintf(w, "No templates matching '%s'\n", searchPattern)
+		} else {
+			writeNoTemplates(w, sm, filesBySource)
+		}
+		return res, listFailures(sm, filesBySource)
+	}
+
+	if opts.tree {
+		writeListTree(w, allPaths, pathSources, selected, opts.annotate)
+		return res, nil
+	}
+
+	for _, path := range allPaths {
+		line := path
+		if opts.format != "" {
+			file := pathFiles[path]
+			line = formatListPath(opts.format, map[string]string{
+				"source":   strings.ToLower(pathSources[path]),
+				"category": strings.ToLower(file.Category),
+				"name":     strings.ToLower(file.Name),
+			})
+		}
+		if date, ok := updated[path]; ok {
+			line += fmt.Sprintf("  (updated %s)", date.UTC().Format(time.DateOnly))
+		}
+		if name, ok := selected[path]; ok && opts.annotate {
+			line += fmt.Sprintf("  (selected by 'add %s')", name)
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	return res, nil
+}
+
+// listFormatFields are the placeholders list --format accepts
+var listFormatFields = []string{"source", "category", "name"}
+
+// checkListFormat rejects a list --format with an unknown or unterminated
+// placeholder
+func checkListFormat(format string) error {
+	for rest := format; ; {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			return nil
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return fmt.Errorf("unterminated placeholder in --format '%s'", format)
+		}
+		field := rest[start+1 : start+end]
+		known := false
+		for _, f := range listFormatFields {
+			known = known || field == f
+		}
+		if !known {
+			return fmt.Errorf("unknown placeholder {%s} in --format (use {source}, {category} and {name})", field)
+		}
+		rest = rest[start+end+1:]
+	}
+}
+
+// formatListPath fills the placeholders of a list --format with values
+// A placeholder whose value is empty, such as {category} for a template
+// without one, is dropped together with the separator after it (or, at the
+// end, the one before it), so {source}:{category}:{name} gives github:go
+func formatListPath(format string, values map[string]string) string {
+	// Split into alternating literals and placeholders, literals first
+	var parts []string
+	for rest := format; ; {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest[max(start, 0):], '}')
+		if start < 0 || end < 0 {
+			parts = append(parts, rest)
+			break
+		}
+		parts = append(parts, rest[:start], rest[start+1:start+end])
+		rest = rest[start+end+1:]
+	}
+
+	for i := 1; i < len(parts); i += 2 {
+		value := values[parts[i]]
+		parts[i] = value
+		if value != "" {
+			continue
+		}
+		if i+2 < len(parts) {
+			parts[i+1] = "" // the separator before the next placeholder
+		} else if i > 1 {
+			parts[i-1] = "" // the last placeholder takes the one before it
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// parseSince parses an --updated-since date, either YYYY-MM-DD (midnight
+// UTC) or an RFC 3339 timestamp
+func parseSince(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --updated-since date '%s' (use YYYY-MM-DD or an RFC 3339 timestamp)", value)
+	}
+	return t, nil
+}
+
+// filterUpdatedSince returns the paths of templates from sources that report
+// commit dates (see source.CommitDater) whose last commit is at or after
+// since, recording each date in updated; other templates are dropped
+// Dates are looked up concurrently and reused from the cache directory for
+// source.CommitDateTTL; it also returns how many lookups failed and the
+// first failure
+func filterUpdatedSince(cfg *config.Config, sm *source.SourceManager, paths []string, sources map[string]string, files map[string]source.TemplateFile, since time.Time, updated map[string]time.Time) ([]string, int, error) {
+	var cache *source.Cache
+	if cfg.CacheDir != "" {
+		cache = source.NewCache(cfg.CacheDir)
+	}
+
+	bySource := make(map[string][]source.TemplateFile)
+	for _, p := range paths {
+		if file, ok := files[p]; ok {
+			bySource[sources[p]] = append(bySource[sources[p]], file)
+		}
+	}
+
+	dates := make(map[string]map[string]time.Time) // source key -> template path -> date
+	failed := 0
+	var firstErr error
+	for _, src := range sm.RemoteSources() {
+		key := sm.SourceKey(src)
+		if _, ok := src.(source.CommitDater); !ok || len(bySource[key]) == 0 {
+			continue
+		}
+		found, failures, err := source.LastCommitDates(src, bySource[key], cache)
+		if err != nil {
+			return nil, 0, err
+		}
+		dates[key] = found
+		if len(failures) > 0 && firstErr == nil {
+			firstErr = failures[0]
+		}
+		failed += len(failures)
+	}
+
+	var kept []string
+	for _, p := range paths {
+		date, ok := dates[sources[p]][files[p].Path]
+		if ok && !date.Before(since) {
+			updated[p] = date
+			kept = append(kept, p)
+		}
+	}
+	return kept, failed, firstErr
+}
+
+// treeNode is a source, category or template in list --tree output
+type treeNode struct {
+	children map[string]*treeNode
+	path     string // list path when the node is a template
+}
+
+// child returns the named child, creating it if needed
+func (n *treeNode) child(name string) *treeNode {
+	if n.children == nil {
+		n.children = make(map[string]*treeNode)
+	}
+	c, ok := n.children[name]
+	if !ok {
+		c = &treeNode{}
+		n.children[name] = c
+	}
+	return c
+}
+
+// writeListTree prints paths nested by source, then category, with template
+// names as leaves; nested categories such as community/golang get a level
+// each
+func writeListTree(w io.Writer, paths []string, sources, selected map[string]string, annotate bool) {
+	root := &treeNode{}
+	for _, p := range paths {
+		// The source key may itself contain a slash (github:owner/repo)
+		key := strings.ToLower(sources[p])
+		node := root.child(key)
+		for _, part := range strings.Split(strings.TrimPrefix(p, key+"/"), "/") {
+			node = node.child(part)
+		}
+		node.path = p
+	}
+
+	for _, key := range sortedKeys(root.children) {
+		fmt.Fprintln(w, key)
+		writeTreeChildren(w, root.children[key], "", selected, annotate)
+	}
+}
+
+// writeTreeChildren prints the children of a node with box-drawing branches
+func writeTreeChildren(w io.Writer, node *treeNode, indent string, selected map[string]string, annotate bool) {
+	names := sortedKeys(node.children)
+	for i, name := range names {
+		child := node.children[name]
+		branch, next := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		line := indent + branch + name
+		if by, ok := selected[child.path]; ok && annotate && child.path != "" {
+			line += fmt.Sprintf("  (selected by 'add %s')", by)
+		}
+		fmt.Fprintln(w, line)
+		writeTreeChildren(w, child, indent+next, selected, annotate)
+	}
+}
+
+// sortedKeys returns the keys of a tree level in sorted order
+func sortedKeys(children map[string]*treeNode) []string {
+	keys := make([]string, 0, len(children))
+	for k := range children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ListEntry is one template in list and search results and --json output
+type ListEntry struct {
+	Path       string `json:"path"`
+	Source     string `json:"source"`
+	SelectedBy string `json:"selected_by,omitempty"` // name 'add' resolves to this path
+	Updated    string `json:"updated,omitempty"`     // last upstream commit, with --updated-since
+	Origin     string `json:"origin,omitempty"`      // with --installed, where the section came from, from its header
+}
+
+// listInstalled lists the templates already in .gitignore, one managed
+// section per name in file order, instead of the catalog; sections from
+// 'ignore' hold patterns rather than templates and are left out
+// No source is queried, so it works offline
+func listInstalled(w io.Writer, opts listOptions) (ListResult, error) {
+	var res ListResult
+	for flag, set := range map[string]bool{
+		"--annotate":      opts.annotate,
+		"--category":      opts.category != "",
+		"--format":        opts.format != "",
+		"--local-only":    opts.localOnly,
+		"--remote-only":   opts.remoteOnly,
+		"--source":        opts.source != "",
+		"--tree":          opts.tree,
+		"--updated-since": opts.updated != "",
+	} {
+		if set {
+			return res, fmt.Errorf("--installed cannot be combined with %s", flag)
+		}
+	}
+
+	manager, err := newManager()
+	if err != nil {
+		return res, err
+	}
+	sections, err := manager.ListSections()
+	if err != nil {
+		return res, err
+	}
+	res.Templates = []ListEntry{}
+	seen := make(map[string]bool)
+	for _, name := range sections {
+		if seen[name] || strings.HasPrefix(name, gitignore.IgnoredSectionPrefix) {
+			continue
+		}
+		seen[name] = true
+		if opts.search != "" {
+			matched, err := matchesSearch(strings.ToLower(name), opts.search)
+			if err != nil {
+				return res, err
+			}
+			if !matched {
+				continue
+			}
+		}
+		entry := ListEntry{Path: name}
+		if body, err := manager.GetSection(name); err == nil && strings.HasPrefix(body, gitignore.HeaderPrefix) {
+			header, _, _ := strings.Cut(strings.TrimPrefix(body, gitignore.HeaderPrefix), "\n")
+			entry.Origin, _, _ = strings.Cut(header, " on ")
+			entry.Source, _, _ = strings.Cut(entry.Origin, "/")
+			if strings.Contains(entry.Source, ":") {
+				entry.Source = "" // a URL added with --from-url
+			}
+		}
+		res.Templates = append(res.Templates, entry)
+	}
+
+	switch {
+	case opts.json:
+		return res, writeListJSON(w, res.Templates, opts.count)
+	case opts.count:
+		fmt.Fprintln(w, len(res.Templates))
+	case len(res.Templates) == 0 && opts.search != "":
+		fmt.Fprintf(w, "No sections in .gitignore matching '%s'\n", opts.search)
+	case len(res.Templates) == 0:
+		fmt.Fprintln(w, "No templates in .gitignore; see 'gitignore list' for what's available")
+	default:
+		for _, entry := range res.Templates {
+			if entry.Origin != "" {
+				fmt.Fprintf(w, "%s  (from %s)\n", entry.Path, entry.Origin)
+			} else {
+				fmt.Fprintln(w, entry.Path)
+			}
+		}
+	}
+	return res, nil
+}
+
+// writeListJSON prints entries as a JSON array, or as {"count": n} when
+// count is set
+func writeListJSON(w io.Writer, entries []ListEntry, count bool) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if count {
+		return enc.Encode(struct {
+			Count int `json:"count"`
+		}{len(entries)})
+	}
+	return enc.Encode(entries)
+}
+
+func cmdCategories(cfg *config.Config) error {
+	_, err := cmdCategoriesTo(os.Stdout, cfg)
+	return err
+}
+
+// CategoriesResult is the outcome of categories
+type CategoriesResult struct {
+	Categories []string `json:"categories"` // source/category paths, by source priority
+}
+
+// cmdCategoriesTo lists the distinct categories of each source as
+// source/category paths, the prefix of the paths shown by list
+func cmdCategoriesTo(w io.Writer, cfg *config.Config) (CategoriesResult, error) {
+	var res CategoriesResult
+	sm, err := newSourceManager(cfg)
+	if err != nil {
+		return res, fmt.Errorf("failed to create source manager: %w", err)
+	}
+
+	filesBySource, err := sm.ListBySource()
+	if err != nil {
+		return res, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	var paths []string
+	for _, src := range sm.AllSources() {
+		key := sm.SourceKey(src)
+		result, ok := filesBySource[key]
+		if !ok {
+			continue
+		}
+		if result.Error != nil {
+			if !globals.noWarnings {
+				fmt.Fprintf(os.Stderr, "⚠️  %s: %v\n", formatSourceName(src.Name()), result.Error)
+			}
+			continue
+		}
+		for _, category := range source.Categories(result.Files) {
+			paths = append(paths, fmt.Sprintf("%s/%s", strings.ToLower(key), strings.ToLower(category)))
+		}
+	}
+
+	res.Categories = paths
+	if len(paths) == 0 {
+		fmt.Fprintln(w, "No categories available")
+		return res, nil
+	}
+	for _, path := range paths {
+		fmt.Fprintln(w, path)
+	}
+	return res, nil
+}
+
+// formatSourceName returns a human-readable source name
+func formatSourceName(source string) string {
+	switch source {
+	case "local":
+		return "Local"
+	case "github":
+		return "GitHub"
+	case "toptal":
+		return "Toptal"
+	default:
+		return source
+	}
+}
+
+// addFlags are the flags accepted by add
+var addFlags = map[string]bool{
+	"--sort":           false,
+	"--minimal":        false,
+	"--replace":        false,
+	"--upsert":         false,
+	"--from-url":       true,
+	"--name":           true,
+	"--after":          true,
+	"--before":         true,
+	"--append-to":      true,
+	"--create":         false,
+	"--merge":          false,
+	"--dry-run":        false,
+	"--merge-includes": false,
+	"--dedupe":         false,
+	"--no-dedupe":      false,
+	"--prefix":         true,
+}
+
+// checkAppendFlags rejects --append-to combinations that make no sense
+func checkAppendFlags(flags map[string]string) error {
+	if _, merge := flags["--merge"]; merge {
+		for _, flag := range []string{"--replace", "--upsert", "--append-to", "--from-url", "--prefix"} {
+			if _, ok := flags[flag]; ok {
+				return fmt.Errorf("%s cannot be used with --merge", flag)
+			}
+		}
+	}
+	appendTo, ok := flags["--append-to"]
+	if !ok {
+		if _, create := flags["--create"]; create {
+			return fmt.Errorf("--create requires --append-to")
+		}
+		return nil
+	}
+	if strings.TrimSpace(appendTo) == "" {
+		return fmt.Errorf("--append-to requires a section name")
+	}
+	for _, flag := range []string{"--replace", "--upsert"} {
+		if _, ok := flags[flag]; ok {
+			return fmt.Errorf("%s cannot be used with --append-to", flag)
+		}
+	}
+	return nil
+}
+
+// addOptions controls how add writes a template
+type addOptions struct {
+	sort          bool   // sort patterns within the new section
+	minimal       bool   // drop the template's comments and blank lines
+	replace       bool   // overwrite the section if it already exists (--replace or --upsert)
+	after         string // place a new section after this one (--after)
+	before        string // place a new section before this one (--before)
+	dryRun        bool   // show the change instead of writing it (--dry-run)
+	appendTo      string // merge into this existing section instead (--append-to)
+	create        bool   // with appendTo, create the section if it is missing (--create)
+	merge         bool   // merge into a section for the same template from another source (--merge)
+	mergeIncludes bool   // put gitignore.includes templates in the template's own section (--merge-includes)
+	dedupe        *bool  // leave out patterns already in the file (--dedupe, --no-dedupe); nil uses gitignore.dedupe-on-add
+	prefix        string // scope the patterns to this directory (--prefix)
+}
+
+// newAddOptions builds addOptions from parsed add flags
+func newAddOptions(flags map[string]string) addOptions {
+	_, sortPatterns := flags["--sort"]
+	_, replace := flags["--replace"]
+	_, upsert := flags["--upsert"]
+	_, minimal := flags["--minimal"]
+	_, dryRun := flags["--dry-run"]
+	_, create := flags["--create"]
+	_, merge := flags["--merge"]
+	_, mergeIncludes := flags["--merge-includes"]
+	var dedupe *bool
+	if _, on := flags["--dedupe"]; on {
+		dedupe = &on
+	} else if _, off := flags["--no-dedupe"]; off {
+		dedupe = new(bool)
+	}
+	return addOptions{
+		sort:          sortPatterns,
+		minimal:       minimal,
+		replace:       replace || upsert,
+		after:         flags["--after"],
+		before:        flags["--before"],
+		dryRun:        dryRun,
+		appendTo:      flags["--append-to"],
+		create:        create,
+		merge:         merge,
+		mergeIncludes: mergeIncludes,
+		dedupe:        dedupe,
+		prefix:        flags["--prefix"],
+	}
+}
+
+// scopeSection applies --prefix to a section about to be added: its
+// patterns are rewritten to apply only under the prefix, and its name
+// gets the prefix appended, e.g. "Node (frontend/)", so it can sit next to
+// an unscoped section of the same template
+func (o addOptions) scopeSection(sectionName, content string) (string, string, error) {
+	if o.prefix == "" {
+		return sectionName, content, nil
+	}
+	prefix, err := gitignore.CleanPrefix(o.prefix)
+	if err != nil {
+		return "", "", err
+	}
+	content, err = gitignore.PrefixContent(content, prefix)
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("%s (%s/)", sectionName, prefix), content, nil
+}
+
+// dedupeOn reports whether patterns already in the file are left out of the
+// new section: --dedupe or --no-dedupe if given, else gitignore.dedupe-on-add
+func (o addOptions) dedupeOn(cfg *config.Config) bool {
+	if o.dedupe != nil {
+		return *o.dedupe
+	}
+	return cfg.DedupeOnAdd
+}
+
+func cmdAdd(cfg *config.Config, templateType string, opts addOptions) error {
+	_, err := cmdAddTo(os.Stdout, cfg, templateType, opts)
+	return err
+}
+
+// AddResult is the outcome of add
+// At most one of Created, Replaced and Appended is set; none means the file
+// already had everything
+type AddResult struct {
+	Path     string   `json:"path"`               // the file written (or, with --dry-run, not written)
+	Section  string   `json:"section"`            // section the template went into
+	Source   string   `json:"source"`             // where the template came from, e.g. github/go or a URL
+	Created  bool     `json:"created"`            // a new section was added
+	Replaced bool     `json:"replaced"`           // an existing section's content was replaced
+	Appended []string `json:"appended,omitempty"` // lines merged into an existing section
+	Included []string `json:"included,omitempty"` // gitignore.includes templates added with it
+	Skipped  []string `json:"skipped,omitempty"`  // includes already in the file
+	Deduped  []string `json:"deduped,omitempty"`  // patterns left out because the file already had them
+}
+
+// cmdAddTo adds a template as a section, first adding the templates it
+// pulls in through gitignore.includes as sections of their own (or, with
+// opts.mergeIncludes, as part of its section); includes already in the
+// file are skipped
+func cmdAddTo(w io.Writer, cfg *config.Config, templateType string, opts addOptions) (AddResult, error) {
+	// Create source manager
+	sm, err := newSourceManager(cfg)
+	if err != nil {
+		return AddResult{}, fmt.Errorf("failed to create source manager: %w", err)
+	}
+	includes, err := cfg.ExpandIncludes(templateType)
+	if err != nil {
+		return AddResult{}, err
+	}
+
+	// GetAny handles source prefixes automatically (e.g., "github/rust" vs "rust")
+	file, content, err := templateContent(sm, templateType, opts)
+	if err != nil {
+		return AddResult{}, err
+	}
+
+	// Create section name (include category if present)
+	sectionName := file.Name
+	if file.Category != "" {
+		sectionName = file.Category + "/" + file.Name
+	}
+
+	// Add to gitignore
+	manager, err := newManager(gitignore.WithDedupe(opts.dedupeOn(cfg)))
+	if err != nil {
+		return AddResult{}, err
+	}
+	// One dry run covers the includes and the template
+	manager.SetDryRun(opts.dryRun)
+	var included, skipped, merged []string
+	for _, include := range includes {
+		incFile, incContent, err := templateContent(sm, include, opts)
+		if err != nil {
+			return AddResult{}, fmt.Errorf("failed to add '%s', included by '%s': %w", include, templateType, err)
+		}
+		incSection := incFile.Name
+		if incFile.Category != "" {
+			incSection = incFile.Category + "/" + incFile.Name
+		}
+		existing, err := sameTemplateSection(manager, incFile.Name, incSection)
+		if err != nil {
+			return AddResult{}, err
+		}
+		incPath := templateDisplayPath(incFile)
+		switch {
+		case existing != "":
+			fmt.Fprintf(w, "Skipped '%s' (already in .gitignore as '%s')\n", incPath, existing)
+			skipped = append(skipped, incPath)
+			continue
+		case opts.mergeIncludes:
+			merged = append(merged, fmt.Sprintf("# Included from %s\n%s", incPath, strings.TrimSpace(incContent)))
+		default:
+			incSection, incContent, err = opts.scopeSection(incSection, incContent)
+			if err != nil {
+				return AddResult{}, err
+			}
+			if _, err := writeSection(w, cfg, manager, incSection, incPath, incContent, addOptions{dedupe: opts.dedupe, prefix: opts.prefix}, refNote(incFile)); err != nil {
+				return AddResult{}, err
+			}
+		}
+		included = append(included, incPath)
+	}
+	if len(merged) > 0 {
+		content = strings.Join(append(merged, strings.TrimSpace(content)), "\n\n") + "\n"
+	}
+	sectionName, content, err = opts.scopeSection(sectionName, content)
+	if err != nil {
+		return AddResult{}, err
+	}
+
+	res, err := addTemplate(w, cfg, manager, file, sectionName, content, opts)
+	res.Included, res.Skipped = included, skipped
+	if err != nil || !opts.dryRun {
+		return res, err
+	}
+	return res, writeDryRun(w, manager)
+}
+
+// templateContent fetches a template for add, with --minimal, --sort and any
+// local patch applied
+func templateContent(sm *source.SourceManager, templateType string, opts addOptions) (*source.TemplateFile, string, error) {
+	file, content, err := sm.GetAny(templateType)
+	if err != nil {
+		return nil, "", err
+	}
+	if opts.minimal {
+		content = gitignore.MinimalContent(content)
+	}
+	if opts.sort {
+		content = gitignore.SortContent(content)
+	}
+	content, err = sm.ApplyPatch(file, content)
+	if err != nil {
+		return nil, "", err
+	}
+	return file, content, nil
+}
+
+// addTemplate writes a fetched template as sectionName, honoring --merge
+// and --replace for a section of the same template from another source
+// A section scoped with --prefix only ever matches itself
+func addTemplate(w io.Writer, cfg *config.Config, manager *gitignore.Manager, file *source.TemplateFile, sectionName, content string, opts addOptions) (AddResult, error) {
+	if opts.appendTo == "" && opts.prefix == "" {
+		existing, err := sameTemplateSection(manager, file.Name, sectionName)
+		if err != nil {
+			return AddResult{}, err
+		}
+		switch {
+		case existing != "" && opts.merge:
+			opts.appendTo = existing
+		case existing != "" && opts.replace:
+			sectionName = existing
+		case existing != "" && existing != sectionName:
+			return AddResult{}, fmt.Errorf("'%s' is the same template as section '%s' already in .gitignore; pass --merge to add its new patterns to '%s', or --replace to replace it",
+				templateDisplayPath(file), existing, existing)
+		}
+	}
+	return writeSection(w, cfg, manager, sectionName, templateDisplayPath(file), content, opts, refNote(file))
+}
+
+// sameTemplateSection returns the section already holding the template named
+// name, preferring sectionName itself, or "" if there is none
+// Sources spell and categorize names differently (GitHub's "Go", Toptal's
+// "go"), so a section matches when its last path element equals name
+// ignoring case; ignore patterns never match
+func sameTemplateSection(manager *gitignore.Manager, name, sectionName string) (string, error) {
+	sections, err := manager.ListSections()
+	if err != nil {
+		return "", err
+	}
+	match := ""
+	for _, section := range sections {
+		if section == sectionName {
+			return section, nil
+		}
+		if match == "" && !strings.HasPrefix(section, gitignore.IgnoredSectionPrefix) && strings.EqualFold(path.Base(section), name) {
+			match = section
+		}
+	}
+	return match, nil
+}
+
+func cmdAddURL(cfg *config.Config, rawURL, name string, opts addOptions) error {
+	_, err := cmdAddURLTo(os.Stdout, cfg, rawURL, name, opts)
+	return err
+}
+
+// cmdAddURLTo downloads a template from a raw URL and adds it as a section
+// named name (by default the file name without .gitignore), bypassing the
+// configured sources
+func cmdAddURLTo(w io.Writer, cfg *config.Config, rawURL, name string, opts addOptions) (AddResult, error) {
+	if cfg.Offline {
+		return AddResult{}, fmt.Errorf("%w: cannot download %s", source.ErrOffline, rawURL)
+	}
+	if name == "" {
+		name = source.TemplateNameFromURL(rawURL)
+		if name == "" {
+			return AddResult{}, fmt.Errorf("cannot derive a section name from %s; pass --name", rawURL)
+		}
+	}
+
+	content, err := source.FetchURL(commandContext(), rawURL, userAgent(cfg), cfg.Proxy())
+	if err != nil {
+		return AddResult{}, err
+	}
+	if opts.minimal {
+		content = gitignore.MinimalContent(content)
+	}
+	if opts.sort {
+		content = gitignore.SortContent(content)
+	}
+	name, content, err = opts.scopeSection(name, content)
+	if err != nil {
+		return AddResult{}, err
+	}
+
+	manager, err := newManager(gitignore.WithDedupe(opts.dedupeOn(cfg)))
+	if err != nil {
+		return AddResult{}, err
+	}
+	return addSection(w, cfg, manager, name, rawURL, content, opts, "")
+}
+
+// addSection writes a fetched template as a section, replacing an existing
+// one when opts.replace is set, and reports the result
+// origin names where the content came from in output and headers; note is
+// appended to the message
+func addSection(w io.Writer, cfg *config.Config, manager *gitignore.Manager, sectionName, origin, content string, opts addOptions, note string) (AddResult, error) {
+	if opts.dryRun {
+		manager.SetDryRun(true)
+		res, err := writeSection(w, cfg, manager, sectionName, origin, content, opts, note)
+		if err != nil {
+			return res, err
+		}
+		return res, writeDryRun(w, manager)
+	}
+	return writeSection(w, cfg, manager, sectionName, origin, content, opts, note)
+}
+
+// writeSection implements addSection
+func writeSection(w io.Writer, cfg *config.Config, manager *gitignore.Manager, sectionName, origin, content string, opts addOptions, note string) (AddResult, error) {
+	res := AddResult{Path: manager.Path(), Section: sectionName, Source: origin}
+	if opts.appendTo != "" {
+		added, err := manager.AppendToSection(opts.appendTo, content)
+		res.Section, res.Appended = opts.appendTo, added
+		switch {
+		case err == nil && len(added) == 0:
+			fmt.Fprintf(w, "Section '%s' already has every line of '%s'\n", opts.appendTo, origin)
+			return res, nil
+		case err == nil:
+			fmt.Fprintf(w, "Appended %d line(s) from '%s' to '%s'%s\n", len(added), origin, opts.appendTo, note)
+			return res, nil
+		case !errors.Is(err, gitignore.ErrSectionNotFound):
+			return res, err
+		case !opts.create:
+			return res, fmt.Errorf("%w (pass --create to add it)", err)
+		}
+		// --create: add the fetched template as the missing section
+		sectionName = opts.appendTo
+	}
+
+	content = sectionContent(cfg, origin, content)
+
+	// Patterns the file already has are left out of new sections only
+	var dups []string
+	if opts.dedupeOn(cfg) {
+		var err error
+		if dups, err = manager.DuplicatePatterns(content); err != nil {
+			return res, err
+		}
+	}
+	added := func() (AddResult, error) {
+		if opts.prefix != "" {
+			note = fmt.Sprintf(" as '%s'%s", sectionName, note)
+		}
+		fmt.Fprintf(w, "%s '%s' to .gitignore%s\n", pastOrWould("Added", "add", opts.dryRun), origin, note)
+		if len(dups) > 0 {
+			fmt.Fprintf(w, "Left out %d pattern(s) already in .gitignore: %s\n", len(dups), strings.Join(dups, ", "))
+		}
+		res.Created, res.Deduped = true, dups
+		return res, nil
+	}
+
+	position, err := addPosition(w, manager, sectionName, opts)
+	if err != nil {
+		return res, err
+	}
+	if position >= 0 {
+		// An existing section is replaced where it is; only new ones move
+		if opts.replace {
+			exists, err := manager.HasSection(sectionName)
+			if err != nil {
+				return res, err
+			}
+			if exists {
+				if err := manager.Update(sectionName, content); err != nil {
+					return res, err
+				}
+				fmt.Fprintf(w, "Replaced '%s' in .gitignore%s\n", origin, note)
+				res.Replaced = true
+				return res, nil
+			}
+		}
+		if err := manager.AddAt(sectionName, content, position); err != nil {
+			return res, err
+		}
+		return added()
+	}
+
+	if opts.replace {
+		created, err := manager.AddOrUpdate(sectionName, content)
+		if err != nil {
+			return res, err
+		}
+		if !created {
+			fmt.Fprintf(w, "Replaced '%s' in .gitignore%s\n", origin, note)
+			res.Replaced = true
+			return res, nil
+		}
+		return added()
+	}
+
+	if err := manager.Add(sectionName, content); err != nil {
+		return res, err
+	}
+
+	return added()
+}
+
+// addPosition returns where --after/--before place a new section among the
+// managed sections, or -1 to append it
+// A missing reference section is reported as a warning and the section is
+// appended instead
+func addPosition(w io.Writer, manager *gitignore.Manager, sectionName string, opts addOptions) (int, error) {
+	anchor, offset := opts.after, 1
+	if opts.before != "" {
+		anchor, offset = opts.before, 0
+	}
+	if anchor == "" {
+		return -1, nil
+	}
+
+	sections, err := manager.ListSections()
+	if err != nil {
+		return -1, err
+	}
+	for i, name := range sections {
+		if name == anchor {
+			return i + offset, nil
+		}
+	}
+	fmt.Fprintf(w, "Warning: section '%s' not found; adding '%s' at the end\n", anchor, sectionName)
+	return -1, nil
+}
+
+// matchesSearch reports whether a display path matches a search pattern
+// Patterns containing glob characters (*, ? or [) are matched with path.Match
+// against the whole path and against every trailing part of it, so
+// 'global/*' and '*js*' work without naming the source; other patterns are
+// case-insensitive substring matches
+func matchesSearch(displayPath, pattern string) (bool, error) {
+	pattern = strings.ToLower(pattern)
+	if !strings.ContainsAny(pattern, "*?[") {
+		return strings.Contains(displayPath, pattern), nil
+	}
+
+	candidate := displayPath
+	for {
+		matched, err := path.Match(pattern, candidate)
+		if err != nil {
+			return false, fmt.Errorf("invalid search pattern '%s': %w", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+		i := strings.Index(candidate, "/")
+		if i < 0 {
+			return false, nil
+		}
+		candidate = candidate[i+1:]
+	}
+}
+
+// templateDisplayPath builds the display path used by list/search
+// (lowercase source/category/name)
+func templateDisplayPath(file *source.TemplateFile) string {
+	if file.Category == "" {
+		return fmt.Sprintf("%s/%s", strings.ToLower(file.Source), strings.ToLower(file.Name))
+	}
+	return fmt.Sprintf("%s/%s/%s", strings.ToLower(file.Source), strings.ToLower(file.Category), strings.ToLower(file.Name))
+}
+
+// refNote describes the pinned ref a template was fetched from, if any
+func refNote(file *source.TemplateFile) string {
+	if file.Ref == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (ref %s)", file.Ref)
+}
+
+// sectionContent prepares fetched template content for writing, prepending a
+// provenance header when gitignore.add-header is enabled
+func sectionContent(cfg *config.Config, displayPath, content string) string {
+	if !cfg.AddHeader {
+		return content
+	}
+	return gitignore.ProvenanceHeader(displayPath, time.Now()) + "\n" + strings.TrimSpace(content)
+}
+
+func cmdDelete(cfg *config.Config, templateType string, force, dryRun bool) error {
+	_, err := cmdDeleteTo(os.Stdout, cfg, templateType, force, dryRun)
+	return err
+}
+
+// SectionsResult is the outcome of the commands that act on whole sections
+// (delete, sort, clean, uninstall, sections, move and import): the sections deleted,
+// sorted, removed, listed, moved or created, in file order
+type SectionsResult struct {
+	Path     string   `json:"path"` // the file read or written
+	Sections []string `json:"sections"`
+}
+
+// cmdDeleteTo removes a section, named as in the file or as the path add
+// and list print (see resolveSection); with force, a missing section is
+// only noted on stderr so the command can be repeated safely
+func cmdDeleteTo(w io.Writer, cfg *config.Config, templateType string, force, dryRun bool) (SectionsResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return SectionsResult{}, err
+	}
+	res := SectionsResult{Path: manager.Path()}
+	manager.SetDryRun(dryRun)
+
+	// Try to delete the section
+	sectionName, err := resolveSection(cfg, manager, templateType)
+	if err == nil {
+		err = manager.Delete(sectionName)
+	}
+	if err != nil {
+		if force && errors.Is(err, gitignore.ErrSectionNotFound) {
+			fmt.Fprintf(os.Stderr, "Note: %v\n", err)
+			return res, nil
+		}
+		return res, err
+	}
+
+	removed := pastOrWould("Removed", "remove", dryRun)
+	if sectionName != templateType {
+		fmt.Fprintf(w, "%s '%s' (section '%s') from .gitignore\n", removed, templateType, sectionName)
+	} else {
+		fmt.Fprintf(w, "%s '%s' from .gitignore\n", removed, templateType)
+	}
+	res.Sections = []string{sectionName}
+	if dryRun {
+		return res, writeDryRun(w, manager)
+	}
+	return res, nil
+}
+
+// resolveSection returns the section that name refers to, ignoring case, so
+// the lowercase path add and list print finds the section add wrote: with a
+// source prefix such as github/global/macos, the prefix is dropped when no
+// section has the full name, matching Global/macOS
+func resolveSection(cfg *config.Config, manager *gitignore.Manager, name string) (string, error) {
+	sectionName, err := manager.ResolveSection(name)
+	if !errors.Is(err, gitignore.ErrSectionNotFound) {
+		return sectionName, err
+	}
+	sm, smErr := newSourceManager(cfg)
+	if smErr != nil {
+		return "", err
+	}
+	if _, rest, ok := sm.ParseSourcePrefix(name); ok {
+		if sectionName, prefixErr := manager.ResolveSection(rest); !errors.Is(prefixErr, gitignore.ErrSectionNotFound) {
+			return sectionName, prefixErr
+		}
+	}
+	return "", err
+}
+
+// pastOrWould returns past ("Removed") for a change that was made, or
+// "Would <verb>" ("Would remove") for one a --dry-run only previews
+func pastOrWould(past, verb string, dryRun bool) string {
+	if dryRun {
+		return "Would " + verb
+	}
+	return past
+}
+
+// writeDryRun reports that a --dry-run command left the file alone and
+// prints the diff of what it would have written
+func writeDryRun(w io.Writer, manager *gitignore.Manager) error {
+	diff, err := manager.Preview()
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		fmt.Fprintf(w, "Dry run: %s would not change\n", manager.Path())
+		return nil
+	}
+	fmt.Fprintf(w, "Dry run: %s was not changed. The change would be:\n%s", manager.Path(), diff)
+	return nil
+}
+
+func cmdDeleteGlob(pattern string, force bool) error {
+	_, err := cmdDeleteGlobTo(os.Stdout, os.Stdin, pattern, force)
+	return err
+}
+
+// cmdDeleteGlobTo removes every section whose name matches pattern, asking
+// for confirmation on in unless force is set; with force, no match is only
+// noted on stderr
+func cmdDeleteGlobTo(w io.Writer, in io.Reader, pattern string, force bool) (SectionsResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return SectionsResult{}, err
+	}
+	res := SectionsResult{Path: manager.Path()}
+
+	names, err := manager.MatchSections(pattern)
+	if err != nil {
+		return res, err
+	}
+	if len(names) == 0 {
+		err := fmt.Errorf("no sections in .gitignore match '%s'", pattern)
+		if force {
+			fmt.Fprintf(os.Stderr, "Note: %v\n", err)
+			return res, nil
+		}
+		return res, err
+	}
+
+	if !force {
+		fmt.Fprintf(w, "Sections matching '%s':\n", pattern)
+		for _, name := range names {
+			fmt.Fprintf(w, "  %s\n", name)
+		}
+		fmt.Fprintf(w, "Delete %d section(s)? [y/N] ", len(names))
+		answer, _ := bufio.NewReader(in).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Fprintln(w, "Aborted")
+			return res, nil
+		}
+	}
+
+	if err := manager.DeleteSections(names); err != nil {
+		return res, err
+	}
+
+	fmt.Fprintf(w, "Removed %d section(s) from .gitignore\n", len(names))
+	res.Sections = names
+	return res, nil
+}
+
+func cmdInitSuggest(cfg *config.Config) error {
+	_, err := cmdInitSuggestTo(os.Stdout, cfg)
+	return err
+}
+
+// SuggestResult is the outcome of init --suggest
+type SuggestResult struct {
+	Suggested []Suggestion `json:"suggested"` // templates to add, most files first
+	Present   []string     `json:"present"`   // sections already in the file
+	Unknown   []string     `json:"unknown"`   // detected templates no source has
+}
+
+// Suggestion is a template suggested by init --suggest
+type Suggestion struct {
+	Template string `json:"template"` // path to pass to add, e.g. github/go
+	Files    int    `json:"files"`    // number of files that suggested it
+	Example  string `json:"example"`  // one such file
+}
+
+// cmdInitSuggestTo detects the languages used under the current directory
+// and prints an add command for each matching template that isn't in
+// .gitignore yet; nothing is written
+func cmdInitSuggestTo(w io.Writer, cfg *config.Config) (SuggestResult, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return SuggestResult{}, fmt.Errorf("failed to get current directory: %w", err)
+	}
+	manager, err := newManager()
+	if err != nil {
+		return SuggestResult{}, err
+	}
+	patterns, err := manager.PatternLines()
+	if err != nil {
+		return SuggestResult{}, err
+	}
+	detections, err := gitignore.DetectTemplates(cwd, patterns)
+	if err != nil {
+		return SuggestResult{}, err
+	}
+	if len(detections) == 0 {
+		fmt.Fprintln(w, "No languages detected.")
+		return SuggestResult{}, nil
+	}
+
+	sm, err := newSourceManager(cfg)
+	if err != nil {
+		return SuggestResult{}, fmt.Errorf("failed to create source manager: %w", err)
+	}
+
+	var res SuggestResult
+	for _, det := range detections {
+		file, _, err := sm.Which(det.Template)
+		if err != nil {
+			logging.Verbosef("suggest %s: %v", det.Template, err)
+			res.Unknown = append(res.Unknown, det.Template)
+			continue
+		}
+		sectionName := file.Name
+		if file.Category != "" {
+			sectionName = file.Category + "/" + file.Name
+		}
+		if exists, err := manager.HasSection(sectionName); err != nil {
+			return res, err
+		} else if exists {
+			res.Present = append(res.Present, sectionName)
+			continue
+		}
+		res.Suggested = append(res.Suggested, Suggestion{Template: templateDisplayPath(file), Files: det.Files, Example: det.Example})
+	}
+
+	if len(res.Suggested) > 0 {
+		width := 0
+		for _, sug := range res.Suggested {
+			width = max(width, len("gitignore add "+sug.Template))
+		}
+		fmt.Fprintln(w, "Suggested templates:")
+		for _, sug := range res.Suggested {
+			fmt.Fprintf(w, "  %-*s  # %d file(s), e.g. %s\n", width, "gitignore add "+sug.Template, sug.Files, sug.Example)
+		}
+	} else {
+		fmt.Fprintln(w, "No new templates to suggest.")
+	}
+	if len(res.Present) > 0 {
+		fmt.Fprintf(w, "Already in .gitignore: %s\n", strings.Join(res.Present, ", "))
+	}
+	if len(res.Unknown) > 0 {
+		fmt.Fprintf(w, "No template found for: %s (--verbose shows why)\n", strings.Join(res.Unknown, ", "))
+	}
+	return res, nil
+}
+
+func cmdInit(cfg *config.Config, preset string) error {
+	_, err := cmdInitTo(os.Stdout, cfg, preset)
+	return err
+}
+
+// InitResult is the outcome of init
+type InitResult struct {
+	Path    string   `json:"path"`    // the file written
+	Added   []string `json:"added"`   // templates added, e.g. github/go
+	Skipped []string `json:"skipped"` // types whose section already existed
+	Failed  []string `json:"failed"`  // types that could not be fetched or added
+}
+
+// cmdInitTo adds the configured default types, or the members of a preset
+// when one is named
+func cmdInitTo(w io.Writer, cfg *config.Config, preset string) (InitResult, error) {
+	types := cfg.DefaultTypes
+	label := "default types"
+	if preset != "" {
+		expanded, err := cfg.ExpandPreset(preset)
+		if err != nil {
+			return InitResult{}, err
+		}
+		types = expanded
+		label = fmt.Sprintf("preset '%s'", preset)
+	}
+
+	if len(types) == 0 {
+		if preset != "" {
+			fmt.Fprintf(w, "Preset '%s' has no templates.\n", preset)
+			return InitResult{}, nil
+		}
+		fmt.Fprintln(w, "No default types configured.")
+		fmt.Fprintln(w, "Add 'gitignore.default-types = github/go, github/global/macos' to your config file.")
+		return InitResult{}, nil
+	}
+
+	// Create source manager
+	sm, err := newSourceManager(cfg)
+	if err != nil {
+		return InitResult{}, fmt.Errorf("failed to create source manager: %w", err)
+	}
+
+	manager, err := newManager()
+	if err != nil {
+		return InitResult{}, err
+	}
+	res := InitResult{Path: manager.Path()}
+
+	fmt.Fprintf(w, "Initializing .gitignore with %s: %s\n\n", label, strings.Join(types, ", "))
+
+	// Fetch every template not already present up front and concurrently,
+	// so that network latency overlaps; results are written below in
+	// config order so the output stays deterministic
+	var missing []string
+	for _, templateType := range types {
+		if exists, err := manager.HasSection(templateType); err == nil && !exists {
+			missing = append(missing, templateType)
+		}
+	}
+	fetched := prefetchTemplates(sm, missing, isTerminal(w))
+
+	for _, templateType := range types {
+		// Check if already exists
+		exists, err := manager.HasSection(templateType)
+		if err != nil {
+			fmt.Fprintf(w, "  Warning: could not check for '%s': %v\n", templateType, err)
+			res.Failed = append(res.Failed, templateType)
+			continue
+		}
+		if exists {
+			fmt.Fprintf(w, "  Skipping '%s' (already exists)\n", templateType)
+			res.Skipped = append(res.Skipped, templateType)
+			continue
+		}
+
+		result, ok := fetched[templateType]
+		if !ok {
+			result = fetchTemplate(sm, templateType)
+		}
+		file, content, err := result.file, result.content, result.err
+		if err != nil {
+			fmt.Fprintf(w, "  Warning: template '%s' not found\n", templateType)
+			res.Failed = append(res.Failed, templateType)
+			continue
+		}
+
+		// Create section name (include category if present)
+		sectionName := file.Name
+		if file.Category != "" {
+			sectionName = file.Category + "/" + file.Name
+		}
+
+		content, err = sm.ApplyPatch(file, content)
+		if err != nil {
+			fmt.Fprintf(w, "  Warning: %v\n", err)
+			res.Failed = append(res.Failed, templateType)
+			continue
+		}
+
+		// Add to gitignore
+		displayPath := templateDisplayPath(file)
+		if err := manager.Add(sectionName, sectionContent(cfg, displayPath, content)); err != nil {
+			fmt.Fprintf(w, "  Warning: failed to add '%s': %v\n", templateType, err)
+			res.Failed = append(res.Failed, templateType)
+			continue
+		}
+
+		fmt.Fprintf(w, "  Added '%s'\n", displayPath)
+		res.Added = append(res.Added, displayPath)
+	}
+
+	fmt.Fprintf(w, "\nDone: %d added, %d skipped\n", len(res.Added), len(res.Skipped))
+	return res, nil
+}
+
+func cmdSync(cfg *config.Config, prune, dryRun bool) error {
+	_, err := cmdSyncTo(os.Stdout, cfg, prune, dryRun)
+	return err
+}
+
+// SyncResult is the outcome of sync
+type SyncResult struct {
+	Path    string   `json:"path"`
+	Added   []string `json:"added"`   // templates added, e.g. github/go
+	Removed []string `json:"removed"` // sections removed with --prune
+	Kept    []string `json:"kept"`    // sections already matching a default type
+	Extra   []string `json:"extra"`   // sections not in the default types, kept without --prune
+	Failed  []string `json:"failed"`  // types that could not be found or added
+}
+
+// cmdSyncTo reconciles the managed sections with the configured default
+// types: missing types are added and, with prune, sections for other
+// templates are removed
+// A section counts as a default type when it has the template's name, as
+// 'add' sees it; sections from 'ignore' are never pruned, nor are sections
+// named like a type that could not be looked up
+func cmdSyncTo(w io.Writer, cfg *config.Config, prune, dryRun bool) (SyncResult, error) {
+	types := cfg.DefaultTypes
+	if len(types) == 0 {
+		fmt.Fprintln(w, "No default types configured.")
+		fmt.Fprintln(w, "Add 'gitignore.default-types = github/go, github/global/macos' to your config file.")
+		return SyncResult{}, nil
+	}
+
+	sm, err := newSourceManager(cfg)
+	if err != nil {
+		return SyncResult{}, fmt.Errorf("failed to create source manager: %w", err)
+	}
+	manager, err := newManager()
+	if err != nil {
+		return SyncResult{}, err
+	}
+	res := SyncResult{Path: manager.Path()}
+	manager.SetDryRun(dryRun)
+	fmt.Fprintf(w, "Syncing .gitignore with default types: %s\n\n", strings.Join(types, ", "))
+
+	// Work out the plan without fetching any template content
+	var missing []string
+	wanted := make(map[string]bool)     // sections matching a default type
+	unresolved := make(map[string]bool) // lowercase names of types not found
+	for _, templateType := range types {
+		file, _, err := sm.Which(templateType)
+		if err != nil {
+			fmt.Fprintf(w, "  Warning: template '%s' not found\n", templateType)
+			res.Failed = append(res.Failed, templateType)
+			unresolved[strings.ToLower(path.Base(sm.ResolveAlias(templateType)))] = true
+			continue
+		}
+		sectionName := file.Name
+		if file.Category != "" {
+			sectionName = file.Category + "/" + file.Name
+		}
+		existing, err := sameTemplateSection(manager, file.Name, sectionName)
+		if err != nil {
+			return res, err
+		}
+		if existing == "" {
+			missing = append(missing, templateType)
+			continue
+		}
+		if !wanted[existing] {
+			wanted[existing] = true
+			res.Kept = append(res.Kept, existing)
+		}
+	}
+
+	sections, err := manager.ListSections()
+	if err != nil {
+		return res, err
+	}
+	seen := make(map[string]bool)
+	for _, name := range sections {
+		if seen[name] || wanted[name] || strings.HasPrefix(name, gitignore.IgnoredSectionPrefix) || unresolved[strings.ToLower(path.Base(name))] {
+			continue
+		}
+		seen[name] = true
+		res.Extra = append(res.Extra, name)
+	}
+
+	for _, templateType := range missing {
+		fmt.Fprintf(w, "  + %s\n", templateType)
+	}
+	for _, name := range res.Extra {
+		if prune {
+			fmt.Fprintf(w, "  - %s\n", name)
+		} else {
+			fmt.Fprintf(w, "  ? %s (not a default type; --prune removes it)\n", name)
+		}
+	}
+	for _, name := range res.Kept {
+		fmt.Fprintf(w, "  = %s\n", name)
+	}
+	if len(missing) == 0 && (!prune || len(res.Extra) == 0) {
+		fmt.Fprintln(w, "\nAlready in sync")
+		return res, nil
+	}
+	fmt.Fprintln(w)
+
+	if prune && len(res.Extra) > 0 {
+		if err := manager.DeleteSections(res.Extra); err != nil {
+			return res, err
+		}
+		for _, name := range res.Extra {
+			fmt.Fprintf(w, "  %s '%s'\n", pastOrWould("Removed", "remove", dryRun), name)
+		}
+		res.Removed, res.Extra = res.Extra, nil
+	}
+
+	fetched := prefetchTemplates(sm, missing, isTerminal(w))
+	for _, templateType := range missing {
+		result := fetched[templateType]
+		if result.err != nil {
+			fmt.Fprintf(w, "  Warning: template '%s' could not be fetched: %v\n", templateType, result.err)
+			res.Failed = append(res.Failed, templateType)
+			continue
+		}
+		file := result.file
+		sectionName := file.Name
+		if file.Category != "" {
+			sectionName = file.Category + "/" + file.Name
+		}
+		content, err := sm.ApplyPatch(file, result.content)
+		if err != nil {
+			fmt.Fprintf(w, "  Warning: %v\n", err)
+			res.Failed = append(res.Failed, templateType)
+			continue
+		}
+		displayPath := templateDisplayPath(file)
+		if err := manager.Add(sectionName, sectionContent(cfg, displayPath, content)); err != nil {
+			fmt.Fprintf(w, "  Warning: failed to add '%s': %v\n", templateType, err)
+			res.Failed = append(res.Failed, templateType)
+			continue
+		}
+		fmt.Fprintf(w, "  %s '%s'\n", pastOrWould("Added", "add", dryRun), displayPath)
+		res.Added = append(res.Added, displayPath)
+	}
+
+	summary := fmt.Sprintf("%d added, %d removed", len(res.Added), len(res.Removed))
+	if dryRun {
+		summary = fmt.Sprintf("would add %d, would remove %d", len(res.Added), len(res.Removed))
+	}
+	fmt.Fprintf(w, "\nDone: %s, %d unchanged\n", summary, len(res.Kept))
+	if dryRun {
+		return res, writeDryRun(w, manager)
+	}
+	return res, nil
+}
+
+// initFetchWorkers bounds how many templates init downloads at once
+const initFetchWorkers = 4
+
+// fetchResult is the outcome of fetching one template with GetAny
+type fetchResult struct {
+	file    *source.TemplateFile
+	content string
+	err     error
+}
+
+// fetchTemplate fetches a template; GetAny handles source prefixes
+// automatically (e.g., "github/rust" vs "rust")
+func fetchTemplate(sm *source.SourceManager, templateType string) fetchResult {
+	file, content, err := sm.GetAny(templateType)
+	return fetchResult{file: file, content: content, err: err}
+}
+
+// prefetchTemplates fetches templates concurrently with at most
+// initFetchWorkers in flight, keyed by template type
+// With showProgress, progress is reported on stderr
+func prefetchTemplates(sm *source.SourceManager, types []string, showProgress bool) map[string]fetchResult {
+	progress := newProgress(os.Stderr, len(types), showProgress)
+	results := make(map[string]fetchResult, len(types))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, initFetchWorkers)
+
+	for _, templateType := range types {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(templateType string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			progress.Start(templateType)
+			result := fetchTemplate(sm, templateType)
+			progress.Done()
+
+			mu.Lock()
+			results[templateType] = result
+			mu.Unlock()
+		}(templateType)
+	}
+	wg.Wait()
+	return results
+}
+
+// progress shows a "[3/10] fetching github/python..." line that is rewritten
+// in place as templates are fetched; a disabled progress writes nothing
+// Start and Done may be called from several goroutines
+type progress struct {
+	mu      sync.Mutex
+	w       io.Writer
+	total   int
+	started int
+	active  int
+}
+
+// newProgress returns a progress for total templates writing to w, or a
+// disabled one when enabled is false
+func newProgress(w io.Writer, total int, enabled bool) *progress {
+	if !enabled {
+		w = nil
+	}
+	return &progress{w: w, total: total}
+}
+
+// Start reports that fetching name has begun
+func (p *progress) Start(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.w == nil {
+		return
+	}
+	p.started++
+	p.active++
+	fmt.Fprintf(p.w, "\r\033[K[%d/%d] fetching %s...", p.started, p.total, name)
+}
+
+// Done reports that a fetch has finished, clearing the line once none are
+// in flight so that results print on a clean line
+func (p *progress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.w == nil || p.active == 0 {
+		return
+	}
+	p.active--
+	if p.active == 0 {
+		fmt.Fprint(p.w, "\r\033[K")
+	}
+}
+
+// isTerminal reports whether w is a terminal (character device)
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func cmdPresets(cfg *config.Config) error {
+	_, err := cmdPresetsTo(os.Stdout, cfg)
+	return err
+}
+
+// PresetsResult is the outcome of presets
+type PresetsResult struct {
+	Presets []PresetEntry `json:"presets"` // sorted by name
+}
+
+// PresetEntry is one preset in a PresetsResult
+type PresetEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Members     []string `json:"members"`         // fully expanded
+	Error       string   `json:"error,omitempty"` // why the preset can't be expanded
+}
+
+// cmdPresetsTo lists configured presets with their description and
+// fully expanded members
+func cmdPresetsTo(w io.Writer, cfg *config.Config) (PresetsResult, error) {
+	var res PresetsResult
+	names := cfg.PresetNames()
+	if len(names) == 0 {
+		fmt.Fprintln(w, "No presets configured.")
+		fmt.Fprintln(w, "Add 'gitignore.preset.webapp = node, github/global/macos' to your config file.")
+		return res, nil
+	}
+
+	for _, name := range names {
+		entry := PresetEntry{Name: name, Description: cfg.Presets[name].Description}
+		if entry.Description != "" {
+			fmt.Fprintf(w, "%s - %s\n", name, entry.Description)
+		} else {
+			fmt.Fprintln(w, name)
+		}
+		members, err := cfg.ExpandPreset(name)
+		if err != nil {
+			fmt.Fprintf(w, "  Warning: %v\n", err)
+			entry.Error = err.Error()
+		} else {
+			fmt.Fprintf(w, "  %s\n", strings.Join(members, ", "))
+			entry.Members = members
+		}
+		res.Presets = append(res.Presets, entry)
+	}
+	return res, nil
+}
+
+// configView is the effective configuration as shown by config and the
+// gitignore_config MCP tool
+type configView struct {
+	ConfigFiles        []string `json:"config_files"`
+	TemplateURL        string   `json:"template_url"`
+	TemplateURLRemote  bool     `json:"template_url_from_remote"` // TemplateURL came from the origin remote
+	TemplateRef        string   `json:"template_ref"`
+	TemplatePath       string   `json:"template_path"`
+	EnableToptal       bool     `json:"enable_toptal"`
+	LocalTemplatesPath string   `json:"local_templates_path"`
+	DefaultTypes       []string `json:"default_types"`
+	AddHeader          bool     `json:"add_header"`
+	Offline            bool     `json:"offline"`
+	GitHubContentAPI   bool     `json:"github_content_api"`
+	Backup             bool     `json:"backup"`
+	DedupeOnAdd        bool     `json:"dedupe_on_add"`
+	FollowSymlinks     bool     `json:"follow_symlinks"`
+	UserAgent          string   `json:"user_agent"`
+	HTTPProxy          string   `json:"http_proxy"`
+	CacheDir           string   `json:"cache_dir"`
+	Blocklist          []string `json:"blocklist"`
+	SectionStartPrefix string   `json:"section_start_prefix"`
+	SectionEndPrefix   string   `json:"section_end_prefix"`
+	SourcePriority     []string `json:"source_priority"`
+	Sources            []string `json:"sources"`
+}
+
+func cmdConfig(cfg *config.Config, asJSON bool) error {
+	_, err := cmdConfigTo(os.Stdout, cfg, asJSON)
+	return err
+}
+
+// cmdConfigTo prints the resolved configuration, including which config
+// files exist and the sources that will be consulted, in priority order
+func cmdConfigTo(w io.Writer, cfg *config.Config, asJSON bool) (configView, error) {
+	sm, err := newSourceManager(cfg)
+	if err != nil {
+		return configView{}, fmt.Errorf("failed to create source manager: %w", err)
+	}
+	markers, err := gitignore.NewMarkers(cfg.SectionStartPrefix, cfg.SectionEndPrefix)
+	if err != nil {
+		return configView{}, err
+	}
+
+	view := configView{
+		ConfigFiles:        []string{},
+		TemplateURL:        templateURL(cfg),
+		TemplateURLRemote:  templateURL(cfg) != cfg.TemplateURL,
+		TemplateRef:        cfg.TemplateRef,
+		TemplatePath:       cfg.TemplatePath,
+		EnableToptal:       cfg.EnableToptal,
+		LocalTemplatesPath: cfg.LocalTemplatesPath,
+		DefaultTypes:       append([]string{}, cfg.DefaultTypes...),
+		AddHeader:          cfg.AddHeader,
+		Offline:            cfg.Offline,
+		GitHubContentAPI:   cfg.GitHubContentAPI,
+		Backup:             cfg.Backup,
+		DedupeOnAdd:        cfg.DedupeOnAdd,
+		FollowSymlinks:     cfg.FollowSymlinks,
+		UserAgent:          userAgent(cfg),
+		CacheDir:           cfg.CacheDir,
+		Blocklist:          append([]string{}, cfg.Blocklist...),
+		SectionStartPrefix: markers.Start,
+		SectionEndPrefix:   markers.End,
+		SourcePriority:     append([]string{}, cfg.SourcePriority...),
+		Sources:            []string{},
+	}
+	if globals.config != "" {
+		view.ConfigFiles = []string{globals.config}
+	} else if paths, err := config.GetConfigPaths(); err == nil {
+		for _, path := range paths {
+			if _, err := os.Stat(path); err == nil {
+				view.ConfigFiles = append(view.ConfigFiles, path)
+			}
+		}
+	}
+	if proxy := cfg.Proxy(); proxy != nil {
+		view.HTTPProxy = proxy.Redacted() // don't print proxy passwords
+	}
+	for _, src := range sm.AllSources() {
+		view.Sources = append(view.Sources, sm.SourceKey(src))
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return view, enc.Encode(view)
+	}
+
+	configFiles := "(none)"
+	if len(view.ConfigFiles) > 0 {
+		configFiles = strings.Join(view.ConfigFiles, ", ")
+	}
+	sourcePriority := "(default)"
+	if len(view.SourcePriority) > 0 {
+		sourcePriority = strings.Join(view.SourcePriority, ", ")
+	}
+	fmt.Fprintf(w, "Config files:     %s\n", configFiles)
+	templateRef := "(default branch)"
+	if view.TemplateRef != "" {
+		templateRef = view.TemplateRef
+	}
+	if view.TemplateURLRemote {
+		fmt.Fprintf(w, "Template URL:     %s (from origin remote)\n", view.TemplateURL)
+	} else {
+		fmt.Fprintf(w, "Template URL:     %s\n", view.TemplateURL)
+	}
+	fmt.Fprintf(w, "Template ref:     %s\n", templateRef)
+	templatePath := "(repository root)"
+	if view.TemplatePath != "" {
+		templatePath = view.TemplatePath
+	}
+	fmt.Fprintf(w, "Template path:    %s\n", templatePath)
+	fmt.Fprintf(w, "Toptal enabled:   %t\n", view.EnableToptal)
+	fmt.Fprintf(w, "Local templates:  %s\n", view.LocalTemplatesPath)
+	fmt.Fprintf(w, "Default types:    %s\n", strings.Join(view.DefaultTypes, ", "))
+	fmt.Fprintf(w, "Add header:       %t\n", view.AddHeader)
+	fmt.Fprintf(w, "Offline:          %t\n", view.Offline)
+	fmt.Fprintf(w, "GitHub API first: %t\n", view.GitHubContentAPI)
+	fmt.Fprintf(w, "Backup:           %t\n", view.Backup)
+	fmt.Fprintf(w, "Dedupe on add:    %t\n", view.DedupeOnAdd)
+	fmt.Fprintf(w, "Follow symlinks:  %t\n", view.FollowSymlinks)
+	fmt.Fprintf(w, "User agent:       %s\n", view.UserAgent)
+	httpProxy := "(environment)"
+	if view.HTTPProxy != "" {
+		httpProxy = view.HTTPProxy
+	}
+	fmt.Fprintf(w, "HTTP proxy:       %s\n", httpProxy)
+	fmt.Fprintf(w, "Cache dir:        %s\n", view.CacheDir)
+	blocklist := "(none)"
+	if len(view.Blocklist) > 0 {
+		blocklist = strings.Join(view.Blocklist, ", ")
+	}
+	fmt.Fprintf(w, "Blocklist:        %s\n", blocklist)
+	fmt.Fprintf(w, "Section markers:  %s / %s\n", view.SectionStartPrefix, view.SectionEndPrefix)
+	fmt.Fprintf(w, "Source priority:  %s\n", sourcePriority)
+	fmt.Fprintf(w, "Sources:          %s\n", strings.Join(view.Sources, ", "))
+	return view, nil
+}
+
+func cmdIgnore(patterns []string, normalize, dryRun bool) error {
+	_, err := cmdIgnoreTo(os.Stdout, splitPatternArg(patterns), normalize, dryRun)
+	return err
+}
+
+// PatternsResult is the outcome of ignore and remove
+type PatternsResult struct {
+	Path    string   `json:"path"`    // the file written (or, with --dry-run, not written)
+	Changed []string `json:"changed"` // patterns added or removed
+	Skipped []string `json:"skipped"` // patterns already present (ignore) or not found (remove)
+}
+
+// splitPatternArg splits a single argument holding several patterns: a
+// pasted multi-line block is split at newlines, skipping blank lines and
+// comments, and a single line such as "*.log, tmp/" at commas
+// A multi-line block isn't split at commas, since a comma can be part of a
+// file name; escaped trailing spaces are kept (see gitignore.TrimPattern)
+// More than one argument is returned unchanged
+func splitPatternArg(args []string) []string {
+	if len(args) != 1 || !strings.ContainsAny(args[0], ",\n") {
+		return args
+	}
+	var patterns []string
+	if !strings.Contains(args[0], "\n") {
+		for _, pattern := range strings.Split(args[0], ",") {
+			if pattern = gitignore.TrimPattern(pattern); pattern != "" {
+				patterns = append(patterns, pattern)
+			}
+		}
+		return patterns
+	}
+	for _, line := range strings.Split(args[0], "\n") {
+		pattern := gitignore.TrimPattern(line)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// cmdIgnoreTo adds patterns; with normalize, patterns equivalent to an
+// existing one (see gitignore.NormalizePattern) are skipped too
+func cmdIgnoreTo(w io.Writer, patterns []string, normalize, dryRun bool) (PatternsResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return PatternsResult{}, err
+	}
+	res := PatternsResult{Path: manager.Path()}
+	manager.SetDryRun(dryRun)
+	addPatterns := manager.AddPatterns
+	if normalize {
+		addPatterns = manager.AddPatternsNormalized
+	}
+	added, skipped, err := addPatterns(patterns)
+	if err != nil {
+		return res, err
+	}
+	res.Changed, res.Skipped = added, skipped
+
+	for _, pattern := range added {
+		fmt.Fprintf(w, "%s '%s' to .gitignore\n", pastOrWould("Added", "add", dryRun), pattern)
+	}
+	for _, pattern := range skipped {
+		fmt.Fprintf(w, "Skipped '%s' (already exists)\n", pattern)
+	}
+
+	if len(added) == 0 && len(skipped) == 0 {
+		fmt.Fprintln(w, "No patterns to add")
+	}
+
+	if dryRun {
+		return res, writeDryRun(w, manager)
+	}
+	return res, nil
+}
+
+func cmdRemove(patterns []string, section string, force, dryRun bool) error {
+	_, err := cmdRemoveTo(os.Stdout, patterns, section, force, dryRun)
+	return err
+}
+
+// cmdRemoveTo removes ignored patterns, or with section the matching lines of
+// that section only; missing patterns are reported as warnings (errors with
+// section), and with force are only noted on stderr
+func cmdRemoveTo(w io.Writer, patterns []string, section string, force, dryRun bool) (PatternsResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return PatternsResult{}, err
+	}
+	res := PatternsResult{Path: manager.Path()}
+	manager.SetDryRun(dryRun)
+
+	for _, pattern := range patterns {
+		var err error
+		if section != "" {
+			err = manager.RemoveFromSection(section, pattern)
+		} else {
+			err = manager.RemovePattern(pattern)
+		}
+		if err != nil {
+			if force && (errors.Is(err, gitignore.ErrSectionNotFound) || errors.Is(err, gitignore.ErrPatternNotFound)) {
+				fmt.Fprintf(os.Stderr, "Note: %v\n", err)
+				res.Skipped = append(res.Skipped, pattern)
+				continue
+			}
+			if section != "" {
+				return res, err
+			}
+			fmt.Fprintf(w, "Warning: %v\n", err)
+			res.Skipped = append(res.Skipped, pattern)
+			continue
+		}
+		res.Changed = append(res.Changed, pattern)
+		removed := pastOrWould("Removed", "remove", dryRun)
+		if section != "" {
+			fmt.Fprintf(w, "%s '%s' from section '%s'\n", removed, pattern, section)
+			continue
+		}
+		fmt.Fprintf(w, "%s '%s' from .gitignore\n", removed, pattern)
+	}
+
+	if dryRun {
+		return res, writeDryRun(w, manager)
+	}
+	return res, nil
+}
+
+func cmdSort(sections []string) error {
+	_, err := cmdSortTo(os.Stdout, sections)
+	return err
+}
+
+func cmdSortTo(w io.Writer, sections []string) (SectionsResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return SectionsResult{}, err
+	}
+	res := SectionsResult{Path: manager.Path()}
+
+	sorted, err := manager.SortSections(sections...)
+	if err != nil {
+		return res, err
+	}
+	res.Sections = sorted
+
+	if len(sorted) == 0 {
+		fmt.Fprintln(w, "No sections to sort")
+		return res, nil
+	}
+	for _, name := range sorted {
+		fmt.Fprintf(w, "Sorted '%s'\n", name)
+	}
+	return res, nil
+}
+
+func cmdClean() error {
+	_, err := cmdCleanTo(os.Stdout)
+	return err
+}
+
+// cmdCleanTo removes managed sections that contain no patterns
+func cmdCleanTo(w io.Writer) (SectionsResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return SectionsResult{}, err
+	}
+	res := SectionsResult{Path: manager.Path()}
+
+	removed, err := manager.Clean()
+	if err != nil {
+		return res, err
+	}
+	res.Sections = removed
+
+	if len(removed) == 0 {
+		fmt.Fprintln(w, "No empty sections")
+		return res, nil
+	}
+	for _, name := range removed {
+		fmt.Fprintf(w, "Removed empty section '%s'\n", name)
+	}
+	return res, nil
+}
+
+func cmdUninstall(dryRun bool) error {
+	_, err := cmdUninstallTo(os.Stdout, dryRun)
+	return err
+}
+
+// cmdUninstallTo removes every managed section in a single write, keeping
+// hand-written patterns and comments outside the sections
+func cmdUninstallTo(w io.Writer, dryRun bool) (SectionsResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return SectionsResult{}, err
+	}
+	res := SectionsResult{Path: manager.Path()}
+	manager.SetDryRun(dryRun)
+
+	sections, err := manager.ListSections()
+	if err != nil {
+		return res, err
+	}
+	// A name can label several sections; DeleteSections removes them all
+	seen := make(map[string]bool)
+	for _, name := range sections {
+		if !seen[name] {
+			seen[name] = true
+			res.Sections = append(res.Sections, name)
+		}
+	}
+	if len(res.Sections) == 0 {
+		fmt.Fprintln(w, "No managed sections in .gitignore")
+		return res, nil
+	}
+
+	if err := manager.DeleteSections(res.Sections); err != nil {
+		return res, err
+	}
+	removed := pastOrWould("Removed", "remove", dryRun)
+	for _, name := range res.Sections {
+		fmt.Fprintf(w, "%s '%s'\n", removed, name)
+	}
+	kept, err := manager.Patterns()
+	if err != nil {
+		return res, err
+	}
+	fmt.Fprintf(w, "%s %d managed section(s) from .gitignore; %d hand-written pattern(s) kept\n", removed, len(res.Sections), len(kept))
+	if dryRun {
+		return res, writeDryRun(w, manager)
+	}
+	return res, nil
+}
+
+func cmdDiff(cfg *config.Config, templateType string) error {
+	_, err := cmdDiffTo(os.Stdout, cfg, templateType)
+	return err
+}
+
+// DiffResult is the outcome of diff
+type DiffResult struct {
+	Section string `json:"section"`
+	Source  string `json:"source"`  // the upstream template, e.g. github/go
+	Missing bool   `json:"missing"` // the section is not in the file
+	Diff    string `json:"diff"`    // unified diff to upstream, "" when up to date
+}
+
+// cmdDiffTo prints a unified diff from the template's section in .gitignore
+// to the current upstream template, with any local patch applied as 'add'
+// would; a provenance header in the section is not compared
+func cmdDiffTo(w io.Writer, cfg *config.Config, templateType string) (DiffResult, error) {
+	sm, err := newSourceManager(cfg)
+	if err != nil {
+		return DiffResult{}, fmt.Errorf("failed to create source manager: %w", err)
+	}
+
+	file, upstream, err := sm.GetAny(templateType)
+	if err != nil {
+		return DiffResult{}, fmt.Errorf("failed to fetch template '%s': %w", templateType, err)
+	}
+	upstream, err = sm.ApplyPatch(file, upstream)
+	if err != nil {
+		return DiffResult{}, err
+	}
+
+	sectionName := file.Name
+	if file.Category != "" {
+		sectionName = file.Category + "/" + file.Name
+	}
+
+	manager, err := newManager()
+	if err != nil {
+		return DiffResult{}, err
+	}
+	res := DiffResult{Section: sectionName, Source: templateDisplayPath(file)}
+	body, err := manager.GetSection(sectionName)
+	if errors.Is(err, gitignore.ErrSectionNotFound) {
+		fmt.Fprintf(w, "Section '%s' is not in .gitignore; use 'gitignore add %s' to add it\n", sectionName, templateType)
+		res.Missing = true
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	if strings.HasPrefix(body, gitignore.HeaderPrefix) {
+		_, body, _ = strings.Cut(body, "\n")
+	}
+
+	res.Diff = gitignore.UnifiedDiff(body, upstream, ".gitignore ("+sectionName+")", res.Source)
+	if res.Diff == "" {
+		fmt.Fprintf(w, "'%s' is up to date with %s\n", sectionName, res.Source)
+		return res, nil
+	}
+	fmt.Fprint(w, res.Diff)
+	return res, nil
+}
+
+func cmdRestore() error {
+	_, err := cmdRestoreTo(os.Stdout)
+	return err
+}
+
+// FileResult is the outcome of the commands that write a single file
+// (restore, tidy, new and export)
+type FileResult struct {
+	Path    string `json:"path"`    // the file, "" when export printed to the writer
+	Changed bool   `json:"changed"` // the file was written
+}
+
+// cmdRestoreTo replaces .gitignore with the backup written before the most
+// recent change
+func cmdRestoreTo(w io.Writer) (FileResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return FileResult{}, err
+	}
+	res := FileResult{Path: manager.Path()}
+
+	if err := manager.Restore(); err != nil {
+		return res, err
+	}
+
+	fmt.Fprintf(w, "Restored .gitignore from %s\n", manager.BackupPath())
+	res.Changed = true
+	return res, nil
+}
+
+func cmdTidy() error {
+	_, err := cmdTidyTo(os.Stdout)
+	return err
+}
+
+// cmdTidyTo normalizes whitespace and blank lines in .gitignore
+func cmdTidyTo(w io.Writer) (FileResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return FileResult{}, err
+	}
+	res := FileResult{Path: manager.Path()}
+
+	changed, err := manager.Tidy()
+	if err != nil {
+		return res, err
+	}
+	res.Changed = changed
+
+	if !changed {
+		fmt.Fprintln(w, ".gitignore is already tidy")
+		return res, nil
+	}
+	fmt.Fprintln(w, "Tidied .gitignore")
+	return res, nil
+}
+
+func cmdNew(cfg *config.Config, name string, force, edit bool) error {
+	_, err := cmdNewTo(os.Stdout, cfg, name, force, edit)
+	return err
+}
+
+// cmdNewTo creates a local template with a starter header and, with edit,
+// opens it in an editor (see openEditor)
+func cmdNewTo(w io.Writer, cfg *config.Config, name string, force, edit bool) (FileResult, error) {
+	local := source.NewLocalSourceWithDir(cfg.LocalTemplatesPath)
+	name = strings.TrimSuffix(name, ".gitignore")
+	path, err := local.Create(name, newTemplateContent(name), force)
+	if err != nil {
+		return FileResult{}, err
+	}
+	fmt.Fprintf(w, "Created local template '%s' at %s\n", name, path)
+	res := FileResult{Path: path, Changed: true}
+
+	if !edit {
+		return res, nil
+	}
+	return res, openEditor(path)
+}
+
+// cmdEdit opens an existing local template in an editor
+// Only local templates can be edited; remote ones would be overwritten on
+// the next fetch, so they are reported as an error
+func cmdEdit(cfg *config.Config, name string) error {
+	local := source.NewLocalSourceWithDir(cfg.LocalTemplatesPath)
+	file, err := local.Find(strings.TrimPrefix(name, "local/"))
+	if err != nil {
+		return fmt.Errorf("local template '%s' not found in %s; remote templates can't be edited in place (create a local copy with 'gitignore new %s')",
+			name, local.Dir(), name)
+	}
+	return openEditor(file.Path)
+}
+
+// openEditor opens path in $VISUAL or $EDITOR, falling back to vi (notepad
+// on Windows), and waits for it to exit
+func openEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if strings.TrimSpace(editor) == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if strings.TrimSpace(editor) == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// The editor may include arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", fields[0], err)
+	}
+	return nil
+}
+
+// newTemplateContent is the starter content written by 'gitignore new'
+func newTemplateContent(name string) string {
+	return fmt.Sprintf(`# %s
+# Local template created by gitignore on %s
+# Add one pattern per line; lines starting with # are comments.
+# Add it to a project with: gitignore add local/%s
+`, name, time.Now().Format("2006-01-02"), strings.ToLower(name))
+}
+
+func cmdSections(sorted bool) error {
+	_, err := cmdSectionsTo(os.Stdout, sorted)
+	return err
+}
+
+// cmdSectionsTo lists the managed sections in file order, or alphabetically
+// when sorted is set
+func cmdSectionsTo(w io.Writer, sorted bool) (SectionsResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return SectionsResult{}, err
+	}
+	res := SectionsResult{Path: manager.Path()}
+
+	list := manager.ListSections
+	if sorted {
+		list = manager.ListSectionsSorted
+	}
+	sections, err := list()
+	if err != nil {
+		return res, err
+	}
+	res.Sections = sections
+
+	unterminated, err := manager.UnterminatedSections()
+	if err != nil {
+		return res, err
+	}
+	for _, name := range unterminated {
+		logging.Warnf("section '%s' has no end marker", name)
+	}
+
+	if len(sections) == 0 {
+		fmt.Fprintln(w, "No managed sections")
+		return res, nil
+	}
+	for _, name := range sections {
+		fmt.Fprintln(w, name)
+	}
+	return res, nil
+}
+
+func cmdWhich(cfg *config.Config, templateType string) error {
+	_, err := cmdWhichTo(os.Stdout, cfg, templateType)
+	return err
+}
+
+// WhichResult is the outcome of which
+type WhichResult struct {
+	Template string `json:"template"`           // display path, e.g. github/go
+	Source   string `json:"source"`             // source key
+	Location string `json:"location,omitempty"` // where the source reads templates from
+	Path     string `json:"path"`               // the template file within the source
+	Ref      string `json:"ref,omitempty"`      // pinned ref, if any
+}
+
+// cmdWhichTo reports which source and file 'add <type>' would use, without
+// fetching the template, or lists the sources checked if none has it
+func cmdWhichTo(w io.Writer, cfg *config.Config, templateType string) (WhichResult, error) {
+	sm, err := newSourceManager(cfg)
+	if err != nil {
+		return WhichResult{}, fmt.Errorf("failed to create source manager: %w", err)
+	}
+
+	file, src, err := sm.Which(templateType)
+	var sourcesErr *source.SourcesError
+	if errors.As(err, &sourcesErr) {
+		fmt.Fprintf(w, "'%s' not found. Sources checked:\n", templateType)
+		for _, failure := range sourcesErr.Errors {
+			fmt.Fprintf(w, "  %s\n", failure)
+		}
+		return WhichResult{}, err
+	}
+	if err != nil {
+		return WhichResult{}, err
+	}
+
+	res := WhichResult{
+		Template: templateDisplayPath(file),
+		Source:   sm.SourceKey(src),
+		Location: sourceLocation(src),
+		Path:     file.Path,
+		Ref:      file.Ref,
+	}
+	sourceInfo := res.Source
+	if res.Location != "" {
+		sourceInfo += " (" + res.Location + ")"
+	}
+	fmt.Fprintln(w, res.Template)
+	fmt.Fprintf(w, "  Source: %s\n", sourceInfo)
+	fmt.Fprintf(w, "  Path:   %s\n", res.Path)
+	if res.Ref != "" {
+		fmt.Fprintf(w, "  Ref:    %s\n", res.Ref)
+	}
+	return res, nil
+}
+
+func cmdLsRemote(cfg *config.Config, repoURL string) error {
+	_, err := cmdLsRemoteTo(os.Stdout, cfg, repoURL)
+	return err
+}
+
+// LsRemoteResult is the outcome of ls-remote
+type LsRemoteResult struct {
+	Repo  string   `json:"repo"`  // owner/repo
+	Paths []string `json:"paths"` // .gitignore files, sorted ignoring case
+}
+
+// cmdLsRemoteTo lists the template files in an arbitrary GitHub repository,
+// without adding it to the configured sources
+func cmdLsRemoteTo(w io.Writer, cfg *config.Config, repoURL string) (LsRemoteResult, error) {
+	if cfg.Offline {
+		return LsRemoteResult{}, fmt.Errorf("%w: cannot list %s", source.ErrOffline, repoURL)
+	}
+	repo, err := source.NewGitHubSource(repoURL)
+	if err != nil {
+		return LsRemoteResult{}, fmt.Errorf("%w (expected a repository URL such as https://github.com/owner/repo)", err)
+	}
+	repo.SetUserAgent(userAgent(cfg))
+	repo.SetProxy(cfg.Proxy())
+	repo.SetContext(commandContext())
+
+	files, err := repo.List()
+	if err != nil {
+		return LsRemoteResult{}, fmt.Errorf("failed to list %s: %w", repo.Repo(), err)
+	}
+	if len(files) == 0 {
+		return LsRemoteResult{}, fmt.Errorf("no .gitignore files found in %s", repo.Repo())
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.ToLower(paths[i]) < strings.ToLower(paths[j])
+	})
+	for _, path := range paths {
+		fmt.Fprintln(w, path)
+	}
+	return LsRemoteResult{Repo: repo.Repo(), Paths: paths}, nil
+}
+
+func cmdRefreshCache(cfg *config.Config, full bool, sourceName string) error {
+	_, err := cmdRefreshCacheTo(os.Stdout, cfg, full, sourceName)
+	return err
 }
 
-func cmdListTo(w io.Writer, cfg *config.Config, searchPattern string) error {
-	// Create source manager
-	sm, err := source.NewSourceManager(cfg.LocalTemplatesPath, cfg.TemplateURL, cfg.EnableToptal)
-	if err != nil {
-		return fmt.Errorf("failed to create source manager: %w", err)
-	}
+// RefreshCacheResult is the outcome of refresh-cache, keyed by source key
+type RefreshCacheResult struct {
+	Dir       string                  `json:"dir"` // the cache directory
+	Refreshed map[string]CachedSource `json:"refreshed"`
+	Failed    map[string]string       `json:"failed"` // why each source could not be cached
+}
 
-	// Get all files grouped by source
-	filesBySource, err := sm.ListBySource()
+// CachedSource is what refresh-cache saved for one source
+type CachedSource struct {
+	Templates int      `json:"templates"`          // templates in the listing
+	Contents  int      `json:"contents"`           // templates whose content was cached
+	Failures  []string `json:"failures,omitempty"` // templates whose content could not be fetched
+}
+
+// cmdRefreshCacheTo saves the template listing of every remote source, or
+// only of sourceName (a source name or key) if given, and with full their
+// template content, to the cache dir used while offline
+// Other sources' cached listings are left as they are; local clones are read
+// from disk anyway and are not cached
+func cmdRefreshCacheTo(w io.Writer, cfg *config.Config, full bool, sourceName string) (RefreshCacheResult, error) {
+	if cfg.Offline {
+		return RefreshCacheResult{}, fmt.Errorf("refresh-cache needs network access, but offline mode is on")
+	}
+	if cfg.CacheDir == "" {
+		return RefreshCacheResult{}, fmt.Errorf("no cache directory: set gitignore.cache-dir")
+	}
+	sm, err := newSourceManager(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to list templates: %w", err)
+		return RefreshCacheResult{}, fmt.Errorf("failed to create source manager: %w", err)
 	}
 
-	// Build flat list of all template paths
-	var allPaths []string
-	var warnings []string
-
-	// Process local templates
-	if localResult, ok := filesBySource["local"]; ok {
-		if localResult.Error != nil {
-			warnings = append(warnings, fmt.Sprintf("⚠️  Local templates: %v (path: %s)", localResult.Error, sm.LocalSource().Dir()))
-		} else {
-			for _, file := range localResult.Files {
-				allPaths = append(allPaths, fmt.Sprintf("local/%s", strings.ToLower(file.Name)))
-			}
-		}
+	if sourceName != "" && !sm.HasSource(sourceName) {
+		return RefreshCacheResult{}, fmt.Errorf("unknown source: %s", sourceName)
 	}
 
-	// Process remote templates
+	cache := source.NewCache(cfg.CacheDir)
+	res := RefreshCacheResult{Dir: cache.Dir(), Refreshed: map[string]CachedSource{}, Failed: map[string]string{}}
+	refreshed := 0
 	for _, src := range sm.RemoteSources() {
-		result, ok := filesBySource[src.Name()]
-		if !ok {
+		if _, ok := src.(*source.CloneSource); ok {
 			continue
 		}
-
-		if result.Error != nil {
-			msg := fmt.Sprintf("⚠️  %s: %v", formatSourceName(src.Name()), result.Error)
-			if src.Name() == "github" {
-				if gs, ok := src.(*source.GitHubSource); ok {
-					msg += fmt.Sprintf(" (url: %s)", gs.URL())
-				}
-			}
-			warnings = append(warnings, msg)
+		key := sm.SourceKey(src)
+		if sourceName != "" && !strings.EqualFold(sourceName, src.Name()) && !strings.EqualFold(sourceName, key) {
 			continue
 		}
-
-		for _, file := range result.Files {
-			var path string
-			if file.Category == "" {
-				path = fmt.Sprintf("%s/%s", strings.ToLower(src.Name()), strings.ToLower(file.Name))
-			} else {
-				path = fmt.Sprintf("%s/%s/%s", strings.ToLower(src.Name()), strings.ToLower(file.Category), strings.ToLower(file.Name))
-			}
-			allPaths = append(allPaths, path)
+		refreshed++
+		result, err := cache.Refresh(src, full)
+		if err != nil {
+			fmt.Fprintf(w, "%s: failed: %v\n", key, err)
+			res.Failed[key] = err.Error()
+			continue
 		}
-	}
-
-	// Sort all paths alphabetically
-	sort.Strings(allPaths)
-
-	// Filter by search pattern if provided
-	if searchPattern != "" {
-		searchLower := strings.ToLower(searchPattern)
-		var filtered []string
-		for _, path := range allPaths {
-			if strings.Contains(path, searchLower) {
-				filtered = append(filtered, path)
-			}
+		cached := CachedSource{Templates: result.Templates, Contents: result.Contents}
+		for _, failure := range result.Failures {
+			cached.Failures = append(cached.Failures, failure.Error())
 		}
-		allPaths = filtered
-	}
-
-	// Print warnings first (always to stderr)
-	for _, warn := range warnings {
-		fmt.Fprintln(os.Stderr, warn)
-	}
-	if len(warnings) > 0 {
-		fmt.Fprintln(os.Stderr)
-	}
-
-	// Print paths
-	if len(allPaths) == 0 {
-		if searchPattern != "" {
-			fmt.Fprintf(w, "No templates matching '%s'\n", searchPattern)
-		} else {
-			fmt.Fprintln(w, "No templates available")
+		res.Refreshed[key] = cached
+		if !full {
+			fmt.Fprintf(w, "%s: %d templates\n", key, result.Templates)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %d templates, %d with content\n", key, result.Templates, result.Contents)
+		for _, failure := range result.Failures {
+			fmt.Fprintf(w, "  Warning: %v\n", failure)
 		}
-		return nil
 	}
+	if sourceName != "" && refreshed == 0 {
+		return res, fmt.Errorf("source '%s' is read from disk and has nothing to cache", sourceName)
+	}
+	fmt.Fprintf(w, "Cache: %s\n", cache.Dir())
 
-	for _, path := range allPaths {
-		fmt.Fprintln(w, path)
+	if len(res.Failed) > 0 {
+		return res, fmt.Errorf("%d source(s) could not be cached", len(res.Failed))
 	}
+	return res, nil
+}
 
-	return nil
+func cmdStats() error {
+	_, err := cmdStatsTo(os.Stdout)
+	return err
 }
 
-// formatSourceName returns a human-readable source name
-func formatSourceName(source string) string {
-	switch source {
-	case "local":
-		return "Local"
-	case "github":
-		return "GitHub"
-	case "toptal":
-		return "Toptal"
-	default:
-		return source
+// cmdStatsTo prints counts of the sections, patterns and comments in the
+// gitignore file
+func cmdStatsTo(w io.Writer) (gitignore.Stats, error) {
+	manager, err := newManager()
+	if err != nil {
+		return gitignore.Stats{}, err
+	}
+	stats, err := manager.Stats()
+	if err != nil {
+		return gitignore.Stats{}, err
 	}
+
+	fmt.Fprintf(w, "Managed sections:   %d\n", stats.Sections)
+	fmt.Fprintf(w, "Patterns:           %d\n", stats.Patterns)
+	fmt.Fprintf(w, "Ad-hoc patterns:    %d\n", stats.AdHoc)
+	fmt.Fprintf(w, "Duplicate patterns: %d\n", stats.Duplicates)
+	fmt.Fprintf(w, "Comment lines:      %d\n", stats.Comments)
+	return stats, nil
 }
 
-func cmdAdd(cfg *config.Config, templateType string) error {
-	return cmdAddTo(os.Stdout, cfg, templateType)
+func cmdCheck(name string) error {
+	_, err := cmdCheckTo(os.Stdout, name)
+	return err
 }
 
-func cmdAddTo(w io.Writer, cfg *config.Config, templateType string) error {
-	// Create source manager
-	sm, err := source.NewSourceManager(cfg.LocalTemplatesPath, cfg.TemplateURL, cfg.EnableToptal)
+// cmdCheckTo reports whether a path would be ignored by the patterns in
+// .gitignore, and which pattern decides it
+// The path is taken relative to the working directory
+func cmdCheckTo(w io.Writer, name string) (gitignore.CheckResult, error) {
+	manager, err := newManager()
 	if err != nil {
-		return fmt.Errorf("failed to create source manager: %w", err)
+		return gitignore.CheckResult{}, err
 	}
 
-	// GetAny handles source prefixes automatically (e.g., "github/rust" vs "rust")
-	file, content, err := sm.GetAny(templateType)
+	patterns, err := manager.PatternLines()
 	if err != nil {
-		return err
+		return gitignore.CheckResult{}, err
 	}
 
-	// Get current working directory
-	cwd, err := os.Getwd()
+	// Patterns are relative to the directory holding the file; for
+	// .git/info/exclude that is the top of the working tree, which in a
+	// worktree or submodule isn't next to the git directory
+	root := filepath.Dir(manager.Path())
+	if globals.exclude {
+		top, err := gitignore.FindWorkTree(".")
+		if err != nil {
+			return gitignore.CheckResult{}, err
+		}
+		if top != "" {
+			root = top
+		}
+	}
+	abs, err := filepath.Abs(name)
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return gitignore.CheckResult{}, err
 	}
-
-	// Create section name (include category if present)
-	sectionName := file.Name
-	if file.Category != "" {
-		sectionName = file.Category + "/" + file.Name
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return gitignore.CheckResult{}, fmt.Errorf("path '%s' is outside %s", name, root)
 	}
 
-	// Add to gitignore
-	manager := gitignore.NewManager(cwd)
-	if err := manager.Add(sectionName, content); err != nil {
-		return err
+	isDir := strings.HasSuffix(name, "/")
+	if info, err := os.Stat(abs); err == nil && info.IsDir() {
+		isDir = true
 	}
 
-	// Build display path like list/search (lowercase source/category/name)
-	var displayPath string
-	if file.Category == "" {
-		displayPath = fmt.Sprintf("%s/%s", strings.ToLower(file.Source), strings.ToLower(file.Name))
-	} else {
-		displayPath = fmt.Sprintf("%s/%s/%s", strings.ToLower(file.Source), strings.ToLower(file.Category), strings.ToLower(file.Name))
+	result := gitignore.CheckPath(patterns, filepath.ToSlash(rel), isDir)
+	switch {
+	case result.Ignored && result.Parent != "":
+		fmt.Fprintf(w, "%s is ignored: its directory '%s' matches '%s'\n", name, result.Parent, result.Pattern)
+	case result.Ignored:
+		fmt.Fprintf(w, "%s is ignored by '%s'\n", name, result.Pattern)
+	case result.Pattern != "":
+		fmt.Fprintf(w, "%s is not ignored: re-included by '%s'\n", name, result.Pattern)
+	default:
+		fmt.Fprintf(w, "%s is not ignored\n", name)
 	}
-	fmt.Fprintf(w, "Added '%s' to .gitignore\n", displayPath)
-	return nil
+	return result, nil
 }
 
-func cmdDelete(templateType string) error {
-	return cmdDeleteTo(os.Stdout, templateType)
+func cmdHas(pattern string) error {
+	_, err := cmdHasTo(os.Stdout, pattern)
+	return err
 }
 
-func cmdDeleteTo(w io.Writer, templateType string) error {
-	// Get current working directory
-	cwd, err := os.Getwd()
+// cmdHasTo reports whether pattern (see Manager.ContainsPattern) is in
+// .gitignore, returning errNo as well as false if it isn't so scripts can
+// test the exit status
+func cmdHasTo(w io.Writer, pattern string) (bool, error) {
+	manager, err := newManager()
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return false, err
 	}
 
-	manager := gitignore.NewManager(cwd)
-
-	// Try to delete the section
-	if err := manager.Delete(templateType); err != nil {
-		return err
+	found, err := manager.ContainsPattern(pattern)
+	if err != nil {
+		return false, err
 	}
-
-	fmt.Fprintf(w, "Removed '%s' from .gitignore\n", templateType)
-	return nil
+	if !found {
+		fmt.Fprintf(w, "'%s' is not in %s\n", pattern, filepath.Base(manager.Path()))
+		return false, errNo
+	}
+	fmt.Fprintf(w, "'%s' is in %s\n", pattern, filepath.Base(manager.Path()))
+	return true, nil
 }
 
-func cmdInit(cfg *config.Config) error {
-	return cmdInitTo(os.Stdout, cfg)
+func cmdMove(sectionName string, position int) error {
+	_, err := cmdMoveTo(os.Stdout, sectionName, position)
+	return err
 }
 
-func cmdInitTo(w io.Writer, cfg *config.Config) error {
-	if len(cfg.DefaultTypes) == 0 {
-		fmt.Fprintln(w, "No default types configured.")
-		fmt.Fprintln(w, "Add 'gitignore.default-types = github/go, github/global/macos' to your config file.")
-		return nil
-	}
-
-	// Create source manager
-	sm, err := source.NewSourceManager(cfg.LocalTemplatesPath, cfg.TemplateURL, cfg.EnableToptal)
+// cmdMoveTo moves a section to a 1-based position among the managed sections
+func cmdMoveTo(w io.Writer, sectionName string, position int) (SectionsResult, error) {
+	manager, err := newManager()
 	if err != nil {
-		return fmt.Errorf("failed to create source manager: %w", err)
+		return SectionsResult{}, err
 	}
+	res := SectionsResult{Path: manager.Path()}
 
-	cwd, err := os.Getwd()
+	sections, err := manager.ListSections()
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return res, err
+	}
+	if position < 1 || position > len(sections) {
+		return res, fmt.Errorf("position %d out of range (1-%d)", position, len(sections))
+	}
+	if err := manager.MoveSection(sectionName, position-1); err != nil {
+		return res, err
 	}
 
-	manager := gitignore.NewManager(cwd)
-	addedCount := 0
-	skippedCount := 0
-
-	fmt.Fprintf(w, "Initializing .gitignore with default types: %s\n\n", strings.Join(cfg.DefaultTypes, ", "))
-
-	for _, templateType := range cfg.DefaultTypes {
-		// Check if already exists
-		exists, err := manager.HasSection(templateType)
-		if err != nil {
-			fmt.Fprintf(w, "  Warning: could not check for '%s': %v\n", templateType, err)
-			continue
-		}
-		if exists {
-			fmt.Fprintf(w, "  Skipping '%s' (already exists)\n", templateType)
-			skippedCount++
-			continue
-		}
-
-		// GetAny handles source prefixes automatically (e.g., "github/rust" vs "rust")
-		file, content, err := sm.GetAny(templateType)
-		if err != nil {
-			fmt.Fprintf(w, "  Warning: template '%s' not found\n", templateType)
-			continue
-		}
+	fmt.Fprintf(w, "Moved '%s' to position %d\n", sectionName, position)
+	res.Sections = []string{sectionName}
+	return res, nil
+}
 
-		// Create section name (include category if present)
-		sectionName := file.Name
-		if file.Category != "" {
-			sectionName = file.Category + "/" + file.Name
-		}
+func cmdImport(file string, detect bool) error {
+	_, err := cmdImportTo(os.Stdout, file, detect)
+	return err
+}
 
-		// Add to gitignore
-		if err := manager.Add(sectionName, content); err != nil {
-			fmt.Fprintf(w, "  Warning: failed to add '%s': %v\n", templateType, err)
-			continue
-		}
+// cmdImportTo brings hand-written content under management
+// With no file (or the managed file itself), unmanaged lines are converted in
+// place; otherwise the file's content is appended as new sections
+func cmdImportTo(w io.Writer, file string, detect bool) (SectionsResult, error) {
+	manager, err := newManager()
+	if err != nil {
+		return SectionsResult{}, err
+	}
+	res := SectionsResult{Path: manager.Path()}
 
-		// Build display path like list/search (lowercase source/category/name)
-		var displayPath string
-		if file.Category == "" {
-			displayPath = fmt.Sprintf("%s/%s", strings.ToLower(file.Source), strings.ToLower(file.Name))
-		} else {
-			displayPath = fmt.Sprintf("%s/%s/%s", strings.ToLower(file.Source), strings.ToLower(file.Category), strings.ToLower(file.Name))
+	var created []string
+	if file == "" || sameFile(file, manager.Path()) {
+		created, err = manager.Adopt(detect)
+	} else {
+		content, readErr := os.ReadFile(file)
+		if readErr != nil {
+			return res, fmt.Errorf("failed to read %s: %w", file, readErr)
 		}
-		fmt.Fprintf(w, "  Added '%s'\n", displayPath)
-		addedCount++
+		created, err = manager.Import(string(content), detect)
+	}
+	if err != nil {
+		return res, err
 	}
+	res.Sections = created
 
-	fmt.Fprintf(w, "\nDone: %d added, %d skipped\n", addedCount, skippedCount)
-	return nil
+	if len(created) == 0 {
+		fmt.Fprintln(w, "Nothing to import")
+		return res, nil
+	}
+	for _, name := range created {
+		fmt.Fprintf(w, "Imported section '%s'\n", name)
+	}
+	return res, nil
 }
 
-func cmdIgnore(patterns []string) error {
-	return cmdIgnoreTo(os.Stdout, patterns)
+func cmdExport(output string) error {
+	_, err := cmdExportTo(os.Stdout, output)
+	return err
 }
 
-func cmdIgnoreTo(w io.Writer, patterns []string) error {
-	// Get current working directory
-	cwd, err := os.Getwd()
+// cmdExportTo writes the gitignore without section markers to w, or to the
+// output file if one is given
+func cmdExportTo(w io.Writer, output string) (FileResult, error) {
+	manager, err := newManager()
 	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+		return FileResult{}, err
 	}
 
-	manager := gitignore.NewManager(cwd)
-	added, skipped, err := manager.AddPatterns(patterns)
+	content, err := manager.Export()
 	if err != nil {
-		return err
+		return FileResult{}, err
 	}
 
-	for _, pattern := range added {
-		fmt.Fprintf(w, "Added '%s' to .gitignore\n", pattern)
+	if output == "" {
+		_, err := io.WriteString(w, content)
+		return FileResult{}, err
 	}
-	for _, pattern := range skipped {
-		fmt.Fprintf(w, "Skipped '%s' (already exists)\n", pattern)
+	if sameFile(output, manager.Path()) {
+		return FileResult{}, fmt.Errorf("refusing to export over %s; choose a different output file", manager.Path())
 	}
-
-	if len(added) == 0 && len(skipped) == 0 {
-		fmt.Fprintln(w, "No patterns to add")
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		return FileResult{}, fmt.Errorf("failed to write %s: %w", output, err)
 	}
+	fmt.Fprintf(w, "Exported .gitignore to %s\n", output)
+	return FileResult{Path: output, Changed: true}, nil
+}
 
-	return nil
+// sameFile reports whether two paths refer to the same file
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
 }
 
-func cmdRemove(patterns []string) error {
-	return cmdRemoveTo(os.Stdout, patterns)
+func cmdVersion(asJSON bool) error {
+	_, err := cmdVersionTo(os.Stdout, asJSON)
+	return err
 }
 
-func cmdRemoveTo(w io.Writer, patterns []string) error {
-	// Get current working directory
-	cwd, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %w", err)
+// cmdVersionTo prints the version, or all build metadata as JSON
+func cmdVersionTo(w io.Writer, asJSON bool) (versionInfo, error) {
+	info := getVersionInfo()
+	if !asJSON {
+		fmt.Fprintf(w, "gitignore version %s\n", info.Version)
+		return info, nil
 	}
+	return info, json.NewEncoder(w).Encode(info)
+}
 
-	manager := gitignore.NewManager(cwd)
+// toolError is the JSON error envelope returned by MCP tools when sources
+// failed, so a client can decide whether to retry or pick another source
+type toolError struct {
+	Error   string            `json:"error"`
+	Offline bool              `json:"offline"`
+	Sources []toolSourceError `json:"sources"`
+}
 
-	for _, pattern := range patterns {
-		if err := manager.RemovePattern(pattern); err != nil {
-			fmt.Fprintf(w, "Warning: %v\n", err)
-			continue
-		}
-		fmt.Fprintf(w, "Removed '%s' from .gitignore\n", pattern)
+// toolSourceError is one source's error in a toolError
+type toolSourceError struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// toolErrorResult returns an MCP error result for err, as a toolError
+// envelope (in both the text and structured content) when err carries
+// per-source errors, otherwise as plain text
+func toolErrorResult(err error) *mcp.CallToolResult {
+	var sourcesErr *source.SourcesError
+	if !errors.As(err, &sourcesErr) {
+		return mcp.NewToolResultError(err.Error())
 	}
 
-	return nil
+	envelope := toolError{
+		Error:   err.Error(),
+		Offline: errors.Is(err, source.ErrOffline),
+		Sources: []toolSourceError{},
+	}
+	for _, failure := range sourcesErr.Errors {
+		envelope.Sources = append(envelope.Sources, toolSourceError{Source: failure.Source, Error: failure.Err.Error()})
+	}
+	data, jsonErr := json.Marshal(envelope)
+	if jsonErr != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	result := mcp.NewToolResultStructured(envelope, string(data))
+	result.IsError = true
+	return result
 }
 
 // cmdServe starts an MCP server that exposes gitignore tools
-func cmdServe() error {
-	// Load configuration once for reuse across tool calls
-	cfg, err := config.Load()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
-	}
-
+// The configuration is loaded once by run and reused across tool calls
+func cmdServe(cfg *config.Config) error {
 	// Create MCP server
 	s := server.NewMCPServer(
 		"gitignore",
@@ -431,10 +3756,11 @@ func cmdServe() error {
 	)
 	s.AddTool(listTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		var buf bytes.Buffer
-		if err := cmdListTo(&buf, cfg, ""); err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+		res, err := cmdListTo(&buf, cfg, listOptions{})
+		if err != nil {
+			return toolErrorResult(err), nil
 		}
-		return mcp.NewToolResultText(buf.String()), nil
+		return mcp.NewToolResultStructured(res, buf.String()), nil
 	})
 
 	// Register gitignore_search tool
@@ -442,7 +3768,7 @@ func cmdServe() error {
 		mcp.WithDescription("Search for gitignore templates by name pattern"),
 		mcp.WithString("pattern",
 			mcp.Required(),
-			mcp.Description("Search pattern to filter templates (case-insensitive substring match)"),
+			mcp.Description("Search pattern to filter templates (case-insensitive substring match, or a glob such as 'global/*' when it contains *, ? or [)"),
 		),
 	)
 	s.AddTool(searchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -451,10 +3777,11 @@ func cmdServe() error {
 			return mcp.NewToolResultError("pattern parameter is required"), nil
 		}
 		var buf bytes.Buffer
-		if err := cmdListTo(&buf, cfg, pattern); err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+		res, err := cmdListTo(&buf, cfg, listOptions{search: pattern})
+		if err != nil {
+			return toolErrorResult(err), nil
 		}
-		return mcp.NewToolResultText(buf.String()), nil
+		return mcp.NewToolResultStructured(res, buf.String()), nil
 	})
 
 	// Register gitignore_add tool
@@ -464,6 +3791,9 @@ func cmdServe() error {
 			mcp.Required(),
 			mcp.Description("Template type to add (e.g., 'go', 'github/rust', 'toptal/python')"),
 		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Return a diff of the change without writing .gitignore (default: false)"),
+		),
 	)
 	s.AddTool(addTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		templateType, err := request.RequireString("type")
@@ -471,10 +3801,11 @@ func cmdServe() error {
 			return mcp.NewToolResultError("type parameter is required"), nil
 		}
 		var buf bytes.Buffer
-		if err := cmdAddTo(&buf, cfg, templateType); err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+		res, err := cmdAddTo(&buf, cfg, templateType, addOptions{dryRun: request.GetBool("dry_run", false)})
+		if err != nil {
+			return toolErrorResult(err), nil
 		}
-		return mcp.NewToolResultText(buf.String()), nil
+		return mcp.NewToolResultStructured(res, buf.String()), nil
 	})
 
 	// Register gitignore_delete tool
@@ -484,6 +3815,9 @@ func cmdServe() error {
 			mcp.Required(),
 			mcp.Description("Template type/section name to remove from .gitignore"),
 		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Return a diff of the change without writing .gitignore (default: false)"),
+		),
 	)
 	s.AddTool(deleteTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		templateType, err := request.RequireString("type")
@@ -491,10 +3825,11 @@ func cmdServe() error {
 			return mcp.NewToolResultError("type parameter is required"), nil
 		}
 		var buf bytes.Buffer
-		if err := cmdDeleteTo(&buf, templateType); err != nil {
+		res, err := cmdDeleteTo(&buf, cfg, templateType, false, request.GetBool("dry_run", false))
+		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		return mcp.NewToolResultText(buf.String()), nil
+		return mcp.NewToolResultStructured(res, buf.String()), nil
 	})
 
 	// Register gitignore_ignore tool
@@ -505,6 +3840,9 @@ func cmdServe() error {
 			mcp.Required(),
 			mcp.Description("Array of patterns to add to .gitignore (e.g., ['node_modules', '*.log', 'dist/'])"),
 		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Return a diff of the change without writing .gitignore (default: false)"),
+		),
 	)
 	s.AddTool(ignoreTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args := request.GetArguments()
@@ -522,10 +3860,11 @@ func cmdServe() error {
 			return mcp.NewToolResultError("patterns must contain at least one string"), nil
 		}
 		var buf bytes.Buffer
-		if err := cmdIgnoreTo(&buf, patterns); err != nil {
+		res, err := cmdIgnoreTo(&buf, patterns, false, request.GetBool("dry_run", false))
+		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		return mcp.NewToolResultText(buf.String()), nil
+		return mcp.NewToolResultStructured(res, buf.String()), nil
 	})
 
 	// Register gitignore_remove tool
@@ -536,6 +3875,12 @@ func cmdServe() error {
 			mcp.Required(),
 			mcp.Description("Array of patterns to remove from .gitignore"),
 		),
+		mcp.WithString("section",
+			mcp.Description("Remove the patterns from this section only, leaving identical patterns in other sections (default: remove patterns added with gitignore_ignore)"),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("Return a diff of the change without writing .gitignore (default: false)"),
+		),
 	)
 	s.AddTool(removeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		args := request.GetArguments()
@@ -553,10 +3898,12 @@ func cmdServe() error {
 			return mcp.NewToolResultError("patterns must contain at least one string"), nil
 		}
 		var buf bytes.Buffer
-		if err := cmdRemoveTo(&buf, patterns); err != nil {
+		section := request.GetString("section", "")
+		res, err := cmdRemoveTo(&buf, patterns, section, false, request.GetBool("dry_run", false))
+		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		return mcp.NewToolResultText(buf.String()), nil
+		return mcp.NewToolResultStructured(res, buf.String()), nil
 	})
 
 	// Register gitignore_init tool
@@ -565,10 +3912,73 @@ func cmdServe() error {
 	)
 	s.AddTool(initTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		var buf bytes.Buffer
-		if err := cmdInitTo(&buf, cfg); err != nil {
+		res, err := cmdInitTo(&buf, cfg, "")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultStructured(res, buf.String()), nil
+	})
+
+	// Register gitignore_config tool
+	configTool := mcp.NewTool("gitignore_config",
+		mcp.WithDescription("Show the effective configuration: template URL, Toptal setting, local templates path, default types and the sources consulted in priority order"),
+		mcp.WithString("format",
+			mcp.Description("Output format: 'text' (default) or 'json'"),
+			mcp.Enum("text", "json"),
+		),
+	)
+	s.AddTool(configTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		var buf bytes.Buffer
+		res, err := cmdConfigTo(&buf, cfg, request.GetString("format", "text") == "json")
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return mcp.NewToolResultStructured(res, buf.String()), nil
+	})
+
+	// Register gitignore_read tool
+	readTool := mcp.NewTool("gitignore_read",
+		mcp.WithDescription("Read the current .gitignore file content (read-only)"),
+	)
+	s.AddTool(readTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		manager, err := newManager()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		content, err := manager.Read()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if content == "" {
+			return mcp.NewToolResultText(fmt.Sprintf("%s is empty or does not exist", manager.Path())), nil
+		}
+		return mcp.NewToolResultText(content), nil
+	})
+
+	// Register gitignore_sections tool
+	sectionsTool := mcp.NewTool("gitignore_sections",
+		mcp.WithDescription("List the managed sections in the current .gitignore, one per line (read-only). Use this before gitignore_add to avoid duplicate sections"),
+		mcp.WithBoolean("sorted",
+			mcp.Description("Return sections in alphabetical order instead of file order (default: false)"),
+		),
+	)
+	s.AddTool(sectionsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		manager, err := newManager()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		list := manager.ListSections
+		if request.GetBool("sorted", false) {
+			list = manager.ListSectionsSorted
+		}
+		sections, err := list()
+		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		return mcp.NewToolResultText(buf.String()), nil
+		if len(sections) == 0 {
+			return mcp.NewToolResultText("No managed sections"), nil
+		}
+		return mcp.NewToolResultText(strings.Join(sections, "\n") + "\n"), nil
 	})
 
 	// Run the server using stdio transport
@@ -581,29 +3991,133 @@ func printUsage() {
 Usage:
   gitignore list                List all available templates
   gitignore search <pattern>    Search templates by name
+  gitignore categories          List template categories (e.g. github/global)
   gitignore add <type>          Add a gitignore template to .gitignore
   gitignore delete <type>       Remove a gitignore template from .gitignore
   gitignore ignore <pattern>    Add a path/pattern directly to .gitignore
   gitignore remove <pattern>    Remove a path/pattern added via ignore
-  gitignore init                Initialize .gitignore with configured default types
+  gitignore init [preset]       Initialize .gitignore with default types or a preset
+  gitignore init --suggest      Suggest templates for the languages found in this directory
+  gitignore sync                Add default types missing from .gitignore (--prune, --dry-run)
+  gitignore presets             List configured presets
+  gitignore config [--json]     Show the effective configuration
+  gitignore sort [section...]   Sort patterns within managed sections
+  gitignore move <section>      Reorder a section (--to <n>, 1 = first)
+  gitignore tidy                Normalize blank lines and trailing whitespace
+  gitignore clean               Remove managed sections that contain no patterns
+  gitignore uninstall           Remove every managed section, keeping hand-written rules (--dry-run)
+  gitignore diff <type>         Compare a section in .gitignore with the upstream template
+  gitignore new <name>          Create a local template (--force to overwrite, --edit to open it)
+  gitignore edit <name>         Open a local template in $EDITOR
+  gitignore sections [--sorted] List managed sections in file or alphabetical order
+  gitignore check <path>        Show whether a path is ignored and by which pattern
+  gitignore has <pattern>       Exit 0 if a pattern is in .gitignore, 1 if not
+  gitignore stats               Count sections, patterns, duplicates and comments
+  gitignore which <type>        Show which source and file 'add <type>' would use
+  gitignore refresh-cache       Save remote template listings for offline use (--full for content,
+                                --source <name> for one source)
+  gitignore ls-remote <url>     List the .gitignore files in any GitHub repository
+  gitignore restore             Restore .gitignore from its backup (gitignore.backup)
+  gitignore import [file]       Wrap hand-written content in managed sections
+  gitignore export [-o <file>]  Print .gitignore without section markers
   gitignore serve               Start MCP server for AI assistant integration
   gitignore --help              Show this help message
-  gitignore --version           Show version information
+  gitignore --version           Show version information (--json for build metadata)
+
+Ignore Options:
+  --normalize                   Skip patterns equivalent to one already ignored
+                                (only a leading ./ is ignored: dist and ./dist are the
+                                same, but a leading / (anchored) or trailing / (directories
+                                only) changes what git matches, so dist, /dist and dist/ differ)
+
+Import Options:
+  --detect                      Split by comment headers instead of one Legacy section
+
+Delete/Remove Options:
+  --force                       Succeed when the section or pattern is not present
+                                (with --glob, also skip the confirmation prompt)
+  --glob                        Delete every section matching a pattern (e.g. 'Global/*')
+  --section <name>              Remove patterns from this section only, not other sections
+
+Add Options:
+  --sort                        Sort the template's patterns before adding
+  --minimal                     Drop the template's comments and blank lines
+  --replace                     Overwrite the section if it already exists
+  --upsert                      Same as --replace: add the section or update it in place
+  --after <section>             Insert the new section after an existing one
+  --before <section>            Insert the new section before an existing one
+  --append-to <section>         Merge new lines into an existing section instead
+  --create                      With --append-to, add the section if it is missing
+  --merge                       Merge new lines into the section for the same template
+                                from another source (e.g. Toptal's go into GitHub's Go)
+  --merge-includes              Put the templates from gitignore.includes in the template's
+                                own section instead of sections of their own
+  --from-url <url>              Download the template from a raw URL instead of a source
+  --name <name>                 Section name for --from-url (default: the URL's file name)
+  --dedupe, --no-dedupe         Leave out (or keep) patterns .gitignore already has
+                                (default: gitignore.dedupe-on-add, off)
+  --prefix <dir>                Scope the template's patterns to a subdirectory, in a
+                                section of its own (e.g. --prefix frontend/)
+
+Add/Delete/Ignore/Remove Options:
+  --dry-run                     Print the change as a diff instead of writing .gitignore
+
+Global Options:
+  --path <file>                 Operate on a specific .gitignore file instead of ./.gitignore
+  --config <file>               Load only this config file (no home directory files or environment)
+  --verbose, -v                 Log HTTP requests and template resolution to stderr
+                                (-v on its own shows the version)
+  --debug                       Like --verbose, plus every source lookup step
+  --offline                     Use only local templates; never touch the network
+  --timeout <duration>          Give up on sources after this long (e.g. 30s; default: none)
+  --exclude                     Operate on .git/info/exclude (patterns that are not committed)
+  --no-warnings, --quiet, -q    Hide warnings about sources that failed to list
+
+List/Search Options:
+  --annotate                    Mark the entry 'add <name>' would select
+  --installed                   List the templates already in .gitignore
+  --available                   List the template catalog (default)
+  --count                       Print only the number of matching templates
+  --format <template>           Print paths as e.g. '{source}:{category}:{name}'
+  --tree                        Print results as a tree by source and category
+  --updated-since <date>        Only GitHub templates changed upstream since date (YYYY-MM-DD)
+  --json                        Print results as JSON (with --count: {"count": n})
+  --category <name>             Only list templates in a category (e.g. Global)
+  --local-only                  Only list local templates (no network access)
+  --remote-only                 Only list remote templates
+  --source <name>               Only list templates from one source (local, github, toptal)
 
 Examples:
   gitignore list                # List all available templates
   gitignore search rust         # Search for templates containing "rust"
+  gitignore search rust --annotate # Show which "rust" template add would pick
+  gitignore search py --count   # Count templates matching "py"
+  gitignore search 'global/*'   # Glob search: every template in Global
+  gitignore list --category Global # List only the Global/* templates
+  gitignore list --installed --json # Templates in .gitignore, for tooling
+  gitignore list --updated-since 2025-01-01 # GitHub templates changed this year
   gitignore add Go              # Add Go template (auto-selects source by priority)
   gitignore add github/go       # Add Go template from GitHub
   gitignore add toptal/rust     # Add Rust template from Toptal
   gitignore add local/myproject # Add custom template from local directory
   gitignore delete Go           # Remove Go template
+  gitignore delete Go --force   # Remove Go template if present; never fails if missing
+  gitignore delete --glob 'Global/*' # Remove all Global sections after confirming
   gitignore ignore /dist/       # Add /dist/ pattern to .gitignore
   gitignore ignore node_modules # Add node_modules to .gitignore
   gitignore ignore *.log tmp/   # Add multiple patterns at once
   gitignore remove /dist/       # Remove /dist/ pattern from .gitignore
   gitignore remove node_modules # Remove node_modules from .gitignore
   gitignore init                # Add all default types from config
+  gitignore init webapp         # Add every template in the webapp preset
+  gitignore init --suggest      # Print add commands for detected languages
+  gitignore sync --prune        # Match .gitignore to the default types
+  gitignore sort Go             # Sort patterns in the Go section
+  gitignore move Global/macOS --to 3 # Make macOS the third section
+  gitignore import --detect     # Adopt an existing hand-written .gitignore
+  gitignore export -o share.gitignore # Write a marker-free copy
+  gitignore --path services/api/.gitignore add go  # Target a nested .gitignore
+  gitignore --exclude ignore .idea/  # Ignore locally without committing the pattern
   gitignore serve               # Start MCP server (for AI assistants)
 
 Template Sources (in priority order):
@@ -612,28 +4126,94 @@ Template Sources (in priority order):
      - Create your own templates here
   2. GitHub: Repository configured in gitignorerc
   3. Toptal: API fallback (if enable.toptal.gitignore = true)
+  4. Built-in: Go, Node, Python, macOS, Windows and VisualStudioCode,
+     embedded in the binary for when nothing else is reachable
+  Set gitignore.source-priority to change the order.
 
 Configuration:
   Create ~/.config/gitignore/gitignorerc or ~/.gitignorerc with:
 
-    # GitHub repository URL for templates
+    # GitHub repository URL(s) for templates (comma-separated, earlier wins)
     gitignore.template.url = https://github.com/github/gitignore
 
+    # Pin GitHub templates to a branch, tag or commit (or use .../tree/<ref> URLs)
+    gitignore.template.ref = main
+
+    # Read templates from a subdirectory of the repository instead of its root
+    gitignore.template.path = templates
+
+    # Without a template URL, use the origin remote owner's gitignore repository
+    gitignore.template.url-from-remote = true
+
     # Enable Toptal API as fallback source
     enable.toptal.gitignore = true
 
     # Path to local templates directory
     gitignore.local-templates-path = ~/.config/gitignore/templates
 
+    # Write through a symlinked .gitignore instead of refusing to
+    gitignore.follow-symlinks = false
+
+    # Leave patterns .gitignore already has out of added templates
+    gitignore.dedupe-on-add = true
+
     # Default types for 'init' command
     gitignore.default-types = github/go, github/global/macos, github/global/visualstudiocode
 
-  The ~/.gitignorerc file takes precedence if both exist.
+    # Record the source and date in a comment on each added section
+    gitignore.add-header = true
+
+    # Never contact remote sources (same as --offline)
+    gitignore.offline = false
+
+    # Search order (sources left out follow in default order)
+    gitignore.source-priority = local, toptal, github
+
+    # Aliases: shorthand names for 'add', presets and default-types
+    gitignore.alias.vscode = github/global/visualstudiocode
+
+    # Includes: templates 'add <name>' also adds, each as its own section
+    gitignore.includes.rails = github/ruby, github/node
+
+    # Presets: named template groups for 'init <preset>'
+    gitignore.preset.webapp = node, github/global/macos
+    gitignore.preset.webapp.description = standard Node web app
+
+    # Fetch GitHub templates via api.github.com first (for proxies that
+    # block raw.githubusercontent.com; raw is always tried as a fallback)
+    gitignore.github.content-api = false
+
+    # Copy .gitignore to .gitignore.bak before each change ('restore' undoes it)
+    gitignore.backup = true
+
+    # User-Agent for HTTP requests (default: gitignore/<version>)
+    gitignore.user-agent = gitignore/1.0
+
+    # Proxy for HTTP requests (default: HTTPS_PROXY/HTTP_PROXY from the environment)
+    gitignore.http-proxy = http://proxy.example.com:8080
+
+    # Where 'refresh-cache' saves remote listings for offline use
+    gitignore.cache-dir = ~/.cache/gitignore
+
+    # Templates that may not be added (path.Match patterns on source/path)
+    gitignore.blocklist = toptal/*, github/community/*
+
+    # Section markers (quoted; default: "### START:" and "### END:")
+    gitignore.section.start-prefix = "# >>>"
+    gitignore.section.end-prefix = "# <<<"
+
+    # Fail on unknown keys instead of warning
+    gitignore.strict-config = false
+
+  The ~/.gitignorerc file takes precedence if both exist. Either may also
+  have a TOML variant (gitignorerc.toml), which takes precedence over it.
+  Environment variables such as GITIGNORE_TEMPLATE_URL, GITIGNORE_ENABLE_TOPTAL,
+  GITIGNORE_LOCAL_TEMPLATES_PATH and GITIGNORE_DEFAULT_TYPES override both files.
 
 Local Templates:
   Place custom templates in your local templates directory (default: ~/.config/gitignore/templates/)
   Name files as <type>.gitignore (e.g., myproject.gitignore)
-  Local templates always take precedence over remote sources.
+  Local templates take precedence over remote sources by default.
 
 Default source: https://github.com/github/gitignore
 `
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/polliard/gitignore/src/pkg/config"
+	"github.com/polliard/gitignore/src/pkg/logging"
+)
+
+func TestParseGlobalFlagsVerbose(t *testing.T) {
+	saved := logging.GetLevel()
+	t.Cleanup(func() { logging.SetLevel(saved) })
+
+	for _, flag := range []string{"-v", "--verbose"} {
+		logging.SetLevel(logging.LevelQuiet)
+		rest, err := parseGlobalFlags([]string{flag, "add", "go"})
+		if err != nil {
+			t.Fatalf("parseGlobalFlags(%s) error = %v", flag, err)
+		}
+		if strings.Join(rest, " ") != "add go" {
+			t.Errorf("parseGlobalFlags(%s) = %q, want [add go]", flag, rest)
+		}
+		if logging.GetLevel() != logging.LevelVerbose {
+			t.Errorf("parseGlobalFlags(%s) level = %v, want verbose", flag, logging.GetLevel())
+		}
+	}
+
+	// On its own, -v is still the version command
+	logging.SetLevel(logging.LevelQuiet)
+	rest, err := parseGlobalFlags([]string{"-v"})
+	if err != nil || strings.Join(rest, " ") != "-v" {
+		t.Errorf("parseGlobalFlags(-v) = %q, %v; want [-v]", rest, err)
+	}
+	if logging.GetLevel() != logging.LevelQuiet {
+		t.Error("parseGlobalFlags(-v) alone should not enable verbose logging")
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if got, want := userAgent(cfg), "gitignore/"+getVersion(); got != want {
+		t.Errorf("userAgent() = %q, want %q", got, want)
+	}
+	cfg.UserAgent = "corp-approved-tool/1.0"
+	if got := userAgent(cfg); got != cfg.UserAgent {
+		t.Errorf("userAgent() = %q, want the configured %q", got, cfg.UserAgent)
+	}
+}
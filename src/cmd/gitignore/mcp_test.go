@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/polliard/gitignore/src/pkg/source"
+)
+
+func TestToolErrorResult(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &source.SourcesError{
+		Err: errors.New("template 'rust' not found in any source"),
+		Errors: []source.SourceError{
+			{Source: "local", Err: errors.New("template 'rust' not found")},
+			{Source: "github", Err: errors.New("GitHub API error (status 503)")},
+		},
+	})
+
+	result := toolErrorResult(err)
+	if !result.IsError {
+		t.Fatal("toolErrorResult() should mark the result as an error")
+	}
+	envelope, ok := result.StructuredContent.(toolError)
+	if !ok {
+		t.Fatalf("StructuredContent = %T, want toolError", result.StructuredContent)
+	}
+	if envelope.Offline || len(envelope.Sources) != 2 || envelope.Sources[1].Source != "github" ||
+		envelope.Sources[1].Error != "GitHub API error (status 503)" {
+		t.Errorf("envelope = %+v", envelope)
+	}
+
+	// The text content carries the same envelope for clients without
+	// structured content support
+	var decoded toolError
+	if err := json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &decoded); err != nil {
+		t.Fatalf("text content is not JSON: %v", err)
+	}
+	if decoded.Error != "wrapped: template 'rust' not found in any source" {
+		t.Errorf("decoded error = %q", decoded.Error)
+	}
+
+	plain := toolErrorResult(errors.New("type parameter is required"))
+	if !plain.IsError || plain.StructuredContent != nil {
+		t.Errorf("toolErrorResult() of a plain error = %+v, want a text error", plain)
+	}
+}
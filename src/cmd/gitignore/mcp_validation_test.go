@@ -106,7 +106,7 @@ func createMCPTools() []mcp.Tool {
 			mcp.WithDescription("Search for gitignore templates by name pattern"),
 			mcp.WithString("pattern",
 				mcp.Required(),
-				mcp.Description("Search pattern to filter templates (case-insensitive substring match)"),
+				mcp.Description("Search pattern to filter templates (case-insensitive substring match, or a glob such as 'global/*' when it contains *, ? or [)"),
 			),
 		),
 
@@ -117,6 +117,9 @@ func createMCPTools() []mcp.Tool {
 				mcp.Required(),
 				mcp.Description("Template type to add (e.g., 'go', 'github/rust', 'toptal/python')"),
 			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("Return a diff of the change without writing .gitignore (default: false)"),
+			),
 		),
 
 		// gitignore_delete - string parameter
@@ -126,6 +129,9 @@ func createMCPTools() []mcp.Tool {
 				mcp.Required(),
 				mcp.Description("Template type/section name to remove from .gitignore"),
 			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("Return a diff of the change without writing .gitignore (default: false)"),
+			),
 		),
 
 		// gitignore_ignore - array parameter (must have items!)
@@ -136,6 +142,9 @@ func createMCPTools() []mcp.Tool {
 				mcp.Required(),
 				mcp.Description("Array of patterns to add to .gitignore (e.g., ['node_modules', '*.log', 'dist/'])"),
 			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("Return a diff of the change without writing .gitignore (default: false)"),
+			),
 		),
 
 		// gitignore_remove - array parameter (must have items!)
@@ -146,12 +155,40 @@ func createMCPTools() []mcp.Tool {
 				mcp.Required(),
 				mcp.Description("Array of patterns to remove from .gitignore"),
 			),
+			mcp.WithString("section",
+				mcp.Description("Remove the patterns from this section only, leaving identical patterns in other sections (default: remove patterns added with gitignore_ignore)"),
+			),
+			mcp.WithBoolean("dry_run",
+				mcp.Description("Return a diff of the change without writing .gitignore (default: false)"),
+			),
 		),
 
 		// gitignore_init - no parameters
 		mcp.NewTool("gitignore_init",
 			mcp.WithDescription("Initialize .gitignore with configured default template types"),
 		),
+
+		// gitignore_config - optional string parameter
+		mcp.NewTool("gitignore_config",
+			mcp.WithDescription("Show the effective configuration: template URL, Toptal setting, local templates path, default types and the sources consulted in priority order"),
+			mcp.WithString("format",
+				mcp.Description("Output format: 'text' (default) or 'json'"),
+				mcp.Enum("text", "json"),
+			),
+		),
+
+		// gitignore_read - no parameters
+		mcp.NewTool("gitignore_read",
+			mcp.WithDescription("Read the current .gitignore file content (read-only)"),
+		),
+
+		// gitignore_sections - no parameters
+		mcp.NewTool("gitignore_sections",
+			mcp.WithDescription("List the managed sections in the current .gitignore, one per line (read-only). Use this before gitignore_add to avoid duplicate sections"),
+			mcp.WithBoolean("sorted",
+				mcp.Description("Return sections in alphabetical order instead of file order (default: false)"),
+			),
+		),
 	}
 }
 
@@ -212,18 +249,60 @@ func TestMCPArrayItemsRequired(t *testing.T) {
 	}
 }
 
+// TestMCPDryRunParameter validates that the tools that change .gitignore
+// accept an optional boolean dry_run
+func TestMCPDryRunParameter(t *testing.T) {
+	tools := createMCPTools()
+
+	dryRunTools := map[string]bool{
+		"gitignore_add":    true,
+		"gitignore_delete": true,
+		"gitignore_ignore": true,
+		"gitignore_remove": true,
+	}
+
+	for _, tool := range tools {
+		if !dryRunTools[tool.Name] {
+			continue
+		}
+		delete(dryRunTools, tool.Name)
+
+		t.Run(tool.Name, func(t *testing.T) {
+			propSchema, ok := tool.InputSchema.Properties["dry_run"].(map[string]any)
+			if !ok {
+				t.Fatalf("tool %q is missing a dry_run property", tool.Name)
+			}
+			if propType, _ := propSchema["type"].(string); propType != "boolean" {
+				t.Errorf("dry_run should be boolean type, got %q", propType)
+			}
+			for _, req := range tool.InputSchema.Required {
+				if req == "dry_run" {
+					t.Error("dry_run should be optional")
+				}
+			}
+		})
+	}
+
+	for name := range dryRunTools {
+		t.Errorf("tool %q not defined", name)
+	}
+}
+
 // TestMCPRequiredParameters validates that required parameters are properly marked
 func TestMCPRequiredParameters(t *testing.T) {
 	tools := createMCPTools()
 
 	expectedRequired := map[string][]string{
-		"gitignore_list":   {},
-		"gitignore_search": {"pattern"},
-		"gitignore_add":    {"type"},
-		"gitignore_delete": {"type"},
-		"gitignore_ignore": {"patterns"},
-		"gitignore_remove": {"patterns"},
-		"gitignore_init":   {},
+		"gitignore_list":     {},
+		"gitignore_search":   {"pattern"},
+		"gitignore_add":      {"type"},
+		"gitignore_delete":   {"type"},
+		"gitignore_ignore":   {"patterns"},
+		"gitignore_remove":   {"patterns"},
+		"gitignore_init":     {},
+		"gitignore_config":   {},
+		"gitignore_read":     {},
+		"gitignore_sections": {},
 	}
 
 	for _, tool := range tools {
@@ -276,6 +355,9 @@ func TestMCPToolCount(t *testing.T) {
 		"gitignore_ignore",
 		"gitignore_remove",
 		"gitignore_init",
+		"gitignore_config",
+		"gitignore_read",
+		"gitignore_sections",
 	}
 
 	if len(tools) != len(expectedTools) {
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/polliard/gitignore/src/pkg/config"
+)
+
+func TestSync(t *testing.T) {
+	templates := t.TempDir()
+	for name, content := range map[string]string{"Go": "*.exe\n", "Rust": "target/\n"} {
+		if err := os.WriteFile(filepath.Join(templates, name+".gitignore"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := config.DefaultConfig()
+	cfg.LocalTemplatesPath = templates
+	cfg.Offline = true
+	cfg.CacheDir = ""
+	cfg.DefaultTypes = []string{"go", "rust", "nosuch"}
+
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	saved := globals
+	t.Cleanup(func() { globals = saved })
+	globals.path = path
+
+	content := "### START: Go\n*.exe\n### END: Go\n\n### START: Python\n*.pyc\n### END: Python\n\n### START: NoSuch\nx\n### END: NoSuch\n\n### START: ignored/dist\ndist/\n### END: ignored/dist\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	res, err := cmdSyncTo(&buf, cfg, true, true)
+	if err != nil {
+		t.Fatalf("cmdSyncTo(dry run) error = %v", err)
+	}
+	if strings.Join(res.Added, ",") != "local/rust" || strings.Join(res.Removed, ",") != "Python" ||
+		strings.Join(res.Kept, ",") != "Go" || strings.Join(res.Failed, ",") != "nosuch" {
+		t.Errorf("cmdSyncTo(dry run) = %+v", res)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("dry run changed the file:\n%s", data)
+	}
+	out := buf.String()
+	for _, want := range []string{"  Would remove 'Python'\n", "  Would add 'local/rust'\n", "Done: would add 1, would remove 1, 1 unchanged\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dry run output = %q, want it to contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "Added") || strings.Contains(out, "Removed") {
+		t.Errorf("dry run output = %q, should not claim changes were made", out)
+	}
+
+	// Without --prune, other sections stay
+	res, err = cmdSyncTo(io.Discard, cfg, false, false)
+	if err != nil {
+		t.Fatalf("cmdSyncTo() error = %v", err)
+	}
+	if strings.Join(res.Extra, ",") != "Python" || len(res.Removed) != 0 {
+		t.Errorf("cmdSyncTo() = %+v", res)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "### START: Rust\n") || !strings.Contains(string(data), "### START: Python\n") {
+		t.Errorf("after sync:\n%s", data)
+	}
+
+	if res, err = cmdSyncTo(io.Discard, cfg, false, false); err != nil || len(res.Added) != 0 {
+		t.Errorf("second cmdSyncTo() = %+v, %v; want nothing to add", res, err)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUninstall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitignore")
+	saved := globals
+	t.Cleanup(func() { globals = saved })
+	globals.path = path
+
+	content := "# mine\nsecret.txt\n\n### START: Go\n*.exe\n### END: Go\n\nlocal/\n\n### START: Go\n*.test\n### END: Go\n\n### START: ignored/dist\ndist/\n### END: ignored/dist\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	res, err := cmdUninstallTo(&buf, true)
+	if err != nil {
+		t.Fatalf("cmdUninstallTo(dry run) error = %v", err)
+	}
+	if strings.Join(res.Sections, ",") != "Go,ignored/dist" {
+		t.Errorf("cmdUninstallTo(dry run) = %+v", res)
+	}
+	want := "Would remove 'Go'\nWould remove 'ignored/dist'\nWould remove 2 managed section(s) from .gitignore; 2 hand-written pattern(s) kept\n"
+	if out := buf.String(); !strings.HasPrefix(out, want) || strings.Contains(out, "Removed") {
+		t.Errorf("dry run output = %q, want it to start with %q", out, want)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("dry run changed the file:\n%s", data)
+	}
+
+	if _, err := cmdUninstallTo(io.Discard, false); err != nil {
+		t.Fatalf("cmdUninstallTo() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# mine\nsecret.txt\n\nlocal/\n" {
+		t.Errorf("after uninstall:\n%s", data)
+	}
+}
@@ -4,9 +4,14 @@ package config
 import (
 	"bufio"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/polliard/gitignore/src/pkg/logging"
 )
 
 const (
@@ -18,14 +23,85 @@ const (
 
 	// ConfigFileName is the name of the config file
 	ConfigFileName = "gitignorerc"
+
+	// presetKeyPrefix starts keys that define presets:
+	//   gitignore.preset.<name> = <type>, <type>, ...
+	//   gitignore.preset.<name>.description = <text>
+	presetKeyPrefix         = "gitignore.preset."
+	presetDescriptionSuffix = ".description"
+
+	// aliasKeyPrefix starts keys that define template aliases:
+	//   gitignore.alias.<name> = <type>
+	aliasKeyPrefix = "gitignore.alias."
+
+	// includesKeyPrefix starts keys that make a template pull in others:
+	//   gitignore.includes.<name> = <type>, <type>, ...
+	includesKeyPrefix = "gitignore.includes."
 )
 
+// EnvOverrides maps environment variables to the config keys they override
+// They are applied by Load after all config files, so they take precedence
+var EnvOverrides = []struct {
+	Env string
+	Key string
+}{
+	{"GITIGNORE_TEMPLATE_URL", "gitignore.template.url"},
+	{"GITIGNORE_TEMPLATE_REF", "gitignore.template.ref"},
+	{"GITIGNORE_TEMPLATE_PATH", "gitignore.template.path"},
+	{"GITIGNORE_TEMPLATE_URL_FROM_REMOTE", "gitignore.template.url-from-remote"},
+	{"GITIGNORE_ENABLE_TOPTAL", "enable.toptal.gitignore"},
+	{"GITIGNORE_LOCAL_TEMPLATES_PATH", "gitignore.local-templates-path"},
+	{"GITIGNORE_DEFAULT_TYPES", "gitignore.default-types"},
+	{"GITIGNORE_ADD_HEADER", "gitignore.add-header"},
+	{"GITIGNORE_OFFLINE", "gitignore.offline"},
+	{"GITIGNORE_SOURCE_PRIORITY", "gitignore.source-priority"},
+	{"GITIGNORE_GITHUB_CONTENT_API", "gitignore.github.content-api"},
+	{"GITIGNORE_BACKUP", "gitignore.backup"},
+	{"GITIGNORE_DEDUPE_ON_ADD", "gitignore.dedupe-on-add"},
+	{"GITIGNORE_FOLLOW_SYMLINKS", "gitignore.follow-symlinks"},
+	{"GITIGNORE_USER_AGENT", "gitignore.user-agent"},
+	{"GITIGNORE_HTTP_PROXY", "gitignore.http-proxy"},
+	{"GITIGNORE_CACHE_DIR", "gitignore.cache-dir"},
+	{"GITIGNORE_BLOCKLIST", "gitignore.blocklist"},
+	{"GITIGNORE_SECTION_START_PREFIX", "gitignore.section.start-prefix"},
+	{"GITIGNORE_SECTION_END_PREFIX", "gitignore.section.end-prefix"},
+}
+
+// Preset is a named group of templates that can be added together
+// Members may name other presets, which are expanded by ExpandPreset
+type Preset struct {
+	Members     []string
+	Description string
+}
+
 // Config holds the application configuration
 type Config struct {
-	TemplateURL        string   // GitHub repository URL for templates
-	EnableToptal       bool     // Enable Toptal gitignore API as fallback source
-	LocalTemplatesPath string   // Path to local templates directory
-	DefaultTypes       []string // Default types for init command
+	TemplateURL        string              // GitHub repository URL for templates
+	TemplateRef        string              // Branch, tag or commit to fetch GitHub templates from (empty = default branch)
+	TemplatePath       string              // Repository subdirectory holding the templates (empty = root)
+	TemplateURLRemote  bool                // Without a template URL, use the origin remote owner's gitignore repository
+	EnableToptal       bool                // Enable Toptal gitignore API as fallback source
+	LocalTemplatesPath string              // Path to local templates directory
+	DefaultTypes       []string            // Default types for init command
+	AddHeader          bool                // Prepend a provenance comment to added sections
+	Offline            bool                // Use only local templates (no network access)
+	SourcePriority     []string            // Source lookup order, e.g. local, toptal, github (empty = default)
+	Presets            map[string]*Preset  // Named template groups, keyed by preset name
+	StrictConfig       bool                // Treat unknown config keys as errors instead of warnings
+	GitHubContentAPI   bool                // Fetch GitHub content via api.github.com before raw URLs
+	Backup             bool                // Copy .gitignore to .gitignore.bak before each change
+	DedupeOnAdd        bool                // Leave out patterns .gitignore already has when adding a template
+	FollowSymlinks     bool                // Write through a symlinked .gitignore to its target instead of refusing
+	Aliases            map[string]string   // Template aliases, keyed by lowercase alias name
+	Includes           map[string][]string // Templates each template pulls in, keyed by lowercase template name
+	UserAgent          string              // User-Agent for HTTP requests (empty = gitignore/<version>)
+	HTTPProxy          string              // Proxy URL for HTTP requests (empty = HTTPS_PROXY/HTTP_PROXY environment)
+	CacheDir           string              // Directory for template listings saved by refresh-cache
+	Blocklist          []string            // path.Match patterns of templates that can't be added, e.g. toptal/*
+	SectionStartPrefix string              // Comment that starts a managed section (empty = ### START:)
+	SectionEndPrefix   string              // Comment that ends a managed section (empty = ### END:)
+
+	templateURLSet bool // gitignore.template.url was given, see TemplateURLSet
 }
 
 // DefaultLocalTemplatesPath returns the default local templates path
@@ -37,18 +113,34 @@ func DefaultLocalTemplatesPath() string {
 	return filepath.Join(home, ".config", "gitignore", "templates")
 }
 
+// DefaultCacheDir returns the default cache directory, e.g.
+// ~/.cache/gitignore on Linux
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gitignore")
+}
+
 // DefaultConfig returns a config with default values
 func DefaultConfig() *Config {
 	return &Config{
 		TemplateURL:        DefaultTemplateURL,
 		EnableToptal:       false,
 		LocalTemplatesPath: DefaultLocalTemplatesPath(),
+		CacheDir:           DefaultCacheDir(),
 		DefaultTypes:       []string{},
+		Presets:            map[string]*Preset{},
+		Aliases:            map[string]string{},
+		Includes:           map[string][]string{},
 	}
 }
 
 // Load reads configuration from config files
-// It checks ~/.config/gitignore/gitignorerc first, then ~/.gitignorerc
+// It checks ~/.config/gitignore/gitignorerc first, then ~/.gitignorerc, each
+// followed by its gitignorerc.toml variant, then the environment variables in
+// EnvOverrides
 // Later values override earlier ones
 func Load() (*Config, error) {
 	cfg := DefaultConfig()
@@ -56,17 +148,13 @@ func Load() (*Config, error) {
 	// Get home directory
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return cfg, nil // Return default config if we can't get home dir
+		cfg.applyEnv()
+		return cfg, cfg.validate() // Return default config if we can't get home dir
 	}
 
 	// Config file locations in order of precedence (later overrides earlier)
-	configPaths := []string{
-		filepath.Join(home, ".config", "gitignore", ConfigFileName),
-		filepath.Join(home, "."+ConfigFileName),
-	}
-
-	for _, path := range configPaths {
-		if err := cfg.loadFromFile(path); err != nil {
+	for _, path := range configPaths(home) {
+		if err := cfg.loadConfigFile(path); err != nil {
 			// Ignore file not found errors
 			if !os.IsNotExist(err) {
 				return nil, fmt.Errorf("error reading config from %s: %w", path, err)
@@ -74,19 +162,170 @@ func Load() (*Config, error) {
 		}
 	}
 
+	cfg.applyEnv()
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
-// LoadFromPath loads configuration from a specific file path
+// applyEnv applies any set environment variables from EnvOverrides
+// An empty value counts as set, so GITIGNORE_DEFAULT_TYPES= clears the list
+func (c *Config) applyEnv() {
+	for _, o := range EnvOverrides {
+		if value, ok := os.LookupEnv(o.Env); ok {
+			c.set(o.Key, strings.TrimSpace(value))
+		}
+	}
+}
+
+// LoadFromPath loads configuration from a specific file path, parsed as
+// TOML if it ends in .toml
 func LoadFromPath(path string) (*Config, error) {
 	cfg := DefaultConfig()
-	if err := cfg.loadFromFile(path); err != nil {
+	if err := cfg.loadConfigFile(path); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
 	return cfg, nil
 }
 
+// validate checks values that must be well-formed before anything uses them
+func (c *Config) validate() error {
+	if _, err := ParseProxyURL(c.HTTPProxy); err != nil {
+		return err
+	}
+	for _, pattern := range c.Blocklist {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid gitignore.blocklist pattern '%s': %w", pattern, err)
+		}
+	}
+	return c.checkDefaultTypes()
+}
+
+// sourcePrefixes are the source names a template type may start with, as
+// recognized by source.NewSourceManagerWithOrder
+var sourcePrefixes = []string{"local", "github", "toptal", "builtin"}
+
+// checkDefaultTypes reports gitignore.default-types entries that are
+// obviously malformed, such as githhub/go, as warnings, or as an error when
+// gitignore.strict-config is enabled
+func (c *Config) checkDefaultTypes() error {
+	var problems []string
+	for _, t := range c.DefaultTypes {
+		if msg := defaultTypeProblem(t); msg != "" {
+			problems = append(problems, fmt.Sprintf("gitignore.default-types entry '%s': %s", t, msg))
+		}
+	}
+	if len(problems) > 0 && c.StrictConfig {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	for _, msg := range problems {
+		logging.Warnf("%s", msg)
+	}
+	return nil
+}
+
+// defaultTypeProblem describes what is wrong with a default type, or returns
+// "" if it is a bare name, a category path or has a recognized source prefix
+// Only a first segment one edit away from a source name counts as a typo, so
+// category paths such as global/macos are left alone
+func defaultTypeProblem(t string) string {
+	if strings.HasPrefix(t, "/") || strings.HasSuffix(t, "/") || strings.Contains(t, "//") {
+		return "empty path segment"
+	}
+	first, rest, hasSlash := strings.Cut(t, "/")
+	if scheme, repo, ok := strings.Cut(first, ":"); ok {
+		if !strings.EqualFold(scheme, "github") {
+			return fmt.Sprintf("unknown source '%s'", scheme)
+		}
+		// github:owner/repo/<template>
+		if repo == "" || strings.Count(rest, "/") < 1 {
+			return "expected github:owner/repo/<template>"
+		}
+		return ""
+	}
+	if !hasSlash {
+		return ""
+	}
+	lower := strings.ToLower(first)
+	for _, name := range sourcePrefixes {
+		if lower == name {
+			return ""
+		}
+	}
+	for _, name := range sourcePrefixes {
+		if editDistance(lower, name) == 1 {
+			return fmt.Sprintf("unknown source '%s', did you mean '%s'?", first, name)
+		}
+	}
+	return ""
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b: insertions, deletions, substitutions and adjacent transpositions
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// ParseProxyURL parses a gitignore.http-proxy value, which must be an http,
+// https or socks5 URL with a host; an empty value returns nil
+func ParseProxyURL(value string) (*url.URL, error) {
+	if value == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid gitignore.http-proxy '%s': must be a URL such as http://proxy.example.com:8080", value)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+		return u, nil
+	}
+	return nil, fmt.Errorf("invalid gitignore.http-proxy '%s': scheme must be http, https or socks5", value)
+}
+
+// Proxy returns the configured proxy URL, or nil to use the environment's
+// proxy settings
+func (c *Config) Proxy() *url.URL {
+	u, _ := ParseProxyURL(c.HTTPProxy)
+	return u
+}
+
+// loadConfigFile reads a config file in the format its name implies
+func (c *Config) loadConfigFile(path string) error {
+	if strings.HasSuffix(path, TOMLSuffix) {
+		return c.loadFromTOMLFile(path)
+	}
+	return c.loadFromFile(path)
+}
+
 // loadFromFile reads and parses a config file
+// Unknown keys are reported as warnings with their file and line, or as an
+// error when gitignore.strict-config is enabled; recognized keys are loaded
+// either way
 func (c *Config) loadFromFile(path string) error {
 	file, err := os.Open(path)
 	if err != nil {
@@ -96,6 +335,7 @@ func (c *Config) loadFromFile(path string) error {
 
 	scanner := bufio.NewScanner(file)
 	lineNum := 0
+	var unknown []string
 
 	for scanner.Scan() {
 		lineNum++
@@ -113,31 +353,241 @@ func (c *Config) loadFromFile(path string) error {
 		}
 
 		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Remove quotes if present
-		value = strings.Trim(value, `"'`)
-
-		switch key {
-		case "gitignore.template.url":
-			c.TemplateURL = value
-		case "enable.toptal.gitignore":
-			c.EnableToptal = parseBool(value)
-		case "gitignore.local-templates-path":
-			// Expand ~ to home directory
-			if strings.HasPrefix(value, "~/") {
-				home, err := os.UserHomeDir()
-				if err == nil {
-					value = filepath.Join(home, value[2:])
-				}
+		value := parseValue(parts[1])
+
+		if !c.set(key, value) {
+			unknown = append(unknown, fmt.Sprintf("line %d: unknown config key '%s'", lineNum, key))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if len(unknown) > 0 && c.StrictConfig {
+		return fmt.Errorf("%s: %s", path, strings.Join(unknown, "; "))
+	}
+	for _, msg := range unknown {
+		logging.Warnf("%s: %s", path, msg)
+	}
+	return nil
+}
+
+// set applies a single config key, reporting whether the key is recognized
+func (c *Config) set(key, value string) bool {
+	switch key {
+	case "gitignore.template.url":
+		c.TemplateURL = value
+		c.templateURLSet = true
+	case "gitignore.template.ref":
+		c.TemplateRef = value
+	case "gitignore.template.path":
+		c.TemplatePath = value
+	case "gitignore.template.url-from-remote":
+		c.TemplateURLRemote = parseBool(value)
+	case "enable.toptal.gitignore":
+		c.EnableToptal = parseBool(value)
+	case "gitignore.local-templates-path":
+		c.LocalTemplatesPath = expandHome(value)
+	case "gitignore.default-types":
+		c.DefaultTypes = parseTypesList(value)
+	case "gitignore.add-header":
+		c.AddHeader = parseBool(value)
+	case "gitignore.offline":
+		c.Offline = parseBool(value)
+	case "gitignore.source-priority":
+		c.SourcePriority = parseTypesList(value)
+	case "gitignore.strict-config":
+		c.StrictConfig = parseBool(value)
+	case "gitignore.github.content-api":
+		c.GitHubContentAPI = parseBool(value)
+	case "gitignore.backup":
+		c.Backup = parseBool(value)
+	case "gitignore.dedupe-on-add":
+		c.DedupeOnAdd = parseBool(value)
+	case "gitignore.follow-symlinks":
+		c.FollowSymlinks = parseBool(value)
+	case "gitignore.user-agent":
+		c.UserAgent = value
+	case "gitignore.http-proxy":
+		c.HTTPProxy = value
+	case "gitignore.cache-dir":
+		c.CacheDir = expandHome(value)
+	case "gitignore.blocklist":
+		c.Blocklist = parseTypesList(value)
+	case "gitignore.section.start-prefix":
+		c.SectionStartPrefix = value
+	case "gitignore.section.end-prefix":
+		c.SectionEndPrefix = value
+	default:
+		switch {
+		case strings.HasPrefix(key, presetKeyPrefix):
+			c.setPreset(strings.TrimPrefix(key, presetKeyPrefix), value)
+		case strings.HasPrefix(key, aliasKeyPrefix) && len(key) > len(aliasKeyPrefix):
+			if c.Aliases == nil {
+				c.Aliases = map[string]string{}
+			}
+			c.Aliases[strings.ToLower(strings.TrimPrefix(key, aliasKeyPrefix))] = value
+		case strings.HasPrefix(key, includesKeyPrefix) && len(key) > len(includesKeyPrefix):
+			if c.Includes == nil {
+				c.Includes = map[string][]string{}
+			}
+			c.Includes[strings.ToLower(strings.TrimPrefix(key, includesKeyPrefix))] = parseTypesList(value)
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// setPreset applies a gitignore.preset.* key, with the prefix already removed
+func (c *Config) setPreset(name, value string) {
+	field := ""
+	if strings.HasSuffix(name, presetDescriptionSuffix) {
+		name = strings.TrimSuffix(name, presetDescriptionSuffix)
+		field = "description"
+	}
+	if name == "" {
+		return
+	}
+
+	if c.Presets == nil {
+		c.Presets = map[string]*Preset{}
+	}
+	preset, ok := c.Presets[name]
+	if !ok {
+		preset = &Preset{}
+		c.Presets[name] = preset
+	}
+
+	if field == "description" {
+		preset.Description = value
+	} else {
+		preset.Members = parseTypesList(value)
+	}
+}
+
+// TemplateURLSet reports whether gitignore.template.url was set in a config
+// file or the environment, rather than left at DefaultTemplateURL
+func (c *Config) TemplateURLSet() bool {
+	return c.templateURLSet
+}
+
+// PresetNames returns the configured preset names in sorted order
+func (c *Config) PresetNames() []string {
+	names := make([]string, 0, len(c.Presets))
+	for name := range c.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExpandPreset returns the template types in a preset, recursively replacing
+// members that name other presets with their own members
+// Duplicates are dropped, keeping the first occurrence
+func (c *Config) ExpandPreset(name string) ([]string, error) {
+	var types []string
+	seen := make(map[string]bool)
+	if err := c.expandPreset(name, nil, seen, &types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+func (c *Config) expandPreset(name string, stack []string, seen map[string]bool, types *[]string) error {
+	preset, ok := c.Presets[name]
+	if !ok {
+		return fmt.Errorf("preset '%s' not found", name)
+	}
+	for _, parent := range stack {
+		if parent == name {
+			return fmt.Errorf("preset '%s' includes itself (%s -> %s)", name, strings.Join(stack, " -> "), name)
+		}
+	}
+
+	stack = append(stack, name)
+	for _, member := range preset.Members {
+		if _, isPreset := c.Presets[member]; isPreset {
+			if err := c.expandPreset(member, stack, seen, types); err != nil {
+				return err
 			}
-			c.LocalTemplatesPath = value
-		case "gitignore.default-types":
-			c.DefaultTypes = parseTypesList(value)
+			continue
+		}
+		if !seen[strings.ToLower(member)] {
+			seen[strings.ToLower(member)] = true
+			*types = append(*types, member)
+		}
+	}
+	return nil
+}
+
+// ExpandIncludes returns the templates that adding name pulls in through
+// gitignore.includes, bases first: each include is preceded by its own
+// includes, and name itself is not in the result
+// Names ignore case and duplicates are dropped, keeping the first
+// occurrence; an include graph that leads back to a template is an error
+func (c *Config) ExpandIncludes(name string) ([]string, error) {
+	var types []string
+	seen := map[string]bool{strings.ToLower(name): true}
+	if err := c.expandIncludes(name, nil, seen, &types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+func (c *Config) expandIncludes(name string, stack []string, seen map[string]bool, types *[]string) error {
+	key := strings.ToLower(name)
+	for _, parent := range stack {
+		if strings.ToLower(parent) == key {
+			return fmt.Errorf("template '%s' includes itself (%s -> %s)", name, strings.Join(stack, " -> "), name)
 		}
 	}
 
-	return scanner.Err()
+	stack = append(stack, name)
+	for _, include := range c.Includes[key] {
+		if err := c.expandIncludes(include, stack, seen, types); err != nil {
+			return err
+		}
+		if !seen[strings.ToLower(include)] {
+			seen[strings.ToLower(include)] = true
+			*types = append(*types, include)
+		}
+	}
+	return nil
+}
+
+// parseValue returns a config value without its surrounding quotes or a
+// trailing inline comment
+// A comment starts with # or ; at the start of the value or after
+// whitespace, so "https://example.com/#frag" keeps its fragment; inside a
+// quoted value both characters are always literal
+func parseValue(raw string) string {
+	value := strings.TrimSpace(raw)
+	if value != "" && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+		return strings.Trim(value, `"'`) // unterminated quote
+	}
+
+	for i := 0; i < len(value); i++ {
+		if (value[i] == '#' || value[i] == ';') && (i == 0 || value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
+		}
+	}
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
+
+// expandHome expands a leading ~/ to the home directory
+func expandHome(value string) string {
+	if strings.HasPrefix(value, "~/") {
+		home, err := os.UserHomeDir()
+		if err == nil {
+			value = filepath.Join(home, value[2:])
+		}
+	}
+	return value
 }
 
 // parseBool parses a boolean value from string
@@ -158,15 +608,25 @@ func parseTypesList(value string) []string {
 	return types
 }
 
-// GetConfigPaths returns the list of config file paths that would be checked
+// GetConfigPaths returns the list of config file paths that would be checked,
+// in the order they are loaded
 func GetConfigPaths() ([]string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
 	}
+	return configPaths(home), nil
+}
 
-	return []string{
+// configPaths lists the config files under home in load order; each plain
+// file is followed by its TOML variant, which overrides it
+func configPaths(home string) []string {
+	var paths []string
+	for _, path := range []string{
 		filepath.Join(home, ".config", "gitignore", ConfigFileName),
 		filepath.Join(home, "."+ConfigFileName),
-	}, nil
+	} {
+		paths = append(paths, path, path+TOMLSuffix)
+	}
+	return paths
 }
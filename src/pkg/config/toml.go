@@ -0,0 +1,121 @@
+// Package config handles configuration file parsing and management
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/polliard/gitignore/src/pkg/logging"
+)
+
+// TOMLSuffix is appended to a config file path to name its TOML variant,
+// e.g. ~/.config/gitignore/gitignorerc.toml
+const TOMLSuffix = ".toml"
+
+// loadFromTOMLFile reads a TOML config file
+// Tables and dotted keys are joined into the flat keys of the plain format,
+// so [gitignore.template] url = "..." sets gitignore.template.url, and arrays
+// become comma-separated lists; [preset.<name>] (members, description),
+// [preset], [alias] and [includes] tables define presets, aliases and
+// includes
+// Values must be strings, booleans, integers or arrays of them
+func (c *Config) loadFromTOMLFile(path string) error {
+	var data map[string]any
+	md, err := toml.DecodeFile(path, &data)
+	if err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			return fmt.Errorf("%s:%d: %s", path, perr.Position.Line, perr.Message)
+		}
+		return err
+	}
+
+	var unknown []string
+	for _, key := range md.Keys() {
+		switch md.Type(key...) {
+		case "Hash":
+			continue // a table; its keys follow
+		case "ArrayHash":
+			return fmt.Errorf("%s: %s: arrays of tables are not supported", path, key)
+		}
+
+		value, err := tomlValue(lookupTOMLKey(data, key))
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+		flat := strings.Join(key, ".")
+		if !c.set(tomlConfigKey(flat), value) {
+			unknown = append(unknown, fmt.Sprintf("unknown config key '%s'", flat))
+		}
+	}
+
+	if len(unknown) > 0 && c.StrictConfig {
+		return fmt.Errorf("%s: %s", path, strings.Join(unknown, "; "))
+	}
+	for _, msg := range unknown {
+		logging.Warnf("%s: %s", path, msg)
+	}
+	return nil
+}
+
+// lookupTOMLKey returns the decoded value of a key returned by
+// MetaData.Keys
+func lookupTOMLKey(data map[string]any, key toml.Key) any {
+	var value any = data
+	for _, part := range key {
+		table, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = table[part]
+	}
+	return value
+}
+
+// tomlConfigKey maps a flattened TOML key to the plain format's key:
+// preset.<name>[.members], alias.<name> and includes.<name> (with or
+// without a leading "gitignore.") become gitignore.preset.<name>,
+// gitignore.alias.<name> and gitignore.includes.<name>
+func tomlConfigKey(key string) string {
+	trimmed := strings.TrimPrefix(key, "gitignore.")
+	switch {
+	case strings.HasPrefix(trimmed, "preset."):
+		return presetKeyPrefix + strings.TrimSuffix(strings.TrimPrefix(trimmed, "preset."), ".members")
+	case strings.HasPrefix(trimmed, "alias."):
+		return aliasKeyPrefix + strings.TrimPrefix(trimmed, "alias.")
+	case strings.HasPrefix(trimmed, "includes."):
+		return includesKeyPrefix + strings.TrimPrefix(trimmed, "includes.")
+	}
+	return key
+}
+
+// tomlValue converts a decoded value into the plain format's text: strings
+// as they are, booleans and integers formatted, and arrays joined with ", "
+// (so default-types = ["go", "node"] reads like go, node)
+func tomlValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if _, ok := item.([]any); ok {
+				return "", fmt.Errorf("nested arrays are not supported")
+			}
+			s, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ", "), nil
+	}
+	return "", fmt.Errorf("unsupported value %v (use a string, boolean, integer or array)", value)
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromTOML(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ConfigFileName+TOMLSuffix)
+	content := `# gitignore configuration
+enable.toptal.gitignore = true
+
+[gitignore]
+offline = false # trailing comment
+default-types = ["github/go", "global/macos"]
+source-priority = [
+  "local",
+  "toptal", # prefer Toptal
+  "github",
+]
+
+[gitignore.template]
+url = "https://github.com/acme/templates#main"
+ref = 'v1.2'
+
+[preset]
+minimal = ["go"]
+
+[preset.webapp]
+members = ["node", "github/global/macos"]
+description = "standard \"Node\" web app"
+
+[alias]
+vscode = "github/global/visualstudiocode"
+"c++" = "github/c++"
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create test config: %v", err)
+	}
+
+	cfg, err := LoadFromPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromPath() error: %v", err)
+	}
+
+	if !cfg.EnableToptal || cfg.Offline {
+		t.Errorf("expected Toptal enabled and offline off, got %v/%v", cfg.EnableToptal, cfg.Offline)
+	}
+	if cfg.TemplateURL != "https://github.com/acme/templates#main" || cfg.TemplateRef != "v1.2" {
+		t.Errorf("unexpected template URL/ref %q/%q", cfg.TemplateURL, cfg.TemplateRef)
+	}
+	if fmt.Sprint(cfg.DefaultTypes) != "[github/go global/macos]" {
+		t.Errorf("unexpected DefaultTypes %v", cfg.DefaultTypes)
+	}
+	if fmt.Sprint(cfg.SourcePriority) != "[local toptal github]" {
+		t.Errorf("unexpected SourcePriority %v", cfg.SourcePriority)
+	}
+
+	webapp := cfg.Presets["webapp"]
+	if webapp == nil || fmt.Sprint(webapp.Members) != "[node github/global/macos]" || webapp.Description != `standard "Node" web app` {
+		t.Errorf("unexpected webapp preset %+v", webapp)
+	}
+	if minimal := cfg.Presets["minimal"]; minimal == nil || fmt.Sprint(minimal.Members) != "[go]" {
+		t.Errorf("unexpected minimal preset %+v", minimal)
+	}
+	if cfg.Aliases["vscode"] != "github/global/visualstudiocode" || cfg.Aliases["c++"] != "github/c++" {
+		t.Errorf("unexpected aliases %v", cfg.Aliases)
+	}
+}
+
+func TestLoadFromTOMLErrors(t *testing.T) {
+	tests := map[string]string{
+		"unquoted string": "[gitignore]\nuser-agent = gitignore/1.0\n",
+		"missing equals":  "[gitignore]\noffline\n",
+		"bad table":       "[gitignore\n",
+		"unterminated":    "[gitignore]\ndefault-types = [\"go\",\n",
+		"bad key":         "[gitignore]\nuser agent = \"x\"\n",
+		"nested array":    "[gitignore]\ndefault-types = [[\"go\"]]\n",
+		"float":           "[gitignore]\noffline = 1.5\n",
+		"array of tables": "[[gitignore]]\noffline = true\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), ConfigFileName+TOMLSuffix)
+			if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+				t.Fatalf("failed to create test config: %v", err)
+			}
+			if _, err := LoadFromPath(configPath); err == nil || !strings.Contains(err.Error(), configPath+":") {
+				t.Errorf("LoadFromPath() error = %v, want an error naming the file", err)
+			}
+		})
+	}
+}
+
+func TestLoadTOMLUnknownKeysStrict(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ConfigFileName+TOMLSuffix)
+	content := "[gitignore]\nstrict-config = true\ntypo = \"x\"\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create test config: %v", err)
+	}
+	_, err := LoadFromPath(configPath)
+	if err == nil || !strings.Contains(err.Error(), "gitignore.typo") || !strings.HasPrefix(err.Error(), configPath+": ") {
+		t.Errorf("LoadFromPath() error = %v, want unknown key gitignore.typo in %s", err, configPath)
+	}
+}
+
+func TestLoadTOMLInlineTable(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ConfigFileName+TOMLSuffix)
+	content := "gitignore = { offline = true, template = { ref = \"v2\" } }\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create test config: %v", err)
+	}
+	cfg, err := LoadFromPath(configPath)
+	if err != nil {
+		t.Fatalf("LoadFromPath() error: %v", err)
+	}
+	if !cfg.Offline || cfg.TemplateRef != "v2" {
+		t.Errorf("expected inline table keys to apply, got offline %v, ref %q", cfg.Offline, cfg.TemplateRef)
+	}
+}
+
+func TestLoadTOMLPrecedence(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	plain := filepath.Join(home, "."+ConfigFileName)
+	files := map[string]string{
+		plain:              "gitignore.template.ref = plain\ngitignore.add-header = true\n",
+		plain + TOMLSuffix: "[gitignore.template]\nref = \"toml\"\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test config: %v", err)
+		}
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.TemplateRef != "toml" {
+		t.Errorf("expected the TOML file to override the plain one, got ref %q", cfg.TemplateRef)
+	}
+	if !cfg.AddHeader {
+		t.Error("expected keys only in the plain file to still apply")
+	}
+
+	paths, err := GetConfigPaths()
+	if err != nil {
+		t.Fatalf("GetConfigPaths() error: %v", err)
+	}
+	if len(paths) != 4 || paths[3] != plain+TOMLSuffix {
+		t.Errorf("GetConfigPaths() = %v, want each file followed by its TOML variant", paths)
+	}
+}
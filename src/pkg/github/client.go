@@ -3,23 +3,64 @@
 package github
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/polliard/gitignore/src/pkg/logging"
+)
+
+const (
+	// DefaultAPIBaseURL is the GitHub REST API endpoint
+	DefaultAPIBaseURL = "https://api.github.com"
+
+	// DefaultRawBaseURL serves raw file content from GitHub repositories
+	DefaultRawBaseURL = "https://raw.githubusercontent.com"
+
+	// DefaultUserAgent is sent with requests unless SetUserAgent changes it
+	// It has no version, as the library can't know the caller's; the gitignore
+	// command always sets gitignore/<version>, and other programs should set
+	// their own name and version
+	DefaultUserAgent = "gitignore"
+
+	// DefaultListTTL is how long a fetched template listing is reused
+	DefaultListTTL = 5 * time.Minute
 )
 
 // Client is a GitHub API client for fetching gitignore templates
 type Client struct {
-	httpClient *http.Client
-	repoURL    string
-	owner      string
-	repo       string
-	branch     string
+	httpClient       *http.Client
+	repoURL          string
+	owner            string
+	repo             string
+	mu               sync.Mutex // guards branch, which the tree listing may change
+	branch           string
+	apiBaseURL       string
+	rawBaseURL       string
+	userAgent        string
+	preferContentAPI bool   // fetch content via the Contents API before raw URLs
+	root             string // subdirectory holding the templates ("" = repository root)
+	pinned           bool   // branch is a ref chosen by the user, not the default branch
+	ctx              context.Context
+	pending          Pending
+
+	// The listing is memoized so that each Find and Get in one process
+	// doesn't fetch the whole tree again
+	listMu   sync.Mutex
+	listTTL  time.Duration
+	listed   []GitignoreFile
+	listedAt time.Time
 }
 
 // GitignoreFile represents a gitignore template file
@@ -31,9 +72,49 @@ type GitignoreFile struct {
 
 // TreeResponse represents the GitHub API tree response
 type TreeResponse struct {
-	SHA  string     `json:"sha"`
-	URL  string     `json:"url"`
-	Tree []TreeItem `json:"tree"`
+	SHA       string     `json:"sha"`
+	URL       string     `json:"url"`
+	Tree      []TreeItem `json:"tree"`
+	Truncated bool       `json:"truncated"` // the recursive listing hit GitHub's size limit
+}
+
+// ContentResponse represents the GitHub Contents API response for a file
+type ContentResponse struct {
+	Path     string `json:"path"`
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// RepoResponse represents the GitHub API response for a repository
+type RepoResponse struct {
+	DefaultBranch string `json:"default_branch"`
+}
+
+// errNotFound is wrapped by content errors for a 404, which is what a stale
+// branch produces
+var errNotFound = errors.New("status 404")
+
+// CheckTemplateContent rejects a response that can't be a gitignore template:
+// a Content-Type other than text (or text/html), or a body that starts like
+// an HTML page, as captive portals and proxy error pages do with status 200
+// An empty contentType skips the header check
+func CheckTemplateContent(contentType string, body []byte) error {
+	errHTML := errors.New("received an HTML page instead of a gitignore template (a proxy or captive portal error page?)")
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if mediaType == "text/html" {
+			return errHTML
+		}
+		if err != nil || !strings.HasPrefix(mediaType, "text/") {
+			return fmt.Errorf("unexpected content type '%s', not a gitignore template", contentType)
+		}
+	}
+
+	start := strings.ToLower(strings.TrimLeft(string(body[:min(len(body), 512)]), "\ufeff \t\r\n"))
+	if strings.HasPrefix(start, "<!doctype") || strings.HasPrefix(start, "<html") {
+		return errHTML
+	}
+	return nil
 }
 
 // TreeItem represents an item in the GitHub tree
@@ -47,18 +128,184 @@ type TreeItem struct {
 }
 
 // NewClient creates a new GitHub client from a repository URL
+// A URL of the form https://github.com/owner/repo/tree/<ref> pins the client
+// to that branch, tag or commit (see SetRef)
 func NewClient(repoURL string) (*Client, error) {
 	owner, repo, err := parseRepoURL(repoURL)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+	client := &Client{
+		httpClient: NewHTTPClient(nil),
 		repoURL:    repoURL,
 		owner:      owner,
 		repo:       repo,
 		branch:     "main",
-	}, nil
+		apiBaseURL: DefaultAPIBaseURL,
+		rawBaseURL: DefaultRawBaseURL,
+		userAgent:  DefaultUserAgent,
+		listTTL:    DefaultListTTL,
+		ctx:        context.Background(),
+	}
+	client.SetRef(parseRepoRef(repoURL))
+	return client, nil
+}
+
+// SetRef pins the client to a branch, tag or commit SHA, used for both the
+// tree listing and content URLs instead of the default branch
+// An empty ref leaves the client unchanged
+func (c *Client) SetRef(ref string) {
+	if ref == "" {
+		return
+	}
+	c.mu.Lock()
+	c.branch = ref
+	c.pinned = true
+	c.mu.Unlock()
+	c.Refresh()
+}
+
+// Ref returns the pinned ref, or "" when the default branch is used
+func (c *Client) Ref() string {
+	if !c.pinned {
+		return ""
+	}
+	return c.currentBranch()
+}
+
+// currentBranch returns the branch or ref used for requests
+func (c *Client) currentBranch() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.branch
+}
+
+// SetUserAgent sets the User-Agent header sent with every request, for
+// proxies that reject Go's default; an empty value leaves it unchanged
+func (c *Client) SetUserAgent(userAgent string) {
+	if userAgent != "" {
+		c.userAgent = userAgent
+	}
+}
+
+// SetProxy routes requests through proxy; nil restores the proxy settings
+// from the environment (HTTPS_PROXY, NO_PROXY and friends)
+func (c *Client) SetProxy(proxy *url.URL) {
+	c.httpClient = NewHTTPClient(proxy)
+}
+
+// NewHTTPClient returns an HTTP client with the standard timeout and request
+// logging that connects through proxy, or through the environment's proxy
+// settings when proxy is nil
+func NewHTTPClient(proxy *url.URL) *http.Client {
+	if proxy == nil {
+		return &http.Client{Timeout: 30 * time.Second, Transport: logging.NewTransport(nil)}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	return &http.Client{Timeout: 30 * time.Second, Transport: logging.NewTransport(transport)}
+}
+
+// SetContext makes requests run under ctx, so canceling it aborts them; a
+// nil ctx restores context.Background
+func (c *Client) SetContext(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c.ctx = ctx
+}
+
+// Pending reports whether a request is in flight
+func (c *Client) Pending() bool {
+	return c.pending.InFlight()
+}
+
+// get issues a GET request with the client's User-Agent and context
+func (c *Client) get(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	return c.pending.Do(c.httpClient, req)
+}
+
+// Pending counts the requests in flight through Do, from sending one until
+// its response body is closed, to tell what a canceled command was still
+// waiting on
+// A request cut short by canceling its context stays counted, since it
+// never finished
+type Pending struct {
+	n atomic.Int32
+}
+
+// Do sends req with client, counting it as in flight until the response
+// body is closed; a request whose context is already done isn't sent
+func (p *Pending) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	p.n.Add(1)
+	finished := func() {
+		if req.Context().Err() == nil {
+			p.n.Add(-1)
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		finished()
+		return nil, err
+	}
+	var once sync.Once
+	resp.Body = pendingBody{ReadCloser: resp.Body, done: func() { once.Do(finished) }}
+	return resp, nil
+}
+
+// InFlight reports whether a request sent through Do hasn't finished
+func (p *Pending) InFlight() bool {
+	return p.n.Load() > 0
+}
+
+// pendingBody is a response body that reports when it is closed
+type pendingBody struct {
+	io.ReadCloser
+	done func()
+}
+
+func (b pendingBody) Close() error {
+	defer b.done()
+	return b.ReadCloser.Close()
+}
+
+// SetPreferContentAPI makes GetGitignoreContent use the Contents API on
+// api.github.com first and raw.githubusercontent.com only as a fallback,
+// for networks that block the raw host
+func (c *Client) SetPreferContentAPI(prefer bool) {
+	c.preferContentAPI = prefer
+}
+
+// SetRoot limits templates to a subdirectory of the repository, such as
+// "templates"; categories and names are then computed relative to it
+func (c *Client) SetRoot(root string) {
+	c.root = strings.Trim(root, "/")
+	c.Refresh()
+}
+
+// SetListTTL sets how long the fetched listing is reused
+// A TTL of zero or less disables caching
+func (c *Client) SetListTTL(ttl time.Duration) {
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+	c.listTTL = ttl
+	c.listed, c.listedAt = nil, time.Time{}
+}
+
+// Refresh drops the cached listing, so the next lookup fetches the tree
+// again even if the list TTL has not expired
+func (c *Client) Refresh() {
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+	c.listed, c.listedAt = nil, time.Time{}
 }
 
 func parseRepoURL(repoURL string) (owner, repo string, err error) {
@@ -85,21 +332,61 @@ func parseRepoURL(repoURL string) (owner, repo string, err error) {
 	return "", "", fmt.Errorf("unsupported URL format: %s", repoURL)
 }
 
+// parseRepoRef returns the ref in a .../owner/repo/tree/<ref> URL, or ""
+func parseRepoRef(repoURL string) string {
+	_, rest, ok := strings.Cut(repoURL, "github.com/")
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+	if len(parts) < 4 || parts[2] != "tree" {
+		return ""
+	}
+	return strings.Join(parts[3:], "/")
+}
+
 // ListGitignoreFiles returns all gitignore files in the repository
+// The result is cached for the client's list TTL (see SetListTTL)
 func (c *Client) ListGitignoreFiles() ([]GitignoreFile, error) {
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees/%s?recursive=1",
-		url.PathEscape(c.owner), url.PathEscape(c.repo), url.PathEscape(c.branch))
-	resp, err := c.httpClient.Get(apiURL)
+	c.listMu.Lock()
+	defer c.listMu.Unlock()
+
+	if !c.listedAt.IsZero() && time.Since(c.listedAt) < c.listTTL {
+		logging.Debugf("github: using cached listing of %s/%s (%d entries)", c.owner, c.repo, len(c.listed))
+		return append([]GitignoreFile(nil), c.listed...), nil
+	}
+
+	files, err := c.fetchGitignoreFiles()
+	if err != nil {
+		return nil, err
+	}
+	if c.listTTL > 0 {
+		c.listed, c.listedAt = files, time.Now()
+	}
+	return append([]GitignoreFile(nil), files...), nil
+}
+
+// fetchGitignoreFiles fetches the repository tree and returns its templates
+func (c *Client) fetchGitignoreFiles() ([]GitignoreFile, error) {
+	branch := c.currentBranch()
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/git/trees/%s?recursive=1",
+		c.apiBaseURL, url.PathEscape(c.owner), url.PathEscape(c.repo), url.PathEscape(branch))
+	resp, err := c.get(apiURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch repository tree: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound && c.pinned {
+		return nil, fmt.Errorf("GitHub ref '%s' not found in %s/%s", branch, c.owner, c.repo)
+	}
 	if resp.StatusCode == http.StatusNotFound {
+		c.mu.Lock()
 		c.branch = "master"
-		apiURL = fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees/%s?recursive=1",
-			url.PathEscape(c.owner), url.PathEscape(c.repo), url.PathEscape(c.branch))
-		resp2, err := c.httpClient.Get(apiURL)
+		c.mu.Unlock()
+		apiURL = fmt.Sprintf("%s/repos/%s/%s/git/trees/%s?recursive=1",
+			c.apiBaseURL, url.PathEscape(c.owner), url.PathEscape(c.repo), "master")
+		resp2, err := c.get(apiURL)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch repository tree: %w", err)
 		}
@@ -117,19 +404,80 @@ func (c *Client) ListGitignoreFiles() ([]GitignoreFile, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	items := tree.Tree
+	if tree.Truncated {
+		logging.Verbosef("tree of %s/%s is too large for one listing; listing it directory by directory", c.owner, c.repo)
+		if items, err = c.walkTree(tree.SHA, ""); err != nil {
+			return nil, err
+		}
+	}
+
 	var files []GitignoreFile
 	gitignoreRegex := regexp.MustCompile(`(?i)\.gitignore$`)
-	for _, item := range tree.Tree {
+	for _, item := range items {
 		if item.Type != "blob" || !gitignoreRegex.MatchString(item.Path) {
 			continue
 		}
-		file := parseGitignorePath(item.Path)
+		rel := item.Path
+		if c.root != "" {
+			var ok bool
+			if rel, ok = strings.CutPrefix(item.Path, c.root+"/"); !ok {
+				continue
+			}
+		}
+		file := ParseGitignorePath(rel)
+		file.Path = item.Path // content is still fetched by its full path
 		files = append(files, file)
 	}
 	return files, nil
 }
 
-func parseGitignorePath(path string) GitignoreFile {
+// walkTree lists the tree with the given SHA one directory at a time, for
+// repositories whose recursive listing is truncated, returning its blobs with
+// paths relative to the repository root (prefix is the tree's own path)
+// Directories outside the template root are not fetched
+func (c *Client) walkTree(sha, prefix string) ([]TreeItem, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/git/trees/%s",
+		c.apiBaseURL, url.PathEscape(c.owner), url.PathEscape(c.repo), url.PathEscape(sha))
+	resp, err := c.get(apiURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repository tree: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var tree TreeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tree); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var items []TreeItem
+	for _, item := range tree.Tree {
+		if prefix != "" {
+			item.Path = prefix + "/" + item.Path
+		}
+		if item.Type != "tree" {
+			items = append(items, item)
+			continue
+		}
+		if c.root != "" && !strings.HasPrefix(c.root+"/", item.Path+"/") && !strings.HasPrefix(item.Path, c.root+"/") {
+			continue
+		}
+		sub, err := c.walkTree(item.SHA, item.Path)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, sub...)
+	}
+	return items, nil
+}
+
+// ParseGitignorePath describes a template from its path in a repository,
+// e.g. "Global/macOS.gitignore" becomes macOS in category Global
+func ParseGitignorePath(path string) GitignoreFile {
 	parts := strings.Split(path, "/")
 	filename := parts[len(parts)-1]
 	name := strings.TrimSuffix(filename, ".gitignore")
@@ -141,15 +489,91 @@ func parseGitignorePath(path string) GitignoreFile {
 }
 
 // GetGitignoreContent fetches the content of a specific gitignore file
+// It tries raw.githubusercontent.com and falls back to the Contents API if
+// that fails, or the other way round when SetPreferContentAPI is enabled
+// If both 404 on an unpinned client, the default branch is looked up again
+// and, if it changed, the fetch is retried on it
 func (c *Client) GetGitignoreContent(file GitignoreFile) (string, error) {
-	rawURL := fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s/%s",
-		url.PathEscape(c.owner), url.PathEscape(c.repo), url.PathEscape(c.branch), file.Path)
-	resp, err := c.httpClient.Get(rawURL)
+	branch := c.currentBranch()
+	content, err := c.getContent(file)
+	if err == nil || c.pinned || !errors.Is(err, errNotFound) {
+		return content, err
+	}
+
+	resolved, resolveErr := c.resolveDefaultBranch()
+	if resolveErr != nil || resolved == branch {
+		return "", err
+	}
+	logging.Verbosef("%v; retrying on default branch '%s'", err, resolved)
+	return c.getContent(file)
+}
+
+// getContent fetches a file from the raw host and then the Contents API, or
+// the other way round when SetPreferContentAPI is enabled
+// The error wraps errNotFound only when both endpoints returned 404
+func (c *Client) getContent(file GitignoreFile) (string, error) {
+	first, second := c.getRawContent, c.getAPIContent
+	if c.preferContentAPI {
+		first, second = second, first
+	}
+
+	content, err := first(file)
+	if err == nil {
+		return content, nil
+	}
+	logging.Verbosef("%v; retrying", err)
+
+	content, err2 := second(file)
+	if err2 != nil {
+		if errors.Is(err, errNotFound) && errors.Is(err2, errNotFound) {
+			return "", fmt.Errorf("%w; %w", err, err2)
+		}
+		return "", fmt.Errorf("%w; %v", err, err2)
+	}
+	return content, nil
+}
+
+// resolveDefaultBranch asks the API for the repository's default branch and
+// uses it for later requests
+func (c *Client) resolveDefaultBranch() (string, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s", c.apiBaseURL, url.PathEscape(c.owner), url.PathEscape(c.repo))
+	resp, err := c.get(apiURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch repository: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub API error (status %d)", resp.StatusCode)
+	}
+
+	var repo RepoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if repo.DefaultBranch == "" {
+		return "", fmt.Errorf("GitHub API returned no default branch for %s/%s", c.owner, c.repo)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.branch = repo.DefaultBranch
+	return repo.DefaultBranch, nil
+}
+
+// getRawContent fetches a file from raw.githubusercontent.com
+func (c *Client) getRawContent(file GitignoreFile) (string, error) {
+	rawURL := fmt.Sprintf("%s/%s/%s/%s/%s",
+		c.rawBaseURL, url.PathEscape(c.owner), url.PathEscape(c.repo), url.PathEscape(c.currentBranch()), file.Path)
+	resp, err := c.get(rawURL)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch gitignore content: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("failed to fetch gitignore content (%w)", errNotFound)
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", fmt.Errorf("failed to fetch gitignore content (status %d)", resp.StatusCode)
 	}
@@ -158,6 +582,46 @@ func (c *Client) GetGitignoreContent(file GitignoreFile) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to read gitignore content: %w", err)
 	}
+	if err := CheckTemplateContent(resp.Header.Get("Content-Type"), content); err != nil {
+		return "", fmt.Errorf("failed to fetch gitignore content: %w", err)
+	}
+	return string(content), nil
+}
+
+// getAPIContent fetches a file through the Contents API
+// (/repos/{owner}/{repo}/contents/{path}), which returns it base64-encoded
+func (c *Client) getAPIContent(file GitignoreFile) (string, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s",
+		c.apiBaseURL, url.PathEscape(c.owner), url.PathEscape(c.repo), file.Path, url.QueryEscape(c.currentBranch()))
+	resp, err := c.get(apiURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch gitignore content via Contents API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("failed to fetch gitignore content via Contents API (%w)", errNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch gitignore content via Contents API (status %d)", resp.StatusCode)
+	}
+
+	var body ContentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode Contents API response: %w", err)
+	}
+	if body.Encoding != "base64" {
+		return "", fmt.Errorf("unsupported Contents API encoding '%s'", body.Encoding)
+	}
+
+	// GitHub wraps the base64 content at 60 columns
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body.Content, "\n", ""))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode gitignore content: %w", err)
+	}
+	if err := CheckTemplateContent("", content); err != nil {
+		return "", fmt.Errorf("failed to fetch gitignore content via Contents API: %w", err)
+	}
 	return string(content), nil
 }
 
@@ -186,6 +650,75 @@ func (c *Client) FindGitignoreFile(name string) (*GitignoreFile, error) {
 	return nil, fmt.Errorf("gitignore template '%s' not found", name)
 }
 
+// CommitResponse represents one entry of the GitHub API commit list
+type CommitResponse struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+// LastCommitDate returns when file was last changed, from the newest commit
+// touching its path on the pinned ref or the default branch
+// Each call is one API request, which counts against GitHub's rate limit
+func (c *Client) LastCommitDate(file GitignoreFile) (time.Time, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/commits?path=%s&per_page=1",
+		c.apiBaseURL, url.PathEscape(c.owner), url.PathEscape(c.repo), url.QueryEscape(file.Path))
+	if c.pinned {
+		apiURL += "&sha=" + url.QueryEscape(c.currentBranch())
+	}
+	resp, err := c.get(apiURL)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to fetch commits for %s: %w", file.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, fmt.Errorf("failed to fetch commits for %s (status %d)", file.Path, resp.StatusCode)
+	}
+
+	var commits []CommitResponse
+	if err := json.NewDecoder(resp.Body).Decode(&commits); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode commits for %s: %w", file.Path, err)
+	}
+	if len(commits) == 0 {
+		return time.Time{}, fmt.Errorf("no commits found for %s", file.Path)
+	}
+	return commits[0].Commit.Committer.Date, nil
+}
+
+// Exists reports whether the repository exists, i.e. the API knows it
+// It is false for private repositories the client can't see
+func (c *Client) Exists() (bool, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s", c.apiBaseURL, url.PathEscape(c.owner), url.PathEscape(c.repo))
+	resp, err := c.get(apiURL)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch repository: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("GitHub API error (status %d)", resp.StatusCode)
+}
+
+// OwnerTemplateURL returns the URL of the "gitignore" repository belonging
+// to the owner of a GitHub repository URL, in any form parseRepoURL accepts,
+// e.g. git@github.com:acme/api.git -> https://github.com/acme/gitignore
+func OwnerTemplateURL(repoURL string) (string, error) {
+	owner, _, err := parseRepoURL(repoURL)
+	if err != nil {
+		return "", err
+	}
+	return "https://github.com/" + owner + "/gitignore", nil
+}
+
 // Owner returns the repository owner
 func (c *Client) Owner() string {
 	return c.owner
@@ -1,8 +1,14 @@
 package github
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseRepoURL(t *testing.T) {
@@ -92,6 +98,565 @@ func TestNewClientInvalidURL(t *testing.T) {
 	}
 }
 
+// newTestServer serves the Contents API for Go.gitignore and, unless
+// rawBlocked, the raw file; it records the paths requested
+func newTestServer(t *testing.T, rawBlocked bool, requests *[]string) *httptest.Server {
+	t.Helper()
+	content := "*.exe\n*.test\n"
+	encoded := base64.StdEncoding.EncodeToString([]byte(content))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/raw/owner/repo/main/Go.gitignore":
+			if rawBlocked {
+				http.Error(w, "blocked", http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(content))
+		case "/repos/owner/repo/contents/Go.gitignore":
+			if r.URL.Query().Get("ref") != "main" {
+				t.Errorf("ref = %q, want main", r.URL.Query().Get("ref"))
+			}
+			// GitHub wraps base64 content across lines
+			json.NewEncoder(w).Encode(ContentResponse{
+				Path:     "Go.gitignore",
+				Encoding: "base64",
+				Content:  encoded[:8] + "\n" + encoded[8:] + "\n",
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestClient(t *testing.T, server *httptest.Server) *Client {
+	t.Helper()
+	client, err := NewClient("https://github.com/owner/repo")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	client.apiBaseURL = server.URL
+	client.rawBaseURL = server.URL + "/raw"
+	return client
+}
+
+func TestGetGitignoreContentFallsBackToContentAPI(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, newTestServer(t, true, &requests))
+
+	content, err := client.GetGitignoreContent(GitignoreFile{Name: "Go", Path: "Go.gitignore"})
+	if err != nil {
+		t.Fatalf("GetGitignoreContent() error = %v", err)
+	}
+	if content != "*.exe\n*.test\n" {
+		t.Errorf("GetGitignoreContent() = %q", content)
+	}
+	if len(requests) != 2 || !strings.HasPrefix(requests[0], "/raw/") {
+		t.Errorf("requests = %v, want raw then Contents API", requests)
+	}
+}
+
+func TestGetGitignoreContentPreferContentAPI(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, newTestServer(t, false, &requests))
+	client.SetPreferContentAPI(true)
+
+	content, err := client.GetGitignoreContent(GitignoreFile{Name: "Go", Path: "Go.gitignore"})
+	if err != nil {
+		t.Fatalf("GetGitignoreContent() error = %v", err)
+	}
+	if content != "*.exe\n*.test\n" {
+		t.Errorf("GetGitignoreContent() = %q", content)
+	}
+	if len(requests) != 1 || requests[0] != "/repos/owner/repo/contents/Go.gitignore" {
+		t.Errorf("requests = %v, want only the Contents API", requests)
+	}
+}
+
+func TestGetGitignoreContentBothFail(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, newTestServer(t, true, &requests))
+
+	_, err := client.GetGitignoreContent(GitignoreFile{Name: "Missing", Path: "Missing.gitignore"})
+	if err == nil {
+		t.Fatal("expected error when both raw and Contents API fail")
+	}
+	if !strings.Contains(err.Error(), "status 404") || !strings.Contains(err.Error(), "Contents API") {
+		t.Errorf("error should describe both failures, got: %v", err)
+	}
+}
+
+// newMasterOnlyServer serves a repository whose only branch is master
+func newMasterOnlyServer(t *testing.T, requests *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/repos/owner/repo":
+			json.NewEncoder(w).Encode(RepoResponse{DefaultBranch: "master"})
+		case "/repos/owner/repo/git/trees/master":
+			json.NewEncoder(w).Encode(TreeResponse{Tree: []TreeItem{{Path: "Go.gitignore", Type: "blob"}}})
+		case "/raw/owner/repo/master/Go.gitignore":
+			w.Write([]byte("*.exe\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMasterBranchPersistsFromList(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, newMasterOnlyServer(t, &requests))
+
+	file, err := client.FindGitignoreFile("go")
+	if err != nil {
+		t.Fatalf("FindGitignoreFile() error = %v", err)
+	}
+	requests = nil
+	content, err := client.GetGitignoreContent(*file)
+	if err != nil || content != "*.exe\n" {
+		t.Fatalf("GetGitignoreContent() = %q, %v", content, err)
+	}
+	if len(requests) != 1 || requests[0] != "/raw/owner/repo/master/Go.gitignore" {
+		t.Errorf("requests = %v, want one raw request on master", requests)
+	}
+}
+
+func TestGetGitignoreContentReresolvesStaleBranch(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, newMasterOnlyServer(t, &requests))
+
+	// Without a listing the client still assumes main
+	content, err := client.GetGitignoreContent(GitignoreFile{Name: "Go", Path: "Go.gitignore"})
+	if err != nil || content != "*.exe\n" {
+		t.Fatalf("GetGitignoreContent() = %q, %v", content, err)
+	}
+	want := []string{
+		"/raw/owner/repo/main/Go.gitignore",
+		"/repos/owner/repo/contents/Go.gitignore",
+		"/repos/owner/repo",
+		"/raw/owner/repo/master/Go.gitignore",
+	}
+	if strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+
+	// The resolved branch is kept for later fetches
+	requests = nil
+	if _, err := client.GetGitignoreContent(GitignoreFile{Name: "Go", Path: "Go.gitignore"}); err != nil {
+		t.Fatalf("second GetGitignoreContent() error = %v", err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("requests = %v, want a single raw request", requests)
+	}
+
+	// A file missing on the default branch too is still an error
+	if _, err := client.GetGitignoreContent(GitignoreFile{Name: "Rust", Path: "Rust.gitignore"}); err == nil {
+		t.Error("expected error for a missing template")
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var agents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.URL.Path+" "+r.UserAgent())
+		switch r.URL.Path {
+		case "/repos/owner/repo/git/trees/main":
+			json.NewEncoder(w).Encode(TreeResponse{Tree: []TreeItem{{Path: "Go.gitignore", Type: "blob"}}})
+		case "/repos/owner/repo/contents/Go.gitignore":
+			json.NewEncoder(w).Encode(ContentResponse{Encoding: "base64", Content: base64.StdEncoding.EncodeToString([]byte("*.exe\n"))})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	client.SetUserAgent("gitignore/1.2.3")
+	client.SetUserAgent("")
+
+	file, err := client.FindGitignoreFile("go")
+	if err != nil {
+		t.Fatalf("FindGitignoreFile() error = %v", err)
+	}
+	if _, err := client.GetGitignoreContent(*file); err != nil {
+		t.Fatalf("GetGitignoreContent() error = %v", err)
+	}
+
+	// Tree, raw (404) and Contents API requests all carry the header
+	want := []string{
+		"/repos/owner/repo/git/trees/main gitignore/1.2.3",
+		"/raw/owner/repo/main/Go.gitignore gitignore/1.2.3",
+		"/repos/owner/repo/contents/Go.gitignore gitignore/1.2.3",
+	}
+	if strings.Join(agents, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %v, want %v", agents, want)
+	}
+}
+
+func TestListGitignoreFilesTruncated(t *testing.T) {
+	var fetched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched = append(fetched, r.URL.Path)
+		switch r.URL.Path {
+		case "/repos/owner/repo/git/trees/main":
+			json.NewEncoder(w).Encode(TreeResponse{SHA: "root", Truncated: true, Tree: []TreeItem{
+				{Path: "Go.gitignore", Type: "blob"},
+			}})
+		case "/repos/owner/repo/git/trees/root":
+			if r.URL.Query().Get("recursive") != "" {
+				t.Errorf("expected a non-recursive listing, got %s", r.URL)
+			}
+			json.NewEncoder(w).Encode(TreeResponse{SHA: "root", Tree: []TreeItem{
+				{Path: "Go.gitignore", Type: "blob"},
+				{Path: "Global", Type: "tree", SHA: "global"},
+				{Path: "docs", Type: "tree", SHA: "docs"},
+				{Path: "README.md", Type: "blob"},
+			}})
+		case "/repos/owner/repo/git/trees/global":
+			json.NewEncoder(w).Encode(TreeResponse{SHA: "global", Tree: []TreeItem{
+				{Path: "macOS.gitignore", Type: "blob"},
+			}})
+		case "/repos/owner/repo/git/trees/docs":
+			json.NewEncoder(w).Encode(TreeResponse{SHA: "docs", Tree: []TreeItem{
+				{Path: "Example.gitignore", Type: "blob"},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	files, err := client.ListGitignoreFiles()
+	if err != nil {
+		t.Fatalf("ListGitignoreFiles() error = %v", err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, f.Path)
+	}
+	want := []string{"Go.gitignore", "Global/macOS.gitignore", "docs/Example.gitignore"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ListGitignoreFiles() = %v, want %v", got, want)
+	}
+
+	// With a root, directories outside it are not walked
+	fetched = nil
+	client = newTestClient(t, server)
+	client.SetRoot("Global")
+	files, err = client.ListGitignoreFiles()
+	if err != nil {
+		t.Fatalf("ListGitignoreFiles() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Path != "Global/macOS.gitignore" {
+		t.Errorf("ListGitignoreFiles() with root = %v, want only Global/macOS.gitignore", files)
+	}
+	for _, p := range fetched {
+		if strings.HasSuffix(p, "/trees/docs") {
+			t.Errorf("expected docs not to be fetched outside the root, got %v", fetched)
+		}
+	}
+}
+
+func TestListGitignoreFilesCached(t *testing.T) {
+	trees := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/git/trees/main" {
+			http.NotFound(w, r)
+			return
+		}
+		trees++
+		json.NewEncoder(w).Encode(TreeResponse{Tree: []TreeItem{{Path: "Global/macOS.gitignore", Type: "blob"}}})
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	if _, err := client.ListGitignoreFiles(); err != nil {
+		t.Fatalf("ListGitignoreFiles() error = %v", err)
+	}
+	file, err := client.FindGitignoreFile("global/macos")
+	if err != nil || file.Path != "Global/macOS.gitignore" {
+		t.Fatalf("FindGitignoreFile(global/macos) = %+v, %v", file, err)
+	}
+	if trees != 1 {
+		t.Errorf("tree fetched %d times, want the listing to be reused", trees)
+	}
+
+	client.Refresh()
+	if _, err := client.ListGitignoreFiles(); err != nil || trees != 2 {
+		t.Errorf("after Refresh, tree fetched %d times (err %v), want 2", trees, err)
+	}
+
+	client.SetListTTL(0)
+	client.ListGitignoreFiles()
+	client.ListGitignoreFiles()
+	if trees != 4 {
+		t.Errorf("with no TTL, tree fetched %d times, want 4", trees)
+	}
+}
+
+func TestSetRoot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/git/trees/main":
+			json.NewEncoder(w).Encode(TreeResponse{Tree: []TreeItem{
+				{Path: "Root.gitignore", Type: "blob"},
+				{Path: "templates/Go.gitignore", Type: "blob"},
+				{Path: "templates/Global/macOS.gitignore", Type: "blob"},
+				{Path: "templates-old/Go.gitignore", Type: "blob"},
+			}})
+		case "/raw/owner/repo/main/templates/Global/macOS.gitignore":
+			w.Write([]byte(".DS_Store\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	client.SetRoot("/templates/")
+
+	files, err := client.ListGitignoreFiles()
+	if err != nil {
+		t.Fatalf("ListGitignoreFiles() error = %v", err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, f.Category+"|"+f.Name+"|"+f.Path)
+	}
+	want := []string{"|Go|templates/Go.gitignore", "Global|macOS|templates/Global/macOS.gitignore"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ListGitignoreFiles() = %v, want %v", got, want)
+	}
+
+	file, err := client.FindGitignoreFile("global/macos")
+	if err != nil {
+		t.Fatalf("FindGitignoreFile() error = %v", err)
+	}
+	if content, err := client.GetGitignoreContent(*file); err != nil || content != ".DS_Store\n" {
+		t.Errorf("GetGitignoreContent() = %q, %v", content, err)
+	}
+}
+
+func TestCheckTemplateContent(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		ok          bool
+	}{
+		{"text/plain; charset=utf-8", "*.exe\n", true},
+		{"", "*.exe\n", true},
+		{"text/plain", "", true},
+		{"text/html; charset=utf-8", "*.exe\n", false},
+		{"application/octet-stream", "*.exe\n", false},
+		{"text/plain", "\n  <!DOCTYPE html><html></html>", false},
+		{"", "<HTML><body>blocked</body></HTML>", false},
+		{"", "# <html> in a comment is fine\n", true},
+	}
+	for _, tt := range tests {
+		if err := CheckTemplateContent(tt.contentType, []byte(tt.body)); (err == nil) != tt.ok {
+			t.Errorf("CheckTemplateContent(%q, %q) error = %v, want ok %v", tt.contentType, tt.body, err, tt.ok)
+		}
+	}
+}
+
+func TestGetGitignoreContentRejectsHTML(t *testing.T) {
+	// A captive portal answers the raw host, so the Contents API is tried
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/raw/owner/repo/main/Go.gitignore":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<!DOCTYPE html><title>Log in</title>"))
+		case "/repos/owner/repo/contents/Go.gitignore":
+			json.NewEncoder(w).Encode(ContentResponse{Encoding: "base64", Content: base64.StdEncoding.EncodeToString([]byte("*.exe\n"))})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	content, err := newTestClient(t, server).GetGitignoreContent(GitignoreFile{Name: "Go", Path: "Go.gitignore"})
+	if err != nil {
+		t.Fatalf("GetGitignoreContent() error = %v", err)
+	}
+	if content != "*.exe\n" {
+		t.Errorf("GetGitignoreContent() = %q, want the Contents API content", content)
+	}
+}
+
+func TestSetProxy(t *testing.T) {
+	// The server plays the proxy: requests for the unreachable host arrive
+	// with their absolute URL
+	var hosts []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.URL.Host)
+		json.NewEncoder(w).Encode(TreeResponse{Tree: []TreeItem{{Path: "Go.gitignore", Type: "blob"}}})
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient("https://github.com/owner/repo")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	client.apiBaseURL = "http://api.github.invalid"
+	proxyURL, _ := url.Parse(proxy.URL)
+	client.SetProxy(proxyURL)
+
+	if _, err := client.ListGitignoreFiles(); err != nil {
+		t.Fatalf("ListGitignoreFiles() error = %v", err)
+	}
+	if strings.Join(hosts, ",") != "api.github.invalid" {
+		t.Errorf("proxied hosts = %v, want [api.github.invalid]", hosts)
+	}
+}
+
+func TestParseRepoRef(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/github/gitignore":                    "",
+		"https://github.com/github/gitignore/tree/v1.0":          "v1.0",
+		"https://github.com/github/gitignore/tree/release/2024/": "release/2024",
+		"https://github.com/github/gitignore/blob/main":          "",
+		"git@github.com:owner/repo.git":                          "",
+	}
+	for url, want := range tests {
+		if got := parseRepoRef(url); got != want {
+			t.Errorf("parseRepoRef(%q) = %q, want %q", url, got, want)
+		}
+	}
+
+	client, err := NewClient("https://github.com/github/gitignore/tree/4b76b0e")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if client.Repo() != "gitignore" || client.Ref() != "4b76b0e" {
+		t.Errorf("client repo = %q, ref = %q", client.Repo(), client.Ref())
+	}
+}
+
+func TestPinnedRef(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/repos/owner/repo/git/trees/v1.0":
+			json.NewEncoder(w).Encode(TreeResponse{Tree: []TreeItem{{Path: "Go.gitignore", Type: "blob"}}})
+		case "/raw/owner/repo/v1.0/Go.gitignore":
+			w.Write([]byte("*.exe\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	if client.Ref() != "" {
+		t.Errorf("Ref() = %q before SetRef, want empty", client.Ref())
+	}
+	client.SetRef("v1.0")
+
+	file, err := client.FindGitignoreFile("go")
+	if err != nil {
+		t.Fatalf("FindGitignoreFile() error = %v", err)
+	}
+	if content, err := client.GetGitignoreContent(*file); err != nil || content != "*.exe\n" {
+		t.Errorf("GetGitignoreContent() = %q, %v", content, err)
+	}
+
+	// A missing pinned ref is an error, not a fallback to master
+	client.SetRef("v9.9")
+	requests = nil
+	if _, err := client.ListGitignoreFiles(); err == nil || !strings.Contains(err.Error(), "v9.9") {
+		t.Errorf("ListGitignoreFiles() error = %v, want missing ref error", err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("requests = %v, want a single tree request", requests)
+	}
+}
+
+func TestLastCommitDate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/repos/owner/repo/commits" || query.Get("per_page") != "1" {
+			http.NotFound(w, r)
+			return
+		}
+		if query.Get("path") == "Empty.gitignore" {
+			w.Write([]byte("[]"))
+			return
+		}
+		if query.Get("path") != "Global/Go.gitignore" || query.Get("sha") != "v1.0" {
+			t.Errorf("query = %v, want path Global/Go.gitignore on v1.0", query)
+		}
+		w.Write([]byte(`[{"sha":"abc","commit":{"committer":{"date":"2025-03-04T05:06:07Z"}}}]`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	client.SetRef("v1.0")
+	date, err := client.LastCommitDate(GitignoreFile{Name: "Go", Path: "Global/Go.gitignore", Category: "Global"})
+	if err != nil {
+		t.Fatalf("LastCommitDate() error = %v", err)
+	}
+	if want := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC); !date.Equal(want) {
+		t.Errorf("LastCommitDate() = %v, want %v", date, want)
+	}
+
+	if _, err := client.LastCommitDate(GitignoreFile{Name: "Empty", Path: "Empty.gitignore"}); err == nil {
+		t.Error("LastCommitDate() of a path without commits should fail")
+	}
+}
+
+func TestExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo":
+			w.Write([]byte(`{"default_branch":"main"}`))
+		case "/repos/owner/limited":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for repo, want := range map[string]bool{"repo": true, "missing": false} {
+		client, err := NewClient("https://github.com/owner/" + repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.apiBaseURL = server.URL
+		if got, err := client.Exists(); err != nil || got != want {
+			t.Errorf("Exists(%s) = %v, %v; want %v", repo, got, err, want)
+		}
+	}
+
+	client, _ := NewClient("https://github.com/owner/limited")
+	client.apiBaseURL = server.URL
+	if _, err := client.Exists(); err == nil {
+		t.Error("Exists() should fail on an unexpected status")
+	}
+}
+
+func TestOwnerTemplateURL(t *testing.T) {
+	for _, remote := range []string{
+		"https://github.com/acme/api.git",
+		"git@github.com:acme/api.git",
+		"ssh://git@github.com/acme/api",
+	} {
+		if got, err := OwnerTemplateURL(remote); err != nil || got != "https://github.com/acme/gitignore" {
+			t.Errorf("OwnerTemplateURL(%s) = %q, %v", remote, got, err)
+		}
+	}
+	if _, err := OwnerTemplateURL("https://gitlab.com/acme/api.git"); err == nil {
+		t.Error("OwnerTemplateURL() should reject non-GitHub remotes")
+	}
+}
+
 func TestParseGitignorePath(t *testing.T) {
 	tests := []struct {
 		path         string
@@ -117,7 +682,7 @@ func TestParseGitignorePath(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.path, func(t *testing.T) {
-			file := parseGitignorePath(tt.path)
+			file := ParseGitignorePath(tt.path)
 			if file.Name != tt.wantName {
 				t.Errorf("Name = %v, want %v", file.Name, tt.wantName)
 			}
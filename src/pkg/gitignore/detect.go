@@ -0,0 +1,146 @@
+package gitignore
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DetectMaxFiles bounds how many files DetectTemplates looks at, so that
+// scanning a large tree stays quick
+const DetectMaxFiles = 20000
+
+// detectExtensions maps lowercase file extensions to the template their
+// files suggest
+var detectExtensions = map[string]string{
+	".c":     "c",
+	".h":     "c",
+	".cc":    "c++",
+	".cpp":   "c++",
+	".cxx":   "c++",
+	".hpp":   "c++",
+	".cs":    "visualstudio",
+	".dart":  "dart",
+	".ex":    "elixir",
+	".exs":   "elixir",
+	".go":    "go",
+	".hs":    "haskell",
+	".ipynb": "jupyternotebooks",
+	".java":  "java",
+	".js":    "node",
+	".jsx":   "node",
+	".mjs":   "node",
+	".cjs":   "node",
+	".ts":    "node",
+	".tsx":   "node",
+	".kt":    "kotlin",
+	".kts":   "kotlin",
+	".lua":   "lua",
+	".pl":    "perl",
+	".py":    "python",
+	".r":     "r",
+	".rb":    "ruby",
+	".rs":    "rust",
+	".scala": "scala",
+	".swift": "swift",
+	".tex":   "tex",
+	".tf":    "terraform",
+	".zig":   "zig",
+}
+
+// detectFiles maps file names that identify a toolchain to their template
+var detectFiles = map[string]string{
+	"build.gradle":     "gradle",
+	"build.gradle.kts": "gradle",
+	"Cargo.toml":       "rust",
+	"CMakeLists.txt":   "cmake",
+	"composer.json":    "composer",
+	"Gemfile":          "ruby",
+	"go.mod":           "go",
+	"mix.exs":          "elixir",
+	"package.json":     "node",
+	"pom.xml":          "maven",
+	"pubspec.yaml":     "dart",
+	"pyproject.toml":   "python",
+	"requirements.txt": "python",
+}
+
+// Detection is a template suggested by the files in a directory
+type Detection struct {
+	Template string // template name for add, e.g. "go"
+	Files    int    // number of files that suggested it
+	Example  string // first such file, relative to the scanned directory
+}
+
+// DetectTemplates scans the tree under dir and suggests templates for the
+// languages and tools found, by file extension and well-known file names,
+// most files first
+// Paths ignored by patterns (as returned by PatternLines, relative to dir) are
+// skipped, as are hidden directories and node_modules; at most
+// DetectMaxFiles files are examined
+func DetectTemplates(dir string, patterns []string) ([]Detection, error) {
+	compiled := compilePatterns(patterns)
+	found := make(map[string]*Detection)
+	seen := 0
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			return nil // unreadable entries are simply not counted
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			if checkPath(compiled, strings.Split(rel, "/"), true).Ignored {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if checkPath(compiled, strings.Split(rel, "/"), false).Ignored {
+			return nil
+		}
+
+		if seen++; seen > DetectMaxFiles {
+			return filepath.SkipAll
+		}
+		template, ok := detectFiles[d.Name()]
+		if !ok {
+			template, ok = detectExtensions[strings.ToLower(path.Ext(d.Name()))]
+		}
+		if !ok {
+			return nil
+		}
+		if det := found[template]; det != nil {
+			det.Files++
+		} else {
+			found[template] = &Detection{Template: template, Files: 1, Example: rel}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	detections := make([]Detection, 0, len(found))
+	for _, det := range found {
+		detections = append(detections, *det)
+	}
+	sort.Slice(detections, func(i, j int) bool {
+		if detections[i].Files != detections[j].Files {
+			return detections[i].Files > detections[j].Files
+		}
+		return detections[i].Template < detections[j].Template
+	})
+	return detections, nil
+}
@@ -0,0 +1,46 @@
+package gitignore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectTemplates(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"go.mod",
+		"main.go",
+		"cmd/tool/main.go",
+		"web/app.ts",
+		"web/package.json",
+		"web/node_modules/dep/index.js",
+		"build/out.rs",
+		".cache/x.py",
+		"docs/README.md",
+		"notes.log.py",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+
+	detections, err := DetectTemplates(dir, []string{"build/", "*.log.py"})
+	if err != nil {
+		t.Fatalf("DetectTemplates() error = %v", err)
+	}
+	got := fmt.Sprint(detections)
+	want := "[{go 3 cmd/tool/main.go} {node 2 web/app.ts}]"
+	if got != want {
+		t.Errorf("DetectTemplates() = %s, want %s", got, want)
+	}
+
+	if _, err := DetectTemplates(filepath.Join(dir, "missing"), nil); err == nil {
+		t.Error("DetectTemplates() of a missing directory should fail")
+	}
+}
@@ -0,0 +1,56 @@
+package gitignore
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "# Go\n*.exe\n*.dll\n*.so\nbin/\n"
+	to := "# Go\n*.exe\n*.dll\n*.so\n*.dylib\nbin/\n"
+
+	want := `--- local
++++ upstream
+@@ -2,4 +2,5 @@
+ *.exe
+ *.dll
+ *.so
++*.dylib
+ bin/
+`
+	if got := UnifiedDiff(from, to, "local", "upstream"); got != want {
+		t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffSeparateHunks(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	to := "A\nb\nc\nd\ne\nf\ng\nh\ni\nj\nK\n"
+
+	want := `--- x
++++ y
+@@ -1,4 +1,4 @@
+-a
++A
+ b
+ c
+ d
+@@ -8,4 +8,4 @@
+ h
+ i
+ j
+-k
++K
+`
+	if got := UnifiedDiff(from, to, "x", "y"); got != want {
+		t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnifiedDiffEdgeCases(t *testing.T) {
+	if got := UnifiedDiff("a\nb\n", "a\nb", "x", "y"); got != "" {
+		t.Errorf("UnifiedDiff() of equal content = %q, want empty", got)
+	}
+
+	want := "--- x\n+++ y\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+	if got := UnifiedDiff("", "a\nb\n", "x", "y"); got != want {
+		t.Errorf("UnifiedDiff() from empty = %q, want %q", got, want)
+	}
+}
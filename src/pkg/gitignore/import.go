@@ -0,0 +1,243 @@
+// Package gitignore handles operations on local .gitignore files
+package gitignore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LegacySectionName is the section Import uses for unstructured content
+const LegacySectionName = "Legacy"
+
+// ImportedSection is a named block of content produced by SplitImport
+type ImportedSection struct {
+	Name    string
+	Content string
+}
+
+// SplitImport divides hand-written (unmanaged) content into sections
+// Without detect, all content becomes a single Legacy section
+// With detect, content is split at blank lines and each block that starts
+// with a comment becomes a section named after that comment; blocks without
+// a comment header are merged into the preceding section (or Legacy)
+func SplitImport(content string, detect bool) []ImportedSection {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+	if !detect {
+		return []ImportedSection{{Name: LegacySectionName, Content: strings.TrimSpace(content) + "\n"}}
+	}
+
+	var sections []ImportedSection
+	for _, block := range splitBlocks(content) {
+		name := headerName(block[0])
+		if name == "" && len(sections) > 0 {
+			last := &sections[len(sections)-1]
+			last.Content += "\n" + joinLines(block)
+			continue
+		}
+		if name == "" {
+			name = LegacySectionName
+		}
+		sections = append(sections, ImportedSection{Name: name, Content: joinLines(block)})
+	}
+	return sections
+}
+
+// splitBlocks splits content into runs of non-blank lines
+func splitBlocks(content string) [][]string {
+	var blocks [][]string
+	var current []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, strings.TrimRight(line, "\r"))
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, current)
+	}
+	return blocks
+}
+
+// headerName derives a section name from a comment line such as
+// "# Node modules ###", returning "" if the line is not a named comment
+func headerName(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "#") {
+		return ""
+	}
+	return strings.TrimSpace(strings.Trim(trimmed, "#"))
+}
+
+// Import adds content from another file as managed sections, splitting it
+// with SplitImport; sections already marked in that content are kept as-is
+// Generated names that collide with existing sections get a numeric suffix
+// It returns the names of the sections created
+func (m *Manager) Import(content string, detect bool) ([]string, error) {
+	existing, err := m.ListSections()
+	if err != nil {
+		return nil, err
+	}
+
+	imported, created, err := convertUnmanaged(m.markers, content, detect, existing)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(imported) == "" {
+		return nil, nil
+	}
+
+	currentContent, err := m.Read()
+	if err != nil {
+		return nil, err
+	}
+	if currentContent != "" {
+		importedLines, err := splitLines(imported)
+		if err != nil {
+			return nil, err
+		}
+		for _, sec := range m.markers.findSections(importedLines) {
+			if containsString(existing, sec.name) {
+				return nil, fmt.Errorf("section '%s' already exists in .gitignore", sec.name)
+			}
+		}
+		imported = strings.TrimRight(currentContent, "\n") + "\n\n" + imported
+	}
+
+	return created, m.write(imported)
+}
+
+// Adopt converts the unmanaged content of the gitignore file into managed
+// sections in place, leaving existing sections untouched
+// It returns the names of the sections created
+func (m *Manager) Adopt(detect bool) ([]string, error) {
+	content, err := m.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := m.ListSections()
+	if err != nil {
+		return nil, err
+	}
+
+	adopted, created, err := convertUnmanaged(m.markers, content, detect, existing)
+	if err != nil {
+		return nil, err
+	}
+	if adopted == content {
+		return created, nil
+	}
+	return created, m.write(adopted)
+}
+
+// convertUnmanaged wraps every run of unmanaged lines in content into
+// sections (see SplitImport), copying existing sections verbatim
+// Names in taken, and names generated along the way, are not reused
+func convertUnmanaged(mk Markers, content string, detect bool, taken []string) (string, []string, error) {
+	lines, err := splitLines(content)
+	if err != nil {
+		return "", nil, err
+	}
+
+	used := make(map[string]bool)
+	for _, name := range taken {
+		used[name] = true
+	}
+	for _, sec := range mk.findSections(lines) {
+		used[sec.name] = true
+	}
+
+	var out []string
+	var created []string
+	var gap []string
+
+	flushGap := func() {
+		for _, imp := range SplitImport(joinLines(gap), detect) {
+			name := uniqueSectionName(imp.Name, used)
+			used[name] = true
+			created = append(created, name)
+			out = append(out, "")
+			out = append(out, strings.Split(strings.TrimSuffix(mk.formatSection(name, imp.Content), "\n"), "\n")...)
+			out = append(out, "")
+		}
+		gap = nil
+	}
+
+	next := 0
+	for _, sec := range mk.findSections(lines) {
+		gap = append(gap, lines[next:sec.start]...)
+		flushGap()
+		end := sec.last()
+		if end >= len(lines) {
+			end = len(lines) - 1
+		}
+		out = append(out, "")
+		out = append(out, lines[sec.start:end+1]...)
+		out = append(out, "")
+		next = end + 1
+	}
+	gap = append(gap, lines[next:]...)
+	flushGap()
+
+	return strings.TrimLeft(collapseBlankLines(out), "\n"), created, nil
+}
+
+// uniqueSectionName returns name, or name with a numeric suffix if taken
+func uniqueSectionName(name string, used map[string]bool) string {
+	if !used[name] {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s %d", name, i)
+		if !used[candidate] {
+			return candidate
+		}
+	}
+}
+
+// containsString reports whether values includes s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Export returns the gitignore content with all section markers removed
+// Everything else, including blank-line spacing, is preserved, and the file
+// itself is not modified
+func (m *Manager) Export() (string, error) {
+	content, err := m.Read()
+	if err != nil {
+		return "", err
+	}
+
+	lines, err := splitLines(content)
+	if err != nil {
+		return "", err
+	}
+
+	markers := make(map[int]bool)
+	for _, sec := range m.markers.findSections(lines) {
+		markers[sec.start] = true
+		if !sec.unterminated {
+			markers[sec.end] = true
+		}
+	}
+
+	var kept []string
+	for i, line := range lines {
+		if !markers[i] {
+			kept = append(kept, line)
+		}
+	}
+	return joinLines(kept), nil
+}
@@ -0,0 +1,191 @@
+package gitignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitImport(t *testing.T) {
+	content := `# Dependencies
+node_modules/
+vendor/
+
+# Build output
+dist/
+
+*.tmp
+
+secrets.env
+`
+
+	t.Run("single legacy section", func(t *testing.T) {
+		sections := SplitImport(content, false)
+		if len(sections) != 1 {
+			t.Fatalf("expected 1 section, got %d", len(sections))
+		}
+		if sections[0].Name != LegacySectionName {
+			t.Errorf("Name = %q, want %q", sections[0].Name, LegacySectionName)
+		}
+	})
+
+	t.Run("detect comment headers", func(t *testing.T) {
+		sections := SplitImport(content, true)
+		if len(sections) != 2 {
+			t.Fatalf("expected 2 sections, got %d: %+v", len(sections), sections)
+		}
+		if sections[0].Name != "Dependencies" || sections[1].Name != "Build output" {
+			t.Errorf("names = %q, %q", sections[0].Name, sections[1].Name)
+		}
+		// Blocks without a header join the preceding section
+		want := "# Build output\ndist/\n\n*.tmp\n\nsecrets.env\n"
+		if sections[1].Content != want {
+			t.Errorf("Content = %q, want %q", sections[1].Content, want)
+		}
+	})
+
+	t.Run("leading block without header", func(t *testing.T) {
+		sections := SplitImport("*.log\n\n# Editors\n.idea/\n", true)
+		if len(sections) != 2 || sections[0].Name != LegacySectionName || sections[1].Name != "Editors" {
+			t.Errorf("unexpected sections: %+v", sections)
+		}
+	})
+
+	t.Run("empty content", func(t *testing.T) {
+		if sections := SplitImport("\n\n", true); len(sections) != 0 {
+			t.Errorf("expected no sections, got %+v", sections)
+		}
+	})
+}
+
+func TestAdopt(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitignorePath := filepath.Join(tmpDir, ".gitignore")
+
+	content := `# Logs
+*.log
+
+### START: Go
+*.exe
+### END: Go
+
+# Editors
+.idea/
+`
+	if err := os.WriteFile(gitignorePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	created, err := manager.Adopt(true)
+	if err != nil {
+		t.Fatalf("Adopt() error = %v", err)
+	}
+	if len(created) != 2 || created[0] != "Logs" || created[1] != "Editors" {
+		t.Errorf("Adopt() created = %v, want [Logs Editors]", created)
+	}
+
+	got, _ := manager.Read()
+	want := `### START: Logs
+# Logs
+*.log
+### END: Logs
+
+### START: Go
+*.exe
+### END: Go
+
+### START: Editors
+# Editors
+.idea/
+### END: Editors
+`
+	if got != want {
+		t.Errorf("Adopt() content =\n%s\nwant\n%s", got, want)
+	}
+
+	// Adopting again is a no-op now that everything is managed
+	created, err = manager.Adopt(true)
+	if err != nil {
+		t.Fatalf("Adopt() error = %v", err)
+	}
+	if len(created) != 0 {
+		t.Errorf("second Adopt() created = %v, want none", created)
+	}
+}
+
+func TestImportFromOtherFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+
+	if err := manager.Add(LegacySectionName, "existing/\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	created, err := manager.Import("old/\n*.bak\n", false)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	// The existing Legacy section forces a unique name
+	if len(created) != 1 || created[0] != "Legacy 2" {
+		t.Fatalf("Import() created = %v, want [Legacy 2]", created)
+	}
+
+	body, err := manager.GetSection("Legacy 2")
+	if err != nil {
+		t.Fatalf("GetSection() error = %v", err)
+	}
+	if body != "old/\n*.bak\n" {
+		t.Errorf("imported body = %q", body)
+	}
+	if body, _ := manager.GetSection(LegacySectionName); body != "existing/\n" {
+		t.Errorf("existing section changed: %q", body)
+	}
+}
+
+func TestExport(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitignorePath := filepath.Join(tmpDir, ".gitignore")
+
+	content := `# hand-written
+*.log
+
+### START: Go
+*.exe
+
+*.test
+### END: Go
+
+### START: ignored/tmp/
+tmp/
+### END: ignored/tmp/
+`
+	if err := os.WriteFile(gitignorePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	got, err := manager.Export()
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	want := `# hand-written
+*.log
+
+*.exe
+
+*.test
+
+tmp/
+`
+	if got != want {
+		t.Errorf("Export() =\n%s\nwant\n%s", got, want)
+	}
+
+	// The original file must be left unchanged
+	after, _ := manager.Read()
+	if after != content {
+		t.Error("Export() modified the original file")
+	}
+}
@@ -3,65 +3,944 @@ package gitignore
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
 const (
 	DefaultFilename    = ".gitignore"
 	SectionStartPrefix = "### START:"
 	SectionEndPrefix   = "### END:"
+
+	// ForeignStartPrefix and ForeignEndPrefix, each followed by the generator
+	// URL, delimit the blocks written by gitignore.io, which are recognized as
+	// sections for interoperability
+	ForeignStartPrefix = "# Created by "
+	ForeignEndPrefix   = "# End of "
+
+	// HeaderPrefix starts the provenance comment written by ProvenanceHeader
+	HeaderPrefix = "# Added by gitignore from "
+
+	// BackupSuffix is appended to the file path to name its backup
+	BackupSuffix = ".bak"
 )
 
-// Manager handles gitignore file operations
-type Manager struct {
-	filepath string
-}
+// Markers are the comment prefixes that delimit a managed section; each is
+// followed by a space and the section name
+type Markers struct {
+	Start string
+	End   string
+}
+
+// DefaultMarkers are the "### START:" / "### END:" markers
+var DefaultMarkers = Markers{Start: SectionStartPrefix, End: SectionEndPrefix}
+
+// NewMarkers returns markers with the given prefixes, using the default for
+// an empty one
+// Both must be comments, and neither may start with the other, so START and
+// END lines can't be mistaken for each other or for patterns
+func NewMarkers(start, end string) (Markers, error) {
+	mk := DefaultMarkers
+	if start = strings.TrimSpace(start); start != "" {
+		mk.Start = start
+	}
+	if end = strings.TrimSpace(end); end != "" {
+		mk.End = end
+	}
+	for _, prefix := range []string{mk.Start, mk.End} {
+		if !strings.HasPrefix(prefix, "#") {
+			return Markers{}, fmt.Errorf("section marker '%s' must start with #", prefix)
+		}
+	}
+	if strings.HasPrefix(mk.Start, mk.End) || strings.HasPrefix(mk.End, mk.Start) {
+		return Markers{}, fmt.Errorf("section markers '%s' and '%s' must not start with one another", mk.Start, mk.End)
+	}
+	return mk, nil
+}
+
+// ErrSectionNotFound is wrapped by errors for a section that is not in the file
+var ErrSectionNotFound = errors.New("not found in .gitignore")
+
+// ErrSymlink is wrapped by errors for a file that is a symlink and is not
+// written through, see WithFollowSymlinks
+var ErrSymlink = errors.New("is a symlink")
+
+// ErrPatternNotFound is wrapped by errors for a pattern that is not in the
+// section it was looked for in
+var ErrPatternNotFound = errors.New("not found in section")
+
+// ProvenanceHeader returns a comment line recording where a section came from
+// and when, e.g. "# Added by gitignore from github/go on 2024-01-02"
+// Being a comment, it is never treated as a pattern
+func ProvenanceHeader(origin string, date time.Time) string {
+	return fmt.Sprintf("%s%s on %s", HeaderPrefix, origin, date.Format("2006-01-02"))
+}
+
+// Manager handles gitignore file operations
+type Manager struct {
+	filepath string
+	markers  Markers
+	backup   bool    // copy the file to BackupPath before each change
+	dryRun   bool    // keep changes in pending instead of writing them
+	dedupe   bool    // leave out patterns the file already has when adding, see WithDedupe
+	follow   bool    // write through a symlinked file to its target, see WithFollowSymlinks
+	pending  *string // content a dry run would have written, nil if unchanged
+}
+
+// Option configures optional Manager behavior
+type Option func(*Manager)
+
+// WithDedupe makes Add and AddAt leave out pattern lines of the new section
+// that the file already has, or that repeat within the section (see
+// DuplicatePatterns); comments and blank lines are kept
+func WithDedupe(dedupe bool) Option {
+	return func(m *Manager) {
+		m.dedupe = dedupe
+	}
+}
+
+// WithFollowSymlinks makes writes to a file that is a symlink go to the file
+// it points to, which may be outside the repository
+// By default such writes fail with ErrSymlink; reading always follows links
+func WithFollowSymlinks(follow bool) Option {
+	return func(m *Manager) {
+		m.follow = follow
+	}
+}
+
+// NewManager creates a new gitignore manager for the given directory
+func NewManager(dir string, opts ...Option) *Manager {
+	return NewManagerWithPath(filepath.Join(dir, DefaultFilename), opts...)
+}
+
+// NewManagerWithPath creates a new gitignore manager for a specific file path
+func NewManagerWithPath(path string, opts ...Option) *Manager {
+	m := &Manager{filepath: path, markers: DefaultMarkers}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// SetMarkers changes the markers that delimit sections (see NewMarkers)
+// Sections written with other markers are no longer recognized
+func (m *Manager) SetMarkers(markers Markers) {
+	m.markers = markers
+}
+
+// SetBackup makes every change first copy the existing file to BackupPath
+func (m *Manager) SetBackup(backup bool) {
+	m.backup = backup
+}
+
+// SetDryRun makes changes stay in memory instead of being written to disk
+// Later reads see the pending content, so several changes build on each
+// other; Preview shows the result as a diff
+func (m *Manager) SetDryRun(dryRun bool) {
+	m.dryRun = dryRun
+	m.pending = nil
+}
+
+// Preview returns a unified diff from the file on disk to the content a dry
+// run would have written, or "" if nothing would change
+func (m *Manager) Preview() (string, error) {
+	if m.pending == nil {
+		return "", nil
+	}
+	current, err := m.readFile()
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(m.filepath)
+	return UnifiedDiff(current, *m.pending, name, name+" (dry run)"), nil
+}
+
+// BackupPath returns the path of the backup file, e.g. ".gitignore.bak"
+func (m *Manager) BackupPath() string {
+	return m.filepath + BackupSuffix
+}
+
+// Backup copies the gitignore file to BackupPath, replacing any earlier backup
+// It does nothing if the file does not exist
+func (m *Manager) Backup() error {
+	content, err := os.ReadFile(m.filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	if err := os.WriteFile(m.BackupPath(), content, 0644); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the gitignore file with its most recent backup
+// The backup is kept, so restoring again is harmless
+func (m *Manager) Restore() error {
+	content, err := os.ReadFile(m.BackupPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no backup found at %s", m.BackupPath())
+		}
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	path, err := m.writePath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// FindExcludeFile returns the path of info/exclude in the git directory of
+// the repository containing dir, searching dir and its parents for .git (see
+// findGitDir)
+// The file itself need not exist yet; it is created on the first write
+func FindExcludeFile(dir string) (string, error) {
+	gitDir, err := findGitDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if gitDir == "" {
+		return "", fmt.Errorf("no .git found; .git/info/exclude only exists inside a git repository")
+	}
+	return filepath.Join(gitDir, "info", "exclude"), nil
+}
+
+// FindOriginURL returns the URL of the "origin" remote in .git/config for
+// the repository containing dir, or "" if there is no repository or it has
+// no origin
+func FindOriginURL(dir string) (string, error) {
+	gitDir, err := findGitDir(dir)
+	if err != nil || gitDir == "" {
+		return "", err
+	}
+	file, err := os.Open(filepath.Join(gitDir, "config"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer file.Close()
+
+	inOrigin := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inOrigin = strings.Join(strings.Fields(line), " ") == `[remote "origin"]`
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if inOrigin && ok && strings.EqualFold(strings.TrimSpace(key), "url") {
+			return strings.Trim(strings.TrimSpace(value), `"`), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// FindWorkTree returns the top of the working tree containing dir: the
+// nearest of dir and its parents holding .git, or "" if there is none
+func FindWorkTree(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// findGitDir returns the git directory of the repository containing dir,
+// searching dir and its parents, or "" if there is none
+// In a worktree or submodule .git is a file whose "gitdir:" line names the
+// real git directory; a worktree shares info/exclude and config with its
+// main repository, so the directory named by its commondir file is returned
+func findGitDir(dir string) (string, error) {
+	top, err := FindWorkTree(dir)
+	if err != nil || top == "" {
+		return "", err
+	}
+	gitDir := filepath.Join(top, ".git")
+	info, err := os.Stat(gitDir)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return gitDir, nil
+	}
+
+	content, err := os.ReadFile(gitDir)
+	if err != nil {
+		return "", err
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir:")
+	target = strings.TrimSpace(target)
+	if !ok || target == "" {
+		return "", fmt.Errorf("%s is not a git directory or a 'gitdir:' file", gitDir)
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(top, target)
+	}
+	if common, err := os.ReadFile(filepath.Join(target, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(target, commonDir)
+		}
+		return filepath.Clean(commonDir), nil
+	}
+	return filepath.Clean(target), nil
+}
+
+// Exists checks if the gitignore file exists (or, in a dry run, would)
+func (m *Manager) Exists() bool {
+	if m.pending != nil {
+		return true
+	}
+	_, err := os.Stat(m.filepath)
+	return err == nil
+}
+
+// Read reads the current gitignore file content, including changes pending
+// in a dry run
+func (m *Manager) Read() (string, error) {
+	if m.pending != nil {
+		return *m.pending, nil
+	}
+	return m.readFile()
+}
+
+// readFile reads the gitignore file from disk ("" if it doesn't exist)
+func (m *Manager) readFile() (string, error) {
+	content, err := os.ReadFile(m.filepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	return string(content), nil
+}
+
+// ResolveSection returns the name of the section that name refers to: the
+// section itself if one has exactly that name, otherwise the one whose name
+// differs only in case, so "global/macos" finds "Global/macOS"
+// It fails with ErrSectionNotFound if there is none, and if several
+// sections match in different casings
+func (m *Manager) ResolveSection(name string) (string, error) {
+	sections, err := m.ListSections()
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	seen := make(map[string]bool)
+	for _, section := range sections {
+		if section == name {
+			return section, nil
+		}
+		if strings.EqualFold(section, name) && !seen[section] {
+			seen[section] = true
+			matches = append(matches, section)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("section '%s' %w", name, ErrSectionNotFound)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("section '%s' is ambiguous: it matches '%s'", name, strings.Join(matches, "', '"))
+}
+
+// HasSection checks if a section already exists in the gitignore
+// The name must match exactly, so "ignored/dist" isn't found when only
+// "ignored/dist/" exists
+func (m *Manager) HasSection(sectionName string) (bool, error) {
+	content, err := m.Read()
+	if err != nil {
+		return false, err
+	}
+	lines, err := splitLines(content)
+	if err != nil {
+		return false, err
+	}
+	for _, sec := range m.markers.findSections(lines) {
+		if sec.name == sectionName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Add adds a new section to the gitignore file
+func (m *Manager) Add(sectionName, content string) error {
+	exists, err := m.HasSection(sectionName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("section '%s' already exists in .gitignore", sectionName)
+	}
+
+	currentContent, err := m.Read()
+	if err != nil {
+		return err
+	}
+	if m.dedupe {
+		content, _ = m.dedupeContent(currentContent, content)
+	}
+
+	var builder strings.Builder
+	if currentContent != "" {
+		builder.WriteString(currentContent)
+		if !strings.HasSuffix(currentContent, "\n") {
+			builder.WriteString("\n")
+		}
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(m.markers.formatSection(sectionName, content))
+
+	return m.write(builder.String())
+}
+
+// formatSection wraps content in START/END markers for the named section
+func (mk Markers) formatSection(sectionName, content string) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s %s\n", mk.Start, sectionName))
+	content = strings.TrimSpace(content)
+	builder.WriteString(content)
+	if !strings.HasSuffix(content, "\n") {
+		builder.WriteString("\n")
+	}
+	builder.WriteString(fmt.Sprintf("%s %s\n", mk.End, sectionName))
+	return builder.String()
+}
+
+// Delete removes a section from the gitignore file
+func (m *Manager) Delete(sectionName string) error {
+	content, err := m.Read()
+	if err != nil {
+		return err
+	}
+
+	if content == "" {
+		return fmt.Errorf("section '%s' %w", sectionName, ErrSectionNotFound)
+	}
+
+	lines, err := splitLines(content)
+	if err != nil {
+		return err
+	}
+
+	var matches []section
+	for _, sec := range m.markers.findSections(lines) {
+		if sec.name == sectionName {
+			matches = append(matches, sec)
+		}
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("section '%s' %w", sectionName, ErrSectionNotFound)
+	}
+
+	return m.write(collapseBlankLines(removeSections(lines, matches)))
+}
+
+// Tidy normalizes whitespace without removing or reordering any pattern:
+// trailing whitespace is trimmed from every line, leading blank lines are
+// dropped, runs of blank lines become one, each managed section is set off
+// from its neighbors by exactly one blank line and the file ends in a single
+// newline
+// It reports whether the file changed
+func (m *Manager) Tidy() (bool, error) {
+	content, err := m.Read()
+	if err != nil || content == "" {
+		return false, err
+	}
+
+	lines, err := splitLines(content)
+	if err != nil {
+		return false, err
+	}
+
+	starts := make(map[int]bool)
+	ends := make(map[int]bool)
+	for _, sec := range m.markers.findSections(lines) {
+		starts[sec.start] = true
+		if !sec.unterminated {
+			ends[sec.end] = true
+		}
+	}
+
+	var tidied []string
+	for i, line := range lines {
+		line = trimTrailingSpace(line)
+		if line == "" && len(tidied) == 0 {
+			continue
+		}
+		if starts[i] && len(tidied) > 0 && tidied[len(tidied)-1] != "" {
+			tidied = append(tidied, "")
+		}
+		tidied = append(tidied, line)
+		if ends[i] && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" {
+			tidied = append(tidied, "")
+		}
+	}
+
+	result := collapseBlankLines(tidied)
+	if result == content {
+		return false, nil
+	}
+	return true, m.write(result)
+}
+
+// TrimPattern removes surrounding whitespace from a pattern, keeping a
+// trailing space escaped with a backslash (see trimTrailingSpace), so
+// "foo\ " stays a pattern matching "foo "
+func TrimPattern(pattern string) string {
+	return strings.TrimLeft(trimTrailingSpace(pattern), " \t")
+}
+
+// trimTrailingSpace removes trailing whitespace from a line, keeping a space
+// escaped with a backslash, which git treats as part of the pattern
+func trimTrailingSpace(line string) string {
+	trimmed := strings.TrimRight(line, " \t\r")
+	if strings.HasSuffix(trimmed, "\\") && len(trimmed) < len(line) && line[len(trimmed)] == ' ' {
+		return trimmed + " "
+	}
+	return trimmed
+}
+
+// MatchSections returns the names of sections matching a glob pattern, such
+// as "Global/*", in file order and without duplicates
+// Matching uses path.Match syntax and ignores case
+func (m *Manager) MatchSections(pattern string) ([]string, error) {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+
+	sections, err := m.ListSections()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range sections {
+		if matched, _ := path.Match(pattern, strings.ToLower(name)); matched && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// DeleteSections removes several sections in a single write
+// It fails without changing the file if any of them is missing
+func (m *Manager) DeleteSections(names []string) error {
+	content, err := m.Read()
+	if err != nil {
+		return err
+	}
+
+	lines, err := splitLines(content)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string]bool)
+	var matches []section
+	for _, sec := range m.markers.findSections(lines) {
+		for _, name := range names {
+			if sec.name == name {
+				matches = append(matches, sec)
+				found[name] = true
+			}
+		}
+	}
+	for _, name := range names {
+		if !found[name] {
+			return fmt.Errorf("section '%s' %w", name, ErrSectionNotFound)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	return m.write(collapseBlankLines(removeSections(lines, matches)))
+}
+
+// Clean removes managed sections whose body has no pattern lines (only
+// blank lines and comments), collapsing the blank lines left behind
+// It returns the names of the removed sections
+func (m *Manager) Clean() ([]string, error) {
+	content, err := m.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := splitLines(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var empty []section
+	var names []string
+	for _, sec := range m.markers.findSections(lines) {
+		end := sec.end
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if !hasPatterns(lines[sec.start+1 : end]) {
+			empty = append(empty, sec)
+			names = append(names, sec.name)
+		}
+	}
+	if len(empty) == 0 {
+		return nil, nil
+	}
+
+	return names, m.write(collapseBlankLines(removeSections(lines, empty)))
+}
+
+// hasPatterns reports whether any line is a pattern rather than a blank line
+// or comment
+func hasPatterns(lines []string) bool {
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			return true
+		}
+	}
+	return false
+}
+
+// Patterns returns every pattern line in the file, inside sections or not,
+// in file order and without duplicates
+// Blank lines, comments and section markers are left out, as is trailing
+// whitespace git would ignore
+// The first copy of a repeated pattern is kept, so the result isn't suitable
+// for matching; use PatternLines for that
+func (m *Manager) Patterns() ([]string, error) {
+	lines, err := m.PatternLines()
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, pattern := range lines {
+		if !seen[pattern] {
+			seen[pattern] = true
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns, nil
+}
+
+// PatternLines is like Patterns but keeps repeated patterns, so the result
+// can be passed to CheckPath, where the last matching pattern wins
+func (m *Manager) PatternLines() ([]string, error) {
+	content, err := m.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := splitLines(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		patterns = append(patterns, trimTrailingSpace(line))
+	}
+	return patterns, nil
+}
+
+// ContainsPattern reports whether pattern is a pattern line anywhere in the
+// file, compared exactly apart from a leading "./" (see NormalizePattern)
+// Comments and blank lines are not patterns, so "# dist" doesn't count
+func (m *Manager) ContainsPattern(pattern string) (bool, error) {
+	pattern = NormalizePattern(pattern)
+	if pattern == "" {
+		return false, fmt.Errorf("pattern cannot be empty")
+	}
+
+	patterns, err := m.Patterns()
+	if err != nil {
+		return false, err
+	}
+	for _, p := range patterns {
+		if NormalizePattern(p) == pattern {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Stats summarizes the lines of a gitignore file
+type Stats struct {
+	Sections   int // managed sections
+	Patterns   int // pattern lines, duplicates included
+	AdHoc      int // pattern lines outside any managed section
+	Duplicates int // pattern lines repeating an earlier one
+	Comments   int // comment lines, not counting section markers
+}
+
+// Stats counts the sections, patterns and comments in the file, reading it
+// once; patterns are compared as in Patterns
+func (m *Manager) Stats() (Stats, error) {
+	var stats Stats
+	content, err := m.Read()
+	if err != nil {
+		return stats, err
+	}
+
+	lines, err := splitLines(content)
+	if err != nil {
+		return stats, err
+	}
+
+	sections := m.markers.findSections(lines)
+	stats.Sections = len(sections)
+	inSection := make(map[int]bool)
+	for _, sec := range sections {
+		for i := sec.start; i <= sec.last() && i < len(lines); i++ {
+			inSection[i] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, m.markers.Start), strings.HasPrefix(trimmed, m.markers.End):
+		case strings.HasPrefix(trimmed, "#"):
+			stats.Comments++
+		default:
+			stats.Patterns++
+			if !inSection[i] {
+				stats.AdHoc++
+			}
+			pattern := trimTrailingSpace(line)
+			if seen[pattern] {
+				stats.Duplicates++
+			}
+			seen[pattern] = true
+		}
+	}
+	return stats, nil
+}
+
+// DuplicatePatterns returns the pattern lines of content, as they would be
+// added to a new section, that the file already has or that repeat an
+// earlier line of content; patterns are compared as in Patterns
+// Negated patterns ("!") are never reported, since what they re-include
+// depends on the lines before them
+func (m *Manager) DuplicatePatterns(content string) ([]string, error) {
+	current, err := m.Read()
+	if err != nil {
+		return nil, err
+	}
+	_, dropped := m.dedupeContent(current, content)
+	return dropped, nil
+}
+
+// dedupeContent returns content without the duplicate pattern lines
+// DuplicatePatterns reports for a file holding current, and those lines
+func (m *Manager) dedupeContent(current, content string) (string, []string) {
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(current, "\n") {
+		if m.isPattern(line) {
+			seen[trimTrailingSpace(line)] = true
+		}
+	}
+
+	var kept, dropped []string
+	for _, line := range strings.Split(content, "\n") {
+		if m.isPattern(line) && !strings.HasPrefix(strings.TrimSpace(line), "!") {
+			pattern := trimTrailingSpace(line)
+			if seen[pattern] {
+				dropped = append(dropped, pattern)
+				continue
+			}
+			seen[pattern] = true
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n"), dropped
+}
+
+// isPattern reports whether line is a pattern: not blank, a comment or a
+// section marker
+func (m *Manager) isPattern(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed != "" && !strings.HasPrefix(trimmed, "#") &&
+		!strings.HasPrefix(trimmed, m.markers.Start) && !strings.HasPrefix(trimmed, m.markers.End)
+}
+
+// Update replaces the content of an existing section in place, keeping its
+// position in the file
+func (m *Manager) Update(sectionName, content string) error {
+	current, err := m.Read()
+	if err != nil {
+		return err
+	}
+
+	lines, err := splitLines(current)
+	if err != nil {
+		return err
+	}
 
-// NewManager creates a new gitignore manager for the given directory
-func NewManager(dir string) *Manager {
-	return &Manager{
-		filepath: filepath.Join(dir, DefaultFilename),
+	for _, sec := range m.markers.findSections(lines) {
+		if sec.name != sectionName {
+			continue
+		}
+		var builder strings.Builder
+		builder.WriteString(joinLines(lines[:sec.start]))
+		builder.WriteString(m.markers.formatSection(sectionName, content))
+		if sec.last()+1 < len(lines) {
+			builder.WriteString(joinLines(lines[sec.last()+1:]))
+		}
+		return m.write(builder.String())
 	}
-}
 
-// NewManagerWithPath creates a new gitignore manager for a specific file path
-func NewManagerWithPath(path string) *Manager {
-	return &Manager{filepath: path}
+	return fmt.Errorf("section '%s' %w", sectionName, ErrSectionNotFound)
 }
 
-// Exists checks if the gitignore file exists
-func (m *Manager) Exists() bool {
-	_, err := os.Stat(m.filepath)
-	return err == nil
+// AddOrUpdate replaces the content of a section in place if it exists, or
+// adds it at the end of the file otherwise, reporting whether it was added
+func (m *Manager) AddOrUpdate(sectionName, content string) (created bool, err error) {
+	err = m.Update(sectionName, content)
+	if !errors.Is(err, ErrSectionNotFound) {
+		return false, err
+	}
+	if err := m.Add(sectionName, content); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-// Read reads the current gitignore file content
-func (m *Manager) Read() (string, error) {
-	content, err := os.ReadFile(m.filepath)
+// AppendToSection adds the lines of content that an existing section doesn't
+// already contain just before its END marker, keeping their order
+// Blank lines are dropped and lines are compared with surrounding whitespace
+// trimmed; it returns the lines added and leaves the file alone if there are
+// none
+func (m *Manager) AppendToSection(sectionName, content string) ([]string, error) {
+	current, err := m.Read()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return "", nil
+		return nil, err
+	}
+	lines, err := splitLines(current)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sec := range m.markers.findSections(lines) {
+		if sec.name != sectionName {
+			continue
 		}
-		return "", fmt.Errorf("failed to read .gitignore: %w", err)
+		seen := make(map[string]bool)
+		for _, line := range lines[sec.start+1 : sec.end] {
+			seen[strings.TrimSpace(line)] = true
+		}
+
+		var added []string
+		for _, line := range strings.Split(content, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || seen[line] {
+				continue
+			}
+			seen[line] = true
+			added = append(added, line)
+		}
+		if len(added) == 0 {
+			return nil, nil
+		}
+
+		updated := append(append(append([]string(nil), lines[:sec.end]...), added...), lines[sec.end:]...)
+		return added, m.write(joinLines(updated))
 	}
-	return string(content), nil
+
+	return nil, fmt.Errorf("section '%s' %w", sectionName, ErrSectionNotFound)
 }
 
-// HasSection checks if a section already exists in the gitignore
-func (m *Manager) HasSection(sectionName string) (bool, error) {
+// MoveSection relocates a managed section so that it becomes the section at
+// index position (0-based) among all managed sections
+// Content outside managed sections stays where it is
+func (m *Manager) MoveSection(sectionName string, position int) error {
 	content, err := m.Read()
 	if err != nil {
-		return false, err
+		return err
+	}
+
+	lines, err := splitLines(content)
+	if err != nil {
+		return err
 	}
-	startMarker := fmt.Sprintf("%s %s", SectionStartPrefix, sectionName)
-	return strings.Contains(content, startMarker), nil
+
+	sections := m.markers.findSections(lines)
+	from := -1
+	for i, sec := range sections {
+		if sec.name == sectionName {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return fmt.Errorf("section '%s' %w", sectionName, ErrSectionNotFound)
+	}
+	if position < 0 || position >= len(sections) {
+		return fmt.Errorf("position %d out of range (0-%d)", position, len(sections)-1)
+	}
+	if sections[from].unterminated {
+		return fmt.Errorf("section '%s' has no end marker", sectionName)
+	}
+	if position == from {
+		return nil
+	}
+
+	// Cut the block out along with the blank line that separated it from its
+	// neighbours (the one after it, or else the one before it)
+	sec := sections[from]
+	block := append([]string(nil), lines[sec.start:sec.end+1]...)
+	rest := append(append([]string(nil), lines[:sec.start]...), lines[sec.end+1:]...)
+	if sec.start < len(rest) && strings.TrimSpace(rest[sec.start]) == "" {
+		rest = append(rest[:sec.start], rest[sec.start+1:]...)
+	} else if sec.start > 0 && strings.TrimSpace(rest[sec.start-1]) == "" {
+		rest = append(rest[:sec.start-1], rest[sec.start:]...)
+	}
+
+	return m.write(joinLines(m.markers.insertSection(rest, block, position)))
 }
 
-// Add adds a new section to the gitignore file
-func (m *Manager) Add(sectionName, content string) error {
+// AddAt adds a new section so that it becomes the section at index position
+// (0-based) among all managed sections, like MoveSection; a position past the
+// last section places it after the last one
+func (m *Manager) AddAt(sectionName, content string, position int) error {
 	exists, err := m.HasSection(sectionName)
 	if err != nil {
 		return err
@@ -70,94 +949,381 @@ func (m *Manager) Add(sectionName, content string) error {
 		return fmt.Errorf("section '%s' already exists in .gitignore", sectionName)
 	}
 
-	currentContent, err := m.Read()
+	current, err := m.Read()
+	if err != nil {
+		return err
+	}
+	lines, err := splitLines(current)
 	if err != nil {
 		return err
 	}
 
-	var builder strings.Builder
-	if currentContent != "" {
-		builder.WriteString(currentContent)
-		if !strings.HasSuffix(currentContent, "\n") {
-			builder.WriteString("\n")
-		}
-		builder.WriteString("\n")
+	sections := m.markers.findSections(lines)
+	if position < 0 || position > len(sections) {
+		return fmt.Errorf("position %d out of range (0-%d)", position, len(sections))
+	}
+	if len(sections) == 0 {
+		return m.Add(sectionName, content)
+	}
+	if m.dedupe {
+		content, _ = m.dedupeContent(current, content)
 	}
 
-	builder.WriteString(fmt.Sprintf("%s %s\n", SectionStartPrefix, sectionName))
-	content = strings.TrimSpace(content)
-	builder.WriteString(content)
-	if !strings.HasSuffix(content, "\n") {
-		builder.WriteString("\n")
+	block := strings.Split(strings.TrimSuffix(m.markers.formatSection(sectionName, content), "\n"), "\n")
+	return m.write(joinLines(m.markers.insertSection(lines, block, position)))
+}
+
+// insertSection inserts a section block before the section at position in
+// lines, or after the last one, separated from its neighbours by a blank line
+// lines must contain at least one section
+func (mk Markers) insertSection(lines, block []string, position int) []string {
+	sections := mk.findSections(lines)
+	var insert []string
+	var at int
+	if position < len(sections) {
+		at = sections[position].start
+		insert = append(block, "")
+	} else {
+		at = sections[len(sections)-1].last() + 1
+		insert = append([]string{""}, block...)
+	}
+	if at > len(lines) {
+		at = len(lines)
 	}
-	builder.WriteString(fmt.Sprintf("%s %s\n", SectionEndPrefix, sectionName))
+	return append(append(append([]string(nil), lines[:at]...), insert...), lines[at:]...)
+}
 
-	return m.write(builder.String())
+// GetSection returns the body of a section, excluding its markers
+func (m *Manager) GetSection(sectionName string) (string, error) {
+	content, err := m.Read()
+	if err != nil {
+		return "", err
+	}
+
+	lines, err := splitLines(content)
+	if err != nil {
+		return "", err
+	}
+
+	for _, sec := range m.markers.findSections(lines) {
+		if sec.name != sectionName {
+			continue
+		}
+		body := lines[sec.start+1 : sec.end]
+		if len(body) == 0 {
+			return "", nil
+		}
+		return strings.Join(body, "\n") + "\n", nil
+	}
+
+	return "", fmt.Errorf("section '%s' %w", sectionName, ErrSectionNotFound)
 }
 
-// Delete removes a section from the gitignore file
-func (m *Manager) Delete(sectionName string) error {
+// SortSections sorts the patterns inside the named sections, or inside every
+// managed section when no names are given (see SortContent)
+// It returns the names of the sections that were sorted
+func (m *Manager) SortSections(names ...string) ([]string, error) {
 	content, err := m.Read()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if content == "" {
-		return fmt.Errorf("section '%s' not found in .gitignore", sectionName)
+	lines, err := splitLines(content)
+	if err != nil {
+		return nil, err
 	}
 
-	startMarker := fmt.Sprintf("%s %s", SectionStartPrefix, sectionName)
-	endMarker := fmt.Sprintf("%s %s", SectionEndPrefix, sectionName)
+	sections := m.markers.findSections(lines)
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		found := false
+		for _, sec := range sections {
+			if sec.name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("section '%s' %w", name, ErrSectionNotFound)
+		}
+		wanted[name] = true
+	}
 
-	var result strings.Builder
-	inSection := false
-	foundSection := false
+	var sorted []string
+	for _, sec := range sections {
+		if len(wanted) > 0 && !wanted[sec.name] {
+			continue
+		}
+		copy(lines[sec.start+1:sec.end], sortLines(lines[sec.start+1:sec.end]))
+		sorted = append(sorted, sec.name)
+	}
 
-	scanner := bufio.NewScanner(strings.NewReader(content))
-	prevLineEmpty := false
+	if newContent := joinLines(lines); newContent != content {
+		if err := m.write(newContent); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+// SortContent sorts pattern lines alphabetically (case-insensitive), keeping
+// each comment attached to the pattern below it
+// Blank lines and negation ("!") patterns are fixed boundaries: patterns are
+// only reordered between them, so a negation always stays after the patterns
+// it overrides
+// When only the first pattern of a group has comments, they head the whole
+// group (e.g. "# Build" above out/ and bin/) and stay at its top
+func SortContent(content string) string {
+	lines, err := splitLines(content)
+	if err != nil || len(lines) == 0 {
+		return content
+	}
+	return joinLines(sortLines(lines))
+}
+
+// sortLines implements SortContent on a slice of lines
+func sortLines(lines []string) []string {
+	type entry struct {
+		comments []string
+		pattern  string
+	}
+
+	result := make([]string, 0, len(lines))
+	var run []entry
+	var pending []string // comments not yet followed by a pattern
+
+	// header reports whether the first entry's comments head the whole run
+	header := func() bool {
+		if len(run) < 2 || len(run[0].comments) == 0 {
+			return false
+		}
+		for _, e := range run[1:] {
+			if len(e.comments) > 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	flush := func() {
+		if header() {
+			result = append(result, run[0].comments...)
+			run[0].comments = nil
+		}
+		sort.SliceStable(run, func(i, j int) bool {
+			return strings.ToLower(run[i].pattern) < strings.ToLower(run[j].pattern)
+		})
+		for _, e := range run {
+			result = append(result, e.comments...)
+			result = append(result, e.pattern)
+		}
+		result = append(result, pending...)
+		run, pending = nil, nil
+	}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "!"):
+			flush()
+			result = append(result, line)
+		case strings.HasPrefix(trimmed, "#"):
+			pending = append(pending, line)
+		default:
+			run = append(run, entry{comments: pending, pattern: line})
+			pending = nil
+		}
+	}
+	flush()
+
+	return result
+}
 
-		if strings.TrimSpace(line) == startMarker {
-			inSection = true
-			foundSection = true
+// MinimalContent drops comment lines and blank lines, keeping pattern lines
+// (including negations and escaped "\#" patterns) exactly as they are
+func MinimalContent(content string) string {
+	lines, err := splitLines(content)
+	if err != nil {
+		return content
+	}
+	var kept []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
 			continue
 		}
+		kept = append(kept, line)
+	}
+	return joinLines(kept)
+}
+
+// joinLines is the inverse of splitLines, producing newline-terminated content
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// section locates a managed block by the line indexes of its markers
+type section struct {
+	name  string
+	start int // index of the START marker line
+	end   int // index of the END marker line; if unterminated, the index of the next START marker or len(lines)
+
+	foreign      bool // a gitignore.io block rather than one delimited by Markers
+	unterminated bool // no END marker; the section is malformed
+}
+
+// last returns the index of the section's last line: its END marker, or the
+// line before wherever an unterminated section stops
+func (sec section) last() int {
+	if sec.unterminated {
+		return sec.end - 1
+	}
+	return sec.end
+}
+
+// splitLines splits file content into lines without their newlines
+func splitLines(content string) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading .gitignore: %w", err)
+	}
+	return lines, nil
+}
 
-		if strings.TrimSpace(line) == endMarker {
-			inSection = false
+// findSections returns every managed section in file order
+// A section without an END marker is malformed: it stops at the next START
+// marker, or extends to the end of the file, and is marked unterminated
+// Blocks generated by gitignore.io (see foreignSection) outside managed
+// sections are returned as sections too
+func (mk Markers) findSections(lines []string) []section {
+	var sections []section
+	for i := 0; i < len(lines); i++ {
+		line := TrimPattern(lines[i])
+		if !strings.HasPrefix(line, mk.Start) {
+			if sec, ok := foreignSection(lines, i); ok {
+				sections = append(sections, sec)
+				i = sec.end
+			}
 			continue
 		}
 
-		if !inSection {
-			if strings.TrimSpace(line) == "" {
-				if prevLineEmpty {
-					continue
-				}
-				prevLineEmpty = true
-			} else {
-				prevLineEmpty = false
+		name := strings.TrimLeft(strings.TrimPrefix(line, mk.Start), " \t")
+		endMarker := fmt.Sprintf("%s %s", mk.End, name)
+		sec := section{name: name, start: i, end: len(lines), unterminated: true}
+		for j := i + 1; j < len(lines); j++ {
+			trimmed := TrimPattern(lines[j])
+			if trimmed == endMarker {
+				sec.end = j
+				sec.unterminated = false
+				break
+			}
+			if strings.HasPrefix(trimmed, mk.Start) {
+				sec.end = j
+				break
 			}
-			result.WriteString(line)
-			result.WriteString("\n")
 		}
+		sections = append(sections, sec)
+		i = sec.last()
 	}
+	return sections
+}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading .gitignore: %w", err)
+// foreignSection reports whether a block generated by gitignore.io starts
+// at lines[i]: it runs from a "# Created by <url>" comment to the matching
+// "# End of <url>" one and is named after the URL's last path element, the
+// comma-separated template list (e.g. "go,node" for .../gitignore/api/go,node)
+// A "# Created by" comment without its "# End of" is left alone
+func foreignSection(lines []string, i int) (section, bool) {
+	url, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), ForeignStartPrefix)
+	url = strings.TrimSpace(url)
+	if !ok || !(strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://")) {
+		return section{}, false
+	}
+
+	end := ForeignEndPrefix + url
+	for j := i + 1; j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) == end {
+			name := path.Base(strings.TrimRight(url, "/"))
+			if name == "" || name == "." || strings.HasSuffix(url, "//"+name) {
+				name = url
+			}
+			return section{name: name, start: i, end: j, foreign: true}, true
+		}
+	}
+	return section{}, false
+}
+
+// removeSections returns lines with the given sections (markers included) dropped
+func removeSections(lines []string, sections []section) []string {
+	drop := make(map[int]bool)
+	for _, sec := range sections {
+		for i := sec.start; i <= sec.last() && i < len(lines); i++ {
+			drop[i] = true
+		}
+	}
+
+	var kept []string
+	for i, line := range lines {
+		if !drop[i] {
+			kept = append(kept, line)
+		}
 	}
+	return kept
+}
+
+// collapseBlankLines joins lines into file content, collapsing runs of blank
+// lines into one and trimming trailing whitespace so the file ends in a
+// single newline (or is empty)
+func collapseBlankLines(lines []string) string {
+	var result strings.Builder
+	prevLineEmpty := false
 
-	if !foundSection {
-		return fmt.Errorf("section '%s' not found in .gitignore", sectionName)
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			if prevLineEmpty {
+				continue
+			}
+			prevLineEmpty = true
+		} else {
+			prevLineEmpty = false
+		}
+		result.WriteString(line)
+		result.WriteString("\n")
 	}
 
 	finalContent := strings.TrimRight(result.String(), "\n\t ")
 	if finalContent != "" {
 		finalContent += "\n"
 	}
+	return finalContent
+}
+
+// UnterminatedSections returns the names of malformed sections: those whose
+// START marker has no matching END marker
+func (m *Manager) UnterminatedSections() ([]string, error) {
+	content, err := m.Read()
+	if err != nil {
+		return nil, err
+	}
 
-	return m.write(finalContent)
+	lines, err := splitLines(content)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, sec := range m.markers.findSections(lines) {
+		if sec.unterminated {
+			names = append(names, sec.name)
+		}
+	}
+	return names, nil
 }
 
 // ListSections returns all section names currently in the gitignore
@@ -167,26 +1333,98 @@ func (m *Manager) ListSections() ([]string, error) {
 		return nil, err
 	}
 
-	var sections []string
-	scanner := bufio.NewScanner(strings.NewReader(content))
+	lines, err := splitLines(content)
+	if err != nil {
+		return nil, err
+	}
+	foreign := make(map[int]string)
+	for _, sec := range m.markers.findSections(lines) {
+		if sec.foreign {
+			foreign[sec.start] = sec.name
+		}
+	}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if strings.HasPrefix(line, SectionStartPrefix) {
-			name := strings.TrimSpace(strings.TrimPrefix(line, SectionStartPrefix))
+	var sections []string
+	for i, line := range lines {
+		line = TrimPattern(line)
+		if strings.HasPrefix(line, m.markers.Start) {
+			sections = append(sections, strings.TrimLeft(strings.TrimPrefix(line, m.markers.Start), " \t"))
+		} else if name, ok := foreign[i]; ok {
 			sections = append(sections, name)
 		}
 	}
+	return sections, nil
+}
 
-	return sections, scanner.Err()
+// ListSectionsSorted is like ListSections, but returns the names sorted
+// alphabetically (case-insensitive) for output that is compared or diffed
+func (m *Manager) ListSectionsSorted() ([]string, error) {
+	sections, err := m.ListSections()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(sections, func(i, j int) bool {
+		a, b := strings.ToLower(sections[i]), strings.ToLower(sections[j])
+		if a != b {
+			return a < b
+		}
+		return sections[i] < sections[j]
+	})
+	return sections, nil
 }
 
 func (m *Manager) write(content string) error {
-	dir := filepath.Dir(m.filepath)
+	if m.dryRun {
+		m.pending = &content
+		return nil
+	}
+	path, err := m.writePath()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	if m.backup {
+		// Only back up a file that exists and is actually changing
+		if current, err := m.Read(); err == nil && current != "" && current != content {
+			if err := m.Backup(); err != nil {
+				return err
+			}
+		}
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// writePath returns the file a write should go to: the manager's path, or
+// the target of a symlink there when symlinks are followed
+// A symlink that isn't followed is refused, since os.WriteFile would write
+// through it, possibly outside the repository
+func (m *Manager) writePath() (string, error) {
+	info, err := os.Lstat(m.filepath)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return m.filepath, nil
+	}
+	target, err := os.Readlink(m.filepath)
+	if err != nil {
+		return "", err
+	}
+	if !m.follow {
+		return "", fmt.Errorf("%s %w to %s; refusing to write through it", m.filepath, ErrSymlink, target)
+	}
+	resolved, err := filepath.EvalSymlinks(m.filepath)
+	switch {
+	case os.IsNotExist(err):
+		// A dangling link: the write creates the file it names
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(m.filepath), target)
+		}
+		return target, nil
+	case err != nil:
+		return "", fmt.Errorf("failed to resolve symlink %s: %w", m.filepath, err)
 	}
-	return os.WriteFile(m.filepath, []byte(content), 0644)
+	return resolved, nil
 }
 
 // Path returns the gitignore file path
@@ -200,12 +1438,50 @@ const IgnoredSectionPrefix = "ignored/"
 // AddPatterns adds one or more patterns to the gitignore file, each wrapped in section markers.
 // Patterns that already have a section are skipped.
 func (m *Manager) AddPatterns(patterns []string) (added []string, skipped []string, err error) {
+	return m.addPatterns(patterns, false)
+}
+
+// AddPatternsNormalized is like AddPatterns, but also skips patterns that are
+// trivially equivalent to one already added via ignore (see NormalizePattern),
+// e.g. "./dist/" when "dist/" exists
+func (m *Manager) AddPatternsNormalized(patterns []string) (added []string, skipped []string, err error) {
+	return m.addPatterns(patterns, true)
+}
+
+// NormalizePattern reduces a pattern to a form shared by trivially equivalent
+// spellings: surrounding whitespace and a leading "./" are removed
+// A leading "/" (anchoring) and a trailing "/" (directories only) change what
+// a pattern matches, so "build/" and "/build" stay distinct from "build"
+func NormalizePattern(pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	return strings.TrimPrefix(pattern, "./")
+}
+
+func (m *Manager) addPatterns(patterns []string, normalize bool) (added []string, skipped []string, err error) {
+	covered := make(map[string]bool)
+	if normalize {
+		sections, err := m.ListSections()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, name := range sections {
+			if strings.HasPrefix(name, IgnoredSectionPrefix) {
+				covered[NormalizePattern(strings.TrimPrefix(name, IgnoredSectionPrefix))] = true
+			}
+		}
+	}
+
 	for _, pattern := range patterns {
-		pattern = strings.TrimSpace(pattern)
+		pattern = TrimPattern(pattern)
 		if pattern == "" {
 			continue
 		}
 
+		if normalize && covered[NormalizePattern(pattern)] {
+			skipped = append(skipped, pattern)
+			continue
+		}
+
 		sectionName := IgnoredSectionPrefix + pattern
 		exists, err := m.HasSection(sectionName)
 		if err != nil {
@@ -221,6 +1497,7 @@ func (m *Manager) AddPatterns(patterns []string) (added []string, skipped []stri
 			return added, skipped, err
 		}
 		added = append(added, pattern)
+		covered[NormalizePattern(pattern)] = true
 	}
 
 	return added, skipped, nil
@@ -228,7 +1505,7 @@ func (m *Manager) AddPatterns(patterns []string) (added []string, skipped []stri
 
 // RemovePattern removes a pattern that was added via AddPatterns (ignore command)
 func (m *Manager) RemovePattern(pattern string) error {
-	pattern = strings.TrimSpace(pattern)
+	pattern = TrimPattern(pattern)
 	if pattern == "" {
 		return fmt.Errorf("pattern cannot be empty")
 	}
@@ -236,3 +1513,40 @@ func (m *Manager) RemovePattern(pattern string) error {
 	sectionName := IgnoredSectionPrefix + pattern
 	return m.Delete(sectionName)
 }
+
+// RemoveFromSection removes the lines of a section that match pattern (with
+// surrounding whitespace trimmed), leaving the same pattern elsewhere in the
+// file alone
+func (m *Manager) RemoveFromSection(sectionName, pattern string) error {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return fmt.Errorf("pattern cannot be empty")
+	}
+
+	current, err := m.Read()
+	if err != nil {
+		return err
+	}
+	lines, err := splitLines(current)
+	if err != nil {
+		return err
+	}
+
+	for _, sec := range m.markers.findSections(lines) {
+		if sec.name != sectionName {
+			continue
+		}
+		updated := append([]string(nil), lines[:sec.start+1]...)
+		for _, line := range lines[sec.start+1 : sec.end] {
+			if strings.TrimSpace(line) != pattern {
+				updated = append(updated, line)
+			}
+		}
+		if len(updated) == sec.end {
+			return fmt.Errorf("pattern '%s' %w '%s'", pattern, ErrPatternNotFound, sectionName)
+		}
+		return m.write(joinLines(append(updated, lines[sec.end:]...)))
+	}
+
+	return fmt.Errorf("section '%s' %w", sectionName, ErrSectionNotFound)
+}
@@ -1,10 +1,12 @@
 package gitignore
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewManager(t *testing.T) {
@@ -222,6 +224,9 @@ func TestDeleteNonExistentSection(t *testing.T) {
 	if err == nil {
 		t.Error("Delete() should return error for non-existent section")
 	}
+	if !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("Delete() error = %v, want ErrSectionNotFound", err)
+	}
 }
 
 func TestDeletePreservesOtherSections(t *testing.T) {
@@ -327,6 +332,34 @@ func TestListSections(t *testing.T) {
 	}
 }
 
+func TestListSectionsSorted(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+
+	for _, name := range []string{"Python", "go", "Global/macOS", "Go"} {
+		if err := manager.Add(name, "*.tmp\n"); err != nil {
+			t.Fatalf("Add(%s) error = %v", name, err)
+		}
+	}
+
+	sorted, err := manager.ListSectionsSorted()
+	if err != nil {
+		t.Fatalf("ListSectionsSorted() error = %v", err)
+	}
+	if got := strings.Join(sorted, ","); got != "Global/macOS,Go,go,Python" {
+		t.Errorf("ListSectionsSorted() = %s, want Global/macOS,Go,go,Python", got)
+	}
+
+	// The default order is still the file order
+	sections, err := manager.ListSections()
+	if err != nil {
+		t.Fatalf("ListSections() error = %v", err)
+	}
+	if got := strings.Join(sections, ","); got != "Python,go,Global/macOS,Go" {
+		t.Errorf("ListSections() = %s, want file order", got)
+	}
+}
+
 func TestDeleteFromEmptyFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	manager := NewManager(tmpDir)
@@ -335,6 +368,9 @@ func TestDeleteFromEmptyFile(t *testing.T) {
 	if err == nil {
 		t.Error("Delete() should return error for empty/non-existent file")
 	}
+	if !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("Delete() error = %v, want ErrSectionNotFound", err)
+	}
 }
 
 func TestAddCreatesDirectory(t *testing.T) {
@@ -536,6 +572,54 @@ func TestAddPatternsWithExistingContent(t *testing.T) {
 	}
 }
 
+func TestNormalizePattern(t *testing.T) {
+	tests := map[string]string{
+		"dist":        "dist",
+		"./dist":      "dist",
+		" ./dist/ ":   "dist/",
+		"/dist":       "/dist",
+		"dist/":       "dist/",
+		"build/out/":  "build/out/",
+		"./build/out": "build/out",
+	}
+	for input, want := range tests {
+		if got := NormalizePattern(input); got != want {
+			t.Errorf("NormalizePattern(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestAddPatternsNormalized(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+
+	if _, _, err := manager.AddPatterns([]string{"dist/", "/out"}); err != nil {
+		t.Fatalf("AddPatterns() error = %v", err)
+	}
+
+	// Without normalization, equivalent spellings are separate patterns
+	added, _, err := manager.AddPatterns([]string{"./dist/"})
+	if err != nil {
+		t.Fatalf("AddPatterns() error = %v", err)
+	}
+	if len(added) != 1 {
+		t.Errorf("AddPatterns() added = %v, want ./dist/ added", added)
+	}
+
+	// Anchoring and the directory-only slash are significant, so the broader
+	// dist and out are still added
+	added, skipped, err := manager.AddPatternsNormalized([]string{"./dist/", "dist", "out", "./out", "build", "./build", "*.log"})
+	if err != nil {
+		t.Fatalf("AddPatternsNormalized() error = %v", err)
+	}
+	if strings.Join(added, ",") != "dist,out,build,*.log" {
+		t.Errorf("AddPatternsNormalized() added = %v, want [dist out build *.log]", added)
+	}
+	if strings.Join(skipped, ",") != "./dist/,./out,./build" {
+		t.Errorf("AddPatternsNormalized() skipped = %v, want [./dist/ ./out ./build]", skipped)
+	}
+}
+
 func TestRemovePattern(t *testing.T) {
 	tmpDir := t.TempDir()
 	manager := NewManager(tmpDir)
@@ -597,3 +681,1307 @@ func TestRemovePattern(t *testing.T) {
 		t.Errorf("Expected empty file, got:\n%s", content)
 	}
 }
+
+func TestRemoveFromSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	if err := manager.Add("Go", "*.exe\n*.log\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := manager.Add("Custom", "*.log\ntmp/\n  *.log\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := manager.RemoveFromSection("Custom", "*.log"); err != nil {
+		t.Fatalf("RemoveFromSection() error = %v", err)
+	}
+	content, _ := manager.Read()
+	want := "### START: Go\n*.exe\n*.log\n### END: Go\n\n### START: Custom\ntmp/\n### END: Custom\n"
+	if content != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+
+	if err := manager.RemoveFromSection("Custom", "*.log"); !errors.Is(err, ErrPatternNotFound) {
+		t.Errorf("RemoveFromSection() of a missing pattern error = %v, want ErrPatternNotFound", err)
+	}
+	if err := manager.RemoveFromSection("Rust", "target/"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("RemoveFromSection() of a missing section error = %v, want ErrSectionNotFound", err)
+	}
+}
+
+func TestAddUncreatableDirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// A regular file where a parent directory is expected cannot be replaced
+	blocker := filepath.Join(tmpDir, "services")
+	if err := os.WriteFile(blocker, []byte("not a directory"), 0644); err != nil {
+		t.Fatalf("Failed to create blocking file: %v", err)
+	}
+
+	manager := NewManagerWithPath(filepath.Join(blocker, "api", ".gitignore"))
+	err := manager.Add("Go", "*.exe\n")
+	if err == nil {
+		t.Fatal("Add() expected error when parent directory cannot be created")
+	}
+	if !strings.Contains(err.Error(), blocker) {
+		t.Errorf("Add() error = %v, want it to name the blocked path", err)
+	}
+}
+
+func TestGetSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+
+	if err := manager.Add("Go", "*.exe\n*.test\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := manager.Add("Global/macOS", ".DS_Store\n.AppleDouble\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Go", want: "*.exe\n*.test\n"},
+		{name: "Global/macOS", want: ".DS_Store\n.AppleDouble\n"},
+		{name: "Python", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := manager.GetSection(tt.name)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GetSection(%q) expected error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetSection(%q) error = %v", tt.name, err)
+			}
+			if got != tt.want {
+				t.Errorf("GetSection(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSectionExcludesOtherContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitignorePath := filepath.Join(tmpDir, ".gitignore")
+
+	content := `# hand-written
+*.log
+
+### START: Custom
+# keep me
+build/
+### END: Custom
+
+tmp/
+`
+	if err := os.WriteFile(gitignorePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	got, err := NewManager(tmpDir).GetSection("Custom")
+	if err != nil {
+		t.Fatalf("GetSection() error = %v", err)
+	}
+	if want := "# keep me\nbuild/\n"; got != want {
+		t.Errorf("GetSection() = %q, want %q", got, want)
+	}
+}
+
+func TestUnterminatedSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitignorePath := filepath.Join(tmpDir, ".gitignore")
+
+	content := `### START: Broken
+*.tmp
+
+### START: Go
+*.exe
+### END: Go
+
+### START: Node
+node_modules/
+### END: Node
+`
+	if err := os.WriteFile(gitignorePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	names, err := manager.UnterminatedSections()
+	if err != nil {
+		t.Fatalf("UnterminatedSections() error = %v", err)
+	}
+	if strings.Join(names, ",") != "Broken" {
+		t.Errorf("UnterminatedSections() = %v, want [Broken]", names)
+	}
+
+	// The broken section stops at the next START, so later sections are intact
+	got, err := manager.GetSection("Broken")
+	if err != nil {
+		t.Fatalf("GetSection(Broken) error = %v", err)
+	}
+	if got != "*.tmp\n\n" {
+		t.Errorf("GetSection(Broken) = %q", got)
+	}
+	if err := manager.Delete("Node"); err != nil {
+		t.Fatalf("Delete(Node) error = %v", err)
+	}
+	if err := manager.Delete("Broken"); err != nil {
+		t.Fatalf("Delete(Broken) error = %v", err)
+	}
+
+	result, _ := manager.Read()
+	if want := "### START: Go\n*.exe\n### END: Go\n"; result != want {
+		t.Errorf("after deletes = %q, want %q", result, want)
+	}
+}
+
+func TestGetSectionEmptyFile(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	if _, err := manager.GetSection("Go"); err == nil {
+		t.Error("GetSection() expected error for missing file")
+	}
+}
+
+func TestProvenanceHeader(t *testing.T) {
+	date := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	got := ProvenanceHeader("github/go", date)
+	want := "# Added by gitignore from github/go on 2024-01-02"
+	if got != want {
+		t.Errorf("ProvenanceHeader() = %q, want %q", got, want)
+	}
+	if !strings.HasPrefix(got, HeaderPrefix) {
+		t.Errorf("ProvenanceHeader() should start with HeaderPrefix")
+	}
+}
+
+func TestAddWithProvenanceHeader(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+
+	header := ProvenanceHeader("github/go", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err := manager.Add("Go", header+"\n*.exe\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	content, err := manager.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	want := "### START: Go\n" + header + "\n*.exe\n### END: Go\n"
+	if content != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestSortContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "simple",
+			content: "zeta\nalpha\nMid\n",
+			want:    "alpha\nMid\nzeta\n",
+		},
+		{
+			name:    "comments stay with the entry below",
+			content: "# about b\nb.txt\n# about a\na.txt\n",
+			want:    "# about a\na.txt\n# about b\nb.txt\n",
+		},
+		{
+			name:    "negation is a boundary",
+			content: "logs/\n*.log\n!keep.log\nz.tmp\na.tmp\n",
+			want:    "*.log\nlogs/\n!keep.log\na.tmp\nz.tmp\n",
+		},
+		{
+			name:    "blank lines separate groups",
+			content: "# Build\nout/\nbin/\n\n# Editors\n.vscode/\n.idea/\n",
+			want:    "# Build\nbin/\nout/\n\n# Editors\n.idea/\n.vscode/\n",
+		},
+		{
+			name:    "per-entry comments are not a group header",
+			content: "# Build\nout/\n# binaries\nbin/\n",
+			want:    "# binaries\nbin/\n# Build\nout/\n",
+		},
+		{
+			name:    "trailing comment stays at end of group",
+			content: "b\na\n# trailing\n",
+			want:    "a\nb\n# trailing\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SortContent(tt.content); got != tt.want {
+				t.Errorf("SortContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinimalContent(t *testing.T) {
+	content := "# Binaries\n*.exe\n\n  # indented comment\n!keep.exe\n\\#not-a-comment\nbuild/   \nspaced\\ \n\n"
+	want := "*.exe\n!keep.exe\n\\#not-a-comment\nbuild/   \nspaced\\ \n"
+	if got := MinimalContent(content); got != want {
+		t.Errorf("MinimalContent() = %q, want %q", got, want)
+	}
+	if got := MinimalContent("# only comments\n\n"); got != "" {
+		t.Errorf("MinimalContent() of comments only = %q, want empty", got)
+	}
+}
+
+func TestSortSections(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitignorePath := filepath.Join(tmpDir, ".gitignore")
+
+	content := `zz-unmanaged
+aa-unmanaged
+
+### START: Go
+*.test
+*.exe
+### END: Go
+
+### START: Custom
+tmp/
+build/
+### END: Custom
+`
+	if err := os.WriteFile(gitignorePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	sorted, err := manager.SortSections("Go")
+	if err != nil {
+		t.Fatalf("SortSections() error = %v", err)
+	}
+	if len(sorted) != 1 || sorted[0] != "Go" {
+		t.Errorf("SortSections() = %v, want [Go]", sorted)
+	}
+
+	goBody, _ := manager.GetSection("Go")
+	if goBody != "*.exe\n*.test\n" {
+		t.Errorf("Go section = %q, want sorted", goBody)
+	}
+	customBody, _ := manager.GetSection("Custom")
+	if customBody != "tmp/\nbuild/\n" {
+		t.Errorf("Custom section = %q, should be untouched", customBody)
+	}
+
+	// No names sorts every section but leaves unmanaged lines alone
+	sorted, err = manager.SortSections()
+	if err != nil {
+		t.Fatalf("SortSections() error = %v", err)
+	}
+	if len(sorted) != 2 {
+		t.Errorf("SortSections() = %v, want both sections", sorted)
+	}
+	customBody, _ = manager.GetSection("Custom")
+	if customBody != "build/\ntmp/\n" {
+		t.Errorf("Custom section = %q, want sorted", customBody)
+	}
+	result, _ := manager.Read()
+	if !strings.HasPrefix(result, "zz-unmanaged\naa-unmanaged\n") {
+		t.Errorf("unmanaged content should not be reordered:\n%s", result)
+	}
+
+	if _, err := manager.SortSections("Missing"); err == nil {
+		t.Error("SortSections() expected error for missing section")
+	}
+}
+
+func TestSortSectionsUnterminated(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitignorePath := filepath.Join(tmpDir, ".gitignore")
+
+	content := "### START: Broken\nz.tmp\na.tmp\n### START: Go\n*.test\n*.exe\n### END: Go\n"
+	if err := os.WriteFile(gitignorePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	sorted, err := manager.SortSections()
+	if err != nil {
+		t.Fatalf("SortSections() error = %v", err)
+	}
+	if strings.Join(sorted, ",") != "Broken,Go" {
+		t.Errorf("SortSections() = %v, want [Broken Go]", sorted)
+	}
+	result, _ := manager.Read()
+	if want := "### START: Broken\na.tmp\nz.tmp\n### START: Go\n*.exe\n*.test\n### END: Go\n"; result != want {
+		t.Errorf("SortSections() left %q, want %q", result, want)
+	}
+}
+
+func TestClean(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitignorePath := filepath.Join(tmpDir, ".gitignore")
+
+	initial := `*.log
+
+### START: Temp
+### END: Temp
+
+### START: Go
+*.exe
+### END: Go
+
+### START: Notes
+# just a comment
+
+### END: Notes
+
+### START: Node
+node_modules/
+### END: Node
+`
+	if err := os.WriteFile(gitignorePath, []byte(initial), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	removed, err := manager.Clean()
+	if err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if strings.Join(removed, ",") != "Temp,Notes" {
+		t.Errorf("Clean() removed = %v, want [Temp Notes]", removed)
+	}
+
+	expected := `*.log
+
+### START: Go
+*.exe
+### END: Go
+
+### START: Node
+node_modules/
+### END: Node
+`
+	result, _ := manager.Read()
+	if result != expected {
+		t.Errorf("Clean() result =\n%s\nwant\n%s", result, expected)
+	}
+
+	// A second run has nothing to do and leaves the file alone
+	removed, err = manager.Clean()
+	if err != nil || len(removed) != 0 {
+		t.Errorf("Clean() second run = %v, %v; want nothing removed", removed, err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitignorePath := filepath.Join(tmpDir, ".gitignore")
+
+	initial := `# header
+### START: Go
+*.exe
+### END: Go
+
+### START: Node
+node_modules/
+### END: Node
+`
+	if err := os.WriteFile(gitignorePath, []byte(initial), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	if err := manager.Update("Go", "*.exe\n*.test\n"); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	expected := `# header
+### START: Go
+*.exe
+*.test
+### END: Go
+
+### START: Node
+node_modules/
+### END: Node
+`
+	result, _ := manager.Read()
+	if result != expected {
+		t.Errorf("Update() result =\n%s\nwant\n%s", result, expected)
+	}
+
+	// Updating the last section keeps the file newline-terminated
+	if err := manager.Update("Node", "dist/"); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	result, _ = manager.Read()
+	if !strings.HasSuffix(result, "### START: Node\ndist/\n### END: Node\n") {
+		t.Errorf("Update() last section result =\n%s", result)
+	}
+
+	if err := manager.Update("Missing", "x"); err == nil {
+		t.Error("Update() expected error for missing section")
+	}
+}
+
+func TestAddOrUpdate(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+
+	created, err := manager.AddOrUpdate("Go", "*.exe\n")
+	if err != nil {
+		t.Fatalf("AddOrUpdate() error = %v", err)
+	}
+	if !created {
+		t.Error("AddOrUpdate() of a new section should report it as created")
+	}
+	if _, err := manager.AddOrUpdate("Node", "node_modules/\n"); err != nil {
+		t.Fatalf("AddOrUpdate() error = %v", err)
+	}
+
+	created, err = manager.AddOrUpdate("Go", "*.exe\n*.test\n")
+	if err != nil {
+		t.Fatalf("AddOrUpdate() error = %v", err)
+	}
+	if created {
+		t.Error("AddOrUpdate() of an existing section should report it as updated")
+	}
+
+	// The updated section keeps its place before Node
+	expected := `### START: Go
+*.exe
+*.test
+### END: Go
+
+### START: Node
+node_modules/
+### END: Node
+`
+	result, _ := manager.Read()
+	if result != expected {
+		t.Errorf("AddOrUpdate() result =\n%s\nwant\n%s", result, expected)
+	}
+}
+
+func TestAppendToSection(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	if err := manager.Add("Go", "*.exe\n*.test\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := manager.Add("Node", "node_modules/\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	added, err := manager.AppendToSection("Go", "# More Go\n*.test\n\nvendor/\nvendor/\n")
+	if err != nil {
+		t.Fatalf("AppendToSection() error = %v", err)
+	}
+	if strings.Join(added, "|") != "# More Go|vendor/" {
+		t.Errorf("AppendToSection() added %q", added)
+	}
+
+	content, _ := manager.Read()
+	want := "### START: Go\n*.exe\n*.test\n# More Go\nvendor/\n### END: Go\n\n### START: Node\nnode_modules/\n### END: Node\n"
+	if content != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+
+	// Nothing new leaves the file alone
+	if added, err := manager.AppendToSection("Go", "*.exe\n"); err != nil || len(added) != 0 {
+		t.Errorf("AppendToSection() = %q, %v, want nothing added", added, err)
+	}
+
+	if _, err := manager.AppendToSection("Rust", "target/\n"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("AppendToSection() of a missing section error = %v, want ErrSectionNotFound", err)
+	}
+}
+
+func TestMoveSection(t *testing.T) {
+	initial := `# unmanaged header
+### START: Go
+*.exe
+### END: Go
+
+### START: Global/macOS
+.DS_Store
+### END: Global/macOS
+
+### START: Node
+node_modules/
+### END: Node
+`
+
+	tests := []struct {
+		name     string
+		section  string
+		position int
+		want     []string
+	}{
+		{"to end", "Global/macOS", 2, []string{"Go", "Node", "Global/macOS"}},
+		{"to start", "Node", 0, []string{"Node", "Go", "Global/macOS"}},
+		{"to middle", "Go", 1, []string{"Global/macOS", "Go", "Node"}},
+		{"same position", "Go", 0, []string{"Go", "Global/macOS", "Node"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte(initial), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			manager := NewManager(tmpDir)
+			if err := manager.MoveSection(tt.section, tt.position); err != nil {
+				t.Fatalf("MoveSection() error = %v", err)
+			}
+
+			sections, _ := manager.ListSections()
+			if strings.Join(sections, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("sections = %v, want %v", sections, tt.want)
+			}
+
+			result, _ := manager.Read()
+			if !strings.HasPrefix(result, "# unmanaged header\n### START: ") {
+				t.Errorf("unmanaged content moved:\n%s", result)
+			}
+			if strings.Contains(result, "\n\n\n") || !strings.HasSuffix(result, "### END: "+tt.want[2]+"\n") {
+				t.Errorf("unexpected spacing:\n%s", result)
+			}
+			if strings.Count(result, "\n\n") != 2 {
+				t.Errorf("expected sections separated by single blank lines:\n%s", result)
+			}
+			body, _ := manager.GetSection(tt.section)
+			if body == "" {
+				t.Errorf("section %s lost its content", tt.section)
+			}
+		})
+	}
+}
+
+func TestAddAt(t *testing.T) {
+	tmpDir := t.TempDir()
+	initial := `# unmanaged header
+### START: Go
+*.exe
+### END: Go
+
+### START: Node
+node_modules/
+### END: Node
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte(initial), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	if err := manager.AddAt("Rust", "target/", 1); err != nil {
+		t.Fatalf("AddAt() error = %v", err)
+	}
+	if err := manager.AddAt("Python", "__pycache__/", 3); err != nil {
+		t.Fatalf("AddAt() error = %v", err)
+	}
+
+	expected := `# unmanaged header
+### START: Go
+*.exe
+### END: Go
+
+### START: Rust
+target/
+### END: Rust
+
+### START: Node
+node_modules/
+### END: Node
+
+### START: Python
+__pycache__/
+### END: Python
+`
+	result, _ := manager.Read()
+	if result != expected {
+		t.Errorf("AddAt() result =\n%s\nwant\n%s", result, expected)
+	}
+
+	if err := manager.AddAt("Go", "*.exe", 0); err == nil {
+		t.Error("AddAt() of an existing section should fail")
+	}
+	if err := manager.AddAt("Java", "*.class", 9); err == nil {
+		t.Error("AddAt() past the end should fail")
+	}
+}
+
+func TestMoveSectionErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	if err := manager.Add("Go", "*.exe"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if err := manager.MoveSection("Missing", 0); err == nil {
+		t.Error("MoveSection() expected error for missing section")
+	}
+	if err := manager.MoveSection("Go", 1); err == nil {
+		t.Error("MoveSection() expected error for out-of-range position")
+	}
+	if err := manager.MoveSection("Go", -1); err == nil {
+		t.Error("MoveSection() expected error for negative position")
+	}
+}
+
+func TestBackupBeforeWrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	manager.SetBackup(true)
+
+	// Creating the file has nothing to back up
+	if err := manager.Add("Go", "*.exe\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := os.Stat(manager.BackupPath()); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup for a new file, got err = %v", err)
+	}
+
+	before, _ := manager.Read()
+	if err := manager.Add("Node", "node_modules/\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	backup, err := os.ReadFile(manager.BackupPath())
+	if err != nil {
+		t.Fatalf("expected backup: %v", err)
+	}
+	if string(backup) != before {
+		t.Errorf("backup = %q, want %q", backup, before)
+	}
+
+	// Restore brings back the content from before the last change
+	if err := manager.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got, _ := manager.Read(); got != before {
+		t.Errorf("after Restore() content = %q, want %q", got, before)
+	}
+}
+
+func TestBackupSkipsUnchangedContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	manager.SetBackup(true)
+
+	if err := manager.Add("Go", "*.exe\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	content, _ := manager.Read()
+	if err := manager.write(content); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if _, err := os.Stat(manager.BackupPath()); !os.IsNotExist(err) {
+		t.Errorf("expected no backup when content is unchanged, got err = %v", err)
+	}
+}
+
+func TestBackupDisabledByDefault(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+
+	if err := manager.Add("Go", "*.exe\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := manager.Delete("Go"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(manager.BackupPath()); !os.IsNotExist(err) {
+		t.Errorf("expected no backup unless enabled, got err = %v", err)
+	}
+	if err := manager.Restore(); err == nil {
+		t.Error("Restore() should fail without a backup")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	if err := manager.Add("Go", "*.exe\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	before, _ := os.ReadFile(manager.Path())
+
+	manager.SetDryRun(true)
+	if diff, _ := manager.Preview(); diff != "" {
+		t.Errorf("Preview() before any change = %q, want empty", diff)
+	}
+	if err := manager.Add("Node", "node_modules/\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, _, err := manager.AddPatterns([]string{"*.log"}); err != nil {
+		t.Fatalf("AddPatterns() error = %v", err)
+	}
+
+	after, _ := os.ReadFile(manager.Path())
+	if string(after) != string(before) {
+		t.Errorf("dry run changed the file: %q", after)
+	}
+	if ok, _ := manager.HasSection("Node"); !ok {
+		t.Error("expected later reads to see the pending section")
+	}
+
+	diff, err := manager.Preview()
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	for _, want := range []string{"+++ .gitignore (dry run)", "+node_modules/", "+*.log"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Preview() missing %q in:\n%s", want, diff)
+		}
+	}
+}
+
+func TestCustomMarkers(t *testing.T) {
+	markers, err := NewMarkers("# >>>", "# <<<")
+	if err != nil {
+		t.Fatalf("NewMarkers() error = %v", err)
+	}
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	manager.SetMarkers(markers)
+
+	if err := manager.Add("Go", "*.exe\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := manager.Add("Node", "node_modules/\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	content, _ := manager.Read()
+	if !strings.HasPrefix(content, "# >>> Go\n*.exe\n# <<< Go\n") {
+		t.Errorf("content = %q, want custom markers", content)
+	}
+	if ok, _ := manager.HasSection("Node"); !ok {
+		t.Error("HasSection() = false, want true")
+	}
+	if sections, _ := manager.ListSections(); strings.Join(sections, "|") != "Go|Node" {
+		t.Errorf("ListSections() = %q", sections)
+	}
+	if err := manager.Delete("Go"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if content, _ := manager.Read(); strings.Contains(content, "Go") {
+		t.Errorf("Delete() left %q", content)
+	}
+
+	// Sections written with other markers are not recognized
+	if ok, _ := NewManager(tmpDir).HasSection("Node"); ok {
+		t.Error("default markers should not match custom sections")
+	}
+}
+
+func TestForeignSections(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	content := `# Created by https://www.toptal.com/developers/gitignore/api/go,node
+# Edit at https://www.toptal.com/developers/gitignore?templates=go,node
+
+### Go ###
+*.exe
+
+# End of https://www.toptal.com/developers/gitignore/api/go,node
+
+### START: Rust
+# Created by https://www.toptal.com/developers/gitignore/api/rust
+target/
+# End of https://www.toptal.com/developers/gitignore/api/rust
+### END: Rust
+
+# Created by https://example.com/without/end
+*.log
+`
+	if err := os.WriteFile(manager.Path(), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write .gitignore: %v", err)
+	}
+
+	// Blocks inside managed sections, and unterminated ones, are not sections
+	sections, err := manager.ListSections()
+	if err != nil {
+		t.Fatalf("ListSections() error = %v", err)
+	}
+	if strings.Join(sections, "|") != "go,node|Rust" {
+		t.Errorf("ListSections() = %q, want [go,node Rust]", sections)
+	}
+	if ok, _ := manager.HasSection("go,node"); !ok {
+		t.Error("HasSection(go,node) = false, want true")
+	}
+	if ok, _ := manager.HasSection("rust"); ok {
+		t.Error("HasSection(rust) = true for a block inside a managed section")
+	}
+
+	if err := manager.Delete("go,node"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	got, _ := manager.Read()
+	if strings.Contains(got, "go,node") || strings.Contains(got, "*.exe") {
+		t.Errorf("Delete() left the gitignore.io block: %q", got)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(got), "### START: Rust\n") || !strings.Contains(got, "*.log") {
+		t.Errorf("Delete() removed too much: %q", got)
+	}
+}
+
+func TestNewMarkers(t *testing.T) {
+	if mk, err := NewMarkers("", " # <<< "); err != nil || mk.Start != SectionStartPrefix || mk.End != "# <<<" {
+		t.Errorf("NewMarkers() = %+v, %v, want the default start and a trimmed end", mk, err)
+	}
+	for _, tt := range [][2]string{{">>>", "<<<"}, {"# >>>", "# >>> end"}, {"#", "# END"}} {
+		if _, err := NewMarkers(tt[0], tt[1]); err == nil {
+			t.Errorf("NewMarkers(%q, %q) should fail", tt[0], tt[1])
+		}
+	}
+}
+
+func TestMatchAndDeleteSections(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+
+	for _, name := range []string{"Global/macOS", "Go", "global/Linux"} {
+		if err := manager.Add(name, "pattern-"+name+"\n"); err != nil {
+			t.Fatalf("Add(%s) error = %v", name, err)
+		}
+	}
+
+	names, err := manager.MatchSections("Global/*")
+	if err != nil {
+		t.Fatalf("MatchSections() error = %v", err)
+	}
+	if len(names) != 2 || names[0] != "Global/macOS" || names[1] != "global/Linux" {
+		t.Fatalf("MatchSections() = %v, want [Global/macOS global/Linux]", names)
+	}
+	if _, err := manager.MatchSections("["); err == nil {
+		t.Error("MatchSections() should reject an invalid pattern")
+	}
+
+	if err := manager.DeleteSections(append(names, "Missing")); !errors.Is(err, ErrSectionNotFound) {
+		t.Fatalf("DeleteSections() with a missing name error = %v, want ErrSectionNotFound", err)
+	}
+	if sections, _ := manager.ListSections(); len(sections) != 3 {
+		t.Fatalf("failed DeleteSections() should not change the file, sections = %v", sections)
+	}
+
+	if err := manager.DeleteSections(names); err != nil {
+		t.Fatalf("DeleteSections() error = %v", err)
+	}
+	sections, err := manager.ListSections()
+	if err != nil {
+		t.Fatalf("ListSections() error = %v", err)
+	}
+	if len(sections) != 1 || sections[0] != "Go" {
+		t.Errorf("sections after DeleteSections() = %v, want [Go]", sections)
+	}
+}
+
+func TestFindExcludeFile(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git: %v", err)
+	}
+	nested := filepath.Join(repo, "src", "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("failed to create nested dir: %v", err)
+	}
+
+	path, err := FindExcludeFile(nested)
+	if err != nil {
+		t.Fatalf("FindExcludeFile() error = %v", err)
+	}
+	if want := filepath.Join(repo, ".git", "info", "exclude"); path != want {
+		t.Errorf("FindExcludeFile() = %q, want %q", path, want)
+	}
+
+	// The info directory is created on first write
+	manager := NewManagerWithPath(path)
+	if err := manager.Add("ignored/.idea/", ".idea/\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected exclude file to be created: %v", err)
+	}
+
+	if _, err := FindExcludeFile(t.TempDir()); err == nil {
+		t.Error("FindExcludeFile() should fail outside a git repository")
+	}
+}
+
+func TestFindExcludeFileWorktree(t *testing.T) {
+	// A worktree's .git file points into the main repository, whose
+	// commondir holds the shared info/exclude and config
+	main := t.TempDir()
+	worktreeGitDir := filepath.Join(main, ".git", "worktrees", "feature")
+	if err := os.MkdirAll(worktreeGitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktreeGitDir, "commondir"), []byte("../..\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := "[remote \"origin\"]\n\turl = https://github.com/acme/main.git\n"
+	if err := os.WriteFile(filepath.Join(main, ".git", "config"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The worktree is nested in another repository, which must not be used
+	outer := t.TempDir()
+	if err := os.Mkdir(filepath.Join(outer, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	worktree := filepath.Join(outer, "feature")
+	if err := os.MkdirAll(filepath.Join(worktree, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+worktreeGitDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := FindExcludeFile(filepath.Join(worktree, "src"))
+	if err != nil {
+		t.Fatalf("FindExcludeFile() error = %v", err)
+	}
+	if want := filepath.Join(main, ".git", "info", "exclude"); path != want {
+		t.Errorf("FindExcludeFile() = %q, want %q", path, want)
+	}
+	if got, err := FindOriginURL(worktree); err != nil || got != "https://github.com/acme/main.git" {
+		t.Errorf("FindOriginURL() = %q, %v", got, err)
+	}
+	if top, err := FindWorkTree(filepath.Join(worktree, "src")); err != nil || top != worktree {
+		t.Errorf("FindWorkTree() = %q, %v; want %q", top, err, worktree)
+	}
+}
+
+func TestFindExcludeFileSubmodule(t *testing.T) {
+	// A submodule's .git file names its git directory relative to itself
+	parent := t.TempDir()
+	moduleGitDir := filepath.Join(parent, ".git", "modules", "lib")
+	if err := os.MkdirAll(moduleGitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	submodule := filepath.Join(parent, "lib")
+	if err := os.Mkdir(submodule, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(submodule, ".git"), []byte("gitdir: ../.git/modules/lib\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := FindExcludeFile(submodule)
+	if err != nil {
+		t.Fatalf("FindExcludeFile() error = %v", err)
+	}
+	if want := filepath.Join(moduleGitDir, "info", "exclude"); path != want {
+		t.Errorf("FindExcludeFile() = %q, want %q", path, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(submodule, ".git"), []byte("garbage\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FindExcludeFile(submodule); err == nil {
+		t.Error("FindExcludeFile() should fail for a .git file without gitdir:")
+	}
+}
+
+func TestFindOriginURL(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git: %v", err)
+	}
+	config := "[core]\n\tbare = false\n[remote \"upstream\"]\n\turl = https://github.com/other/api.git\n[remote \"origin\"]\n\turl = git@github.com:acme/api.git\n\tfetch = +refs/heads/*:refs/remotes/origin/*\n"
+	if err := os.WriteFile(filepath.Join(repo, ".git", "config"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(repo, "src")
+	if err := os.Mkdir(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := FindOriginURL(nested); err != nil || got != "git@github.com:acme/api.git" {
+		t.Errorf("FindOriginURL() = %q, %v", got, err)
+	}
+	if got, err := FindOriginURL(t.TempDir()); err != nil || got != "" {
+		t.Errorf("FindOriginURL() outside a repository = %q, %v; want none", got, err)
+	}
+}
+
+func TestTidy(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+
+	messy := "\n\n# hand-written  \nbuild/\t\n### START: Go\n*.exe   \n\n\n*.test\n### END: Go\n### START: Node\nnode_modules/\nkeep\\ \n### END: Node\n\n\n\n"
+	if err := os.WriteFile(manager.Path(), []byte(messy), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	changed, err := manager.Tidy()
+	if err != nil {
+		t.Fatalf("Tidy() error = %v", err)
+	}
+	if !changed {
+		t.Error("Tidy() should report a change")
+	}
+
+	want := "# hand-written\nbuild/\n\n### START: Go\n*.exe\n\n*.test\n### END: Go\n\n### START: Node\nnode_modules/\nkeep\\ \n### END: Node\n"
+	got, _ := manager.Read()
+	if got != want {
+		t.Errorf("Tidy() content =\n%q\nwant\n%q", got, want)
+	}
+
+	// Tidying again is a no-op
+	if changed, err := manager.Tidy(); err != nil || changed {
+		t.Errorf("second Tidy() = %v, %v; want no change", changed, err)
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tmpDir := t.TempDir()
+	gitignorePath := filepath.Join(tmpDir, ".gitignore")
+
+	initial := `# project files
+*.log
+.env
+
+### START: Go
+# Binaries
+*.exe
+*.test   
+### END: Go
+
+### START: Node
+node_modules/
+*.log
+### END: Node
+
+dist/
+### START: ignored/.env
+.env
+### END: ignored/.env
+`
+	if err := os.WriteFile(gitignorePath, []byte(initial), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	patterns, err := manager.Patterns()
+	if err != nil {
+		t.Fatalf("Patterns() error = %v", err)
+	}
+
+	expected := []string{"*.log", ".env", "*.exe", "*.test", "node_modules/", "dist/"}
+	if strings.Join(patterns, ",") != strings.Join(expected, ",") {
+		t.Errorf("Patterns() = %q, want %q", patterns, expected)
+	}
+}
+
+func TestContainsPattern(t *testing.T) {
+	tmpDir := t.TempDir()
+	initial := "# build output\n# secrets.txt\n\n### START: Go\n  *.exe  \n### END: Go\ndist/\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte(initial), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	tests := map[string]bool{
+		"*.exe":       true,
+		"dist/":       true,
+		"./dist/":     true,
+		"dist":        false, // dist/ matches directories only
+		"/dist/":      false, // anchored
+		"secrets.txt": false, // only in a comment
+		"*.log":       false,
+	}
+	for pattern, want := range tests {
+		got, err := manager.ContainsPattern(pattern)
+		if err != nil {
+			t.Fatalf("ContainsPattern(%q) error = %v", pattern, err)
+		}
+		if got != want {
+			t.Errorf("ContainsPattern(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+
+	if _, err := manager.ContainsPattern("  "); err == nil {
+		t.Error("ContainsPattern() should error for an empty pattern")
+	}
+}
+
+func TestResolveSection(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	for _, name := range []string{"Global/macOS", "Go", "go", "rust"} {
+		if err := manager.Add(name, "x\n"); err != nil {
+			t.Fatalf("Add(%s) error = %v", name, err)
+		}
+	}
+
+	tests := map[string]string{
+		"Global/macOS": "Global/macOS",
+		"global/macos": "Global/macOS",
+		"go":           "go", // an exact match wins over other casings
+		"RUST":         "rust",
+	}
+	for name, want := range tests {
+		got, err := manager.ResolveSection(name)
+		if err != nil || got != want {
+			t.Errorf("ResolveSection(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	if _, err := manager.ResolveSection("GO"); err == nil || errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("ResolveSection(GO) error = %v, want an ambiguity error", err)
+	}
+	if _, err := manager.ResolveSection("python"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("ResolveSection(python) error = %v, want ErrSectionNotFound", err)
+	}
+}
+
+func TestPatternLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	initial := "*.log\n!keep.log\n\n### START: Logs\n# all logs\n*.log\n### END: Logs\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte(initial), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	manager := NewManager(tmpDir)
+	lines, err := manager.PatternLines()
+	if err != nil {
+		t.Fatalf("PatternLines() error = %v", err)
+	}
+	expected := []string{"*.log", "!keep.log", "*.log"}
+	if strings.Join(lines, ",") != strings.Join(expected, ",") {
+		t.Errorf("PatternLines() = %q, want %q", lines, expected)
+	}
+
+	// The later *.log ignores keep.log again
+	if result := CheckPath(lines, "keep.log", false); !result.Ignored {
+		t.Errorf("CheckPath(keep.log) = %+v, want ignored", result)
+	}
+}
+
+func TestPatternsMissingFile(t *testing.T) {
+	manager := NewManager(t.TempDir())
+	patterns, err := manager.Patterns()
+	if err != nil {
+		t.Fatalf("Patterns() error = %v", err)
+	}
+	if len(patterns) != 0 {
+		t.Errorf("Patterns() = %q, want none", patterns)
+	}
+}
+
+func TestStats(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `# Project ignores
+.env
+*.log
+
+### START: Go
+# Binaries
+*.exe
+*.log
+### END: Go
+
+### START: Node
+node_modules/
+*.exe
+### END: Node
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	stats, err := NewManager(tmpDir).Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	want := Stats{Sections: 2, Patterns: 6, AdHoc: 2, Duplicates: 2, Comments: 2}
+	if stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+
+	empty, err := NewManager(t.TempDir()).Stats()
+	if err != nil || empty != (Stats{}) {
+		t.Errorf("Stats() of a missing file = %+v, %v; want zero counts", empty, err)
+	}
+}
+
+func TestWithDedupe(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, DefaultFilename), []byte("*.log\n\n### START: Go\n*.exe\n!keep.exe\n### END: Go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := "# Logs\n*.log\n*.tmp\n*.tmp\n!keep.exe\n*.exe  \n"
+
+	manager := NewManager(dir, WithDedupe(true))
+	dups, err := manager.DuplicatePatterns(content)
+	if err != nil {
+		t.Fatalf("DuplicatePatterns() error = %v", err)
+	}
+	if strings.Join(dups, ",") != "*.log,*.tmp,*.exe" {
+		t.Errorf("DuplicatePatterns() = %q", dups)
+	}
+
+	if err := manager.AddAt("Tmp", content, 0); err != nil {
+		t.Fatalf("AddAt() error = %v", err)
+	}
+	got, err := manager.GetSection("Tmp")
+	if err != nil {
+		t.Fatalf("GetSection() error = %v", err)
+	}
+	if want := "# Logs\n*.tmp\n!keep.exe"; strings.TrimSpace(got) != want {
+		t.Errorf("deduped section = %q, want %q", got, want)
+	}
+
+	// Without the option, sections are added as given
+	if err := NewManager(dir).Add("Logs", "*.log\n"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if got, _ := NewManager(dir).GetSection("Logs"); strings.TrimSpace(got) != "*.log" {
+		t.Errorf("section without dedupe = %q", got)
+	}
+}
+
+func TestWriteSymlink(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "shared.gitignore")
+	if err := os.WriteFile(outside, []byte("*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo := t.TempDir()
+	link := filepath.Join(repo, DefaultFilename)
+	if err := os.Symlink(outside, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	// By default the link is read but never written through
+	manager := NewManager(repo)
+	if patterns, err := manager.Patterns(); err != nil || len(patterns) != 1 {
+		t.Errorf("Patterns() through the link = %v, %v", patterns, err)
+	}
+	if err := manager.Add("Go", "*.exe\n"); !errors.Is(err, ErrSymlink) {
+		t.Fatalf("Add() error = %v, want ErrSymlink", err)
+	}
+	if data, _ := os.ReadFile(outside); string(data) != "*.log\n" {
+		t.Errorf("target changed despite the refusal:\n%s", data)
+	}
+
+	// Following it writes the target and keeps the link
+	manager = NewManager(repo, WithFollowSymlinks(true))
+	if err := manager.Add("Go", "*.exe\n"); err != nil {
+		t.Fatalf("Add() with WithFollowSymlinks error = %v", err)
+	}
+	if data, _ := os.ReadFile(outside); !strings.Contains(string(data), "### START: Go") {
+		t.Errorf("target after Add() =\n%s", data)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("%s is no longer a symlink", link)
+	}
+}
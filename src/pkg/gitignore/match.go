@@ -0,0 +1,139 @@
+package gitignore
+
+import (
+	"path"
+	"strings"
+)
+
+// CheckResult is the outcome of matching a path against gitignore patterns
+type CheckResult struct {
+	Ignored bool
+	Pattern string // deciding pattern as written, or "" when none matched
+	Parent  string // ignored parent directory that decided the result, if any
+}
+
+// CheckPath reports whether a path, relative to the directory holding the
+// patterns and using forward slashes, is ignored by patterns (in file order)
+// It follows gitignore semantics: the last matching pattern wins, "!"
+// re-includes, a trailing "/" matches only directories, a leading or inner
+// "/" anchors the pattern, and "**" matches any number of directories
+// As in git, a path inside an ignored directory can't be re-included
+func CheckPath(patterns []string, name string, isDir bool) CheckResult {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return CheckResult{}
+	}
+
+	compiled := compilePatterns(patterns)
+
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		parent := strings.Join(parts[:i], "/")
+		if result := checkPath(compiled, parts[:i], true); result.Ignored {
+			result.Parent = parent
+			return result
+		}
+	}
+	return checkPath(compiled, parts, isDir)
+}
+
+// compilePatterns parses pattern lines, dropping blank lines and comments
+func compilePatterns(patterns []string) []pattern {
+	compiled := make([]pattern, 0, len(patterns))
+	for _, p := range patterns {
+		if c, ok := compilePattern(p); ok {
+			compiled = append(compiled, c)
+		}
+	}
+	return compiled
+}
+
+// checkPath matches a single path, given as its segments, without looking
+// at its parent directories
+func checkPath(patterns []pattern, parts []string, isDir bool) CheckResult {
+	var result CheckResult
+	for _, p := range patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(p.segments, parts) {
+			result = CheckResult{Ignored: !p.negate, Pattern: p.text}
+		}
+	}
+	return result
+}
+
+// pattern is a parsed gitignore pattern line
+type pattern struct {
+	text     string   // the line as written
+	negate   bool     // leading "!"
+	dirOnly  bool     // trailing "/"
+	segments []string // glob segments, with "**" for any number of directories
+}
+
+// compilePattern parses a pattern line, reporting false for blank lines and
+// comments
+func compilePattern(line string) (pattern, bool) {
+	text := trimTrailingSpace(line)
+	p := pattern{text: text}
+	if text == "" || strings.HasPrefix(text, "#") {
+		return p, false
+	}
+
+	switch {
+	case strings.HasPrefix(text, "!"):
+		p.negate = true
+		text = text[1:]
+	case strings.HasPrefix(text, `\!`), strings.HasPrefix(text, `\#`):
+		text = text[1:]
+	}
+
+	if strings.HasSuffix(text, "/") {
+		p.dirOnly = true
+		text = strings.TrimRight(text, "/")
+	}
+	if text == "" {
+		return p, false
+	}
+
+	// A pattern without a slash matches at any depth; otherwise it is
+	// relative to the directory holding the patterns
+	anchored := strings.Contains(text, "/")
+	text = strings.TrimPrefix(text, "/")
+	if !anchored {
+		p.segments = append(p.segments, "**")
+	}
+	for _, seg := range strings.Split(text, "/") {
+		if seg == "" {
+			continue
+		}
+		// git spells a negated character class [!...], path.Match [^...]
+		p.segments = append(p.segments, strings.ReplaceAll(seg, "[!", "[^"))
+	}
+	return p, true
+}
+
+// matchSegments reports whether path segments match glob segments, where a
+// "**" segment matches zero or more path segments; a trailing "**" needs at
+// least one, so "dir/**" matches what is inside dir but not dir itself
+func matchSegments(globs, parts []string) bool {
+	if len(globs) == 0 {
+		return len(parts) == 0
+	}
+	if globs[0] == "**" {
+		if len(globs) == 1 {
+			return len(parts) > 0
+		}
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(globs[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	ok, err := path.Match(globs[0], parts[0])
+	return err == nil && ok && matchSegments(globs[1:], parts[1:])
+}
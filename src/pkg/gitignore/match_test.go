@@ -0,0 +1,79 @@
+package gitignore
+
+import "testing"
+
+func TestCheckPath(t *testing.T) {
+	patterns := []string{
+		"# comment",
+		"*.o",
+		"!keep.o",
+		"build/",
+		"/TODO",
+		"docs/*.html",
+		"**/logs/*.log",
+		"vendor/**",
+		"a/**/z",
+		`\!bang`,
+		"tmp[!0-9]",
+	}
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+		pattern string
+	}{
+		{"main.o", false, true, "*.o"},
+		{"src/deep/main.o", false, true, "*.o"},
+		{"keep.o", false, false, "!keep.o"},
+		{"src/keep.o", false, false, "!keep.o"},
+		{"main.go", false, false, ""},
+
+		// Directory-only patterns match the directory and everything in it
+		{"build", true, true, "build/"},
+		{"build", false, false, ""},
+		{"build/output.o", false, true, "build/"},
+		{"src/build/x.txt", false, true, "build/"},
+		// A file in an ignored directory can't be re-included
+		{"build/keep.o", false, true, "build/"},
+
+		// Anchored patterns only match from the root
+		{"TODO", false, true, "/TODO"},
+		{"src/TODO", false, false, ""},
+		{"docs/index.html", false, true, "docs/*.html"},
+		{"docs/api/index.html", false, false, ""},
+		{"src/docs/index.html", false, false, ""},
+
+		// ** matches any number of directories
+		{"logs/app.log", false, true, "**/logs/*.log"},
+		{"a/b/logs/app.log", false, true, "**/logs/*.log"},
+		{"vendor/lib/x.go", false, true, "vendor/**"},
+		{"vendor", true, false, ""},
+		{"a/z", false, true, "a/**/z"},
+		{"a/b/c/z", false, true, "a/**/z"},
+
+		{"!bang", false, true, `\!bang`},
+		{"tmpx", false, true, "tmp[!0-9]"},
+		{"tmp1", false, false, ""},
+	}
+
+	for _, tt := range tests {
+		got := CheckPath(patterns, tt.path, tt.isDir)
+		if got.Ignored != tt.ignored || got.Pattern != tt.pattern {
+			t.Errorf("CheckPath(%q, dir=%v) = {ignored %v, pattern %q}, want {ignored %v, pattern %q}",
+				tt.path, tt.isDir, got.Ignored, got.Pattern, tt.ignored, tt.pattern)
+		}
+	}
+}
+
+func TestCheckPathParent(t *testing.T) {
+	got := CheckPath([]string{"build/"}, "build/sub/output.o", false)
+	if !got.Ignored || got.Parent != "build" {
+		t.Errorf("CheckPath() = %+v, want ignored via parent 'build'", got)
+	}
+
+	got = CheckPath([]string{"*.o"}, "./src/../main.o", false)
+	if !got.Ignored || got.Parent != "" {
+		t.Errorf("CheckPath() of unclean path = %+v, want ignored directly", got)
+	}
+}
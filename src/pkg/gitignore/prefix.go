@@ -0,0 +1,75 @@
+package gitignore
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CleanPrefix normalizes a directory prefix for PrefixContent, e.g.
+// "./frontend/" to "frontend"
+// Globs are kept, so "packages/*" scopes patterns to every package; an
+// empty prefix, a backslash or a "." or ".." element is an error
+func CleanPrefix(prefix string) (string, error) {
+	cleaned := strings.TrimSpace(prefix)
+	cleaned = strings.TrimPrefix(cleaned, "./")
+	cleaned = strings.Trim(cleaned, "/")
+	if cleaned == "" {
+		return "", fmt.Errorf("invalid prefix '%s': must name a directory", prefix)
+	}
+	if strings.Contains(cleaned, `\`) {
+		return "", fmt.Errorf("invalid prefix '%s': must not contain a backslash", prefix)
+	}
+	for _, elem := range strings.Split(cleaned, "/") {
+		if elem == "" || elem == "." || elem == ".." {
+			return "", fmt.Errorf("invalid prefix '%s': must be a relative path without '.' or '..'", prefix)
+		}
+	}
+	return cleaned, nil
+}
+
+// PrefixContent rewrites the patterns of content so they only apply under
+// the directory prefix, relative to the .gitignore holding them; comments
+// and blank lines are kept as they are
+// A pattern without a slash matches at any depth, so "*.log" becomes
+// "/prefix/**/*.log"; one with a leading or inner slash is relative to the
+// file, so "/build" and "docs/_build" become "/prefix/build" and
+// "/prefix/docs/_build"
+// Negations are rewritten the same way behind their "!", so they
+// re-include only under prefix what the rewritten patterns exclude there
+func PrefixContent(content, prefix string) (string, error) {
+	prefix, err := CleanPrefix(prefix)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = prefixPattern(line, prefix)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// prefixPattern implements PrefixContent for one line
+func prefixPattern(line, prefix string) string {
+	text := trimTrailingSpace(line)
+	if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+		return line
+	}
+
+	negate := ""
+	switch {
+	case strings.HasPrefix(text, "!"):
+		negate, text = "!", text[1:]
+	case strings.HasPrefix(text, `\!`), strings.HasPrefix(text, `\#`):
+		// Only special at the start of a line, which they no longer are
+		text = text[1:]
+	}
+
+	body := strings.TrimRight(text, "/")
+	if body == "" {
+		return line
+	}
+	if strings.Contains(body, "/") {
+		return negate + "/" + prefix + "/" + strings.TrimPrefix(text, "/")
+	}
+	return negate + "/" + prefix + "/**/" + text
+}
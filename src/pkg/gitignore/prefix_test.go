@@ -0,0 +1,72 @@
+package gitignore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCleanPrefix(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{"frontend/", "frontend", false},
+		{"./apps/web", "apps/web", false},
+		{"/packages/*/", "packages/*", false},
+		{"", "", true},
+		{"/", "", true},
+		{"../other", "", true},
+		{"apps//web", "", true},
+		{`apps\web`, "", true},
+	}
+	for _, tt := range tests {
+		got, err := CleanPrefix(tt.prefix)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("CleanPrefix(%q) = %q, %v; want %q, error %v", tt.prefix, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPrefixContent(t *testing.T) {
+	content := "# Logs\n*.log\n\n/build\ndocs/_build/\n**/node_modules/\ndist/**\n!keep.log\n!/build/keep\n\\#notes\n\\!important\n"
+	want := "# Logs\n/frontend/**/*.log\n\n/frontend/build\n/frontend/docs/_build/\n/frontend/**/node_modules/\n/frontend/dist/**\n" +
+		"!/frontend/**/keep.log\n!/frontend/build/keep\n/frontend/**/#notes\n/frontend/**/!important\n"
+
+	got, err := PrefixContent(content, "frontend/")
+	if err != nil {
+		t.Fatalf("PrefixContent() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("PrefixContent() =\n%s\nwant\n%s", got, want)
+	}
+	if _, err := PrefixContent(content, "../x"); err == nil {
+		t.Error("PrefixContent() should reject a prefix with '..'")
+	}
+
+	// The rewritten patterns only apply under the prefix
+	patterns := strings.Split(got, "\n")
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"frontend/debug.log", false, true},
+		{"frontend/src/debug.log", false, true},
+		{"debug.log", false, false},
+		{"backend/debug.log", false, false},
+		{"frontend/keep.log", false, false},
+		{"frontend/build", true, true},
+		{"frontend/src/build", true, false},
+		{"build", true, false},
+		{"frontend/docs/_build", true, true},
+		{"frontend/a/node_modules", true, true},
+		{"node_modules", true, false},
+		{"frontend/#notes", false, true},
+	}
+	for _, tt := range tests {
+		if got := CheckPath(patterns, tt.path, tt.isDir); got.Ignored != tt.ignored {
+			t.Errorf("CheckPath(%q) ignored = %v, want %v (pattern %q)", tt.path, got.Ignored, tt.ignored, got.Pattern)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package logging provides a leveled diagnostic logger that writes to stderr
+package logging
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Level controls how much diagnostic output is written
+type Level int
+
+const (
+	// LevelQuiet writes no diagnostics (the default)
+	LevelQuiet Level = iota
+	// LevelVerbose writes HTTP requests and source resolution results
+	LevelVerbose
+	// LevelDebug additionally writes every resolution step
+	LevelDebug
+)
+
+var (
+	mu     sync.Mutex
+	level            = LevelQuiet
+	output io.Writer = os.Stderr
+)
+
+// SetLevel sets the current log level
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// GetLevel returns the current log level
+func GetLevel() Level {
+	mu.Lock()
+	defer mu.Unlock()
+	return level
+}
+
+// SetOutput sets the destination for log output
+// A nil writer restores the default of stderr
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	if w == nil {
+		w = os.Stderr
+	}
+	output = w
+}
+
+// Verbosef logs a message at verbose level
+func Verbosef(format string, args ...any) {
+	logf(LevelVerbose, "verbose", format, args...)
+}
+
+// Debugf logs a message at debug level
+func Debugf(format string, args ...any) {
+	logf(LevelDebug, "debug", format, args...)
+}
+
+// Warnf logs a warning, which is written at every level
+func Warnf(format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	fmt.Fprintf(output, "Warning: %s\n", fmt.Sprintf(format, args...))
+}
+
+func logf(l Level, tag, format string, args ...any) {
+	mu.Lock()
+	defer mu.Unlock()
+	if level < l {
+		return
+	}
+	fmt.Fprintf(output, "[%s] %s\n", tag, fmt.Sprintf(format, args...))
+}
+
+// Transport is an http.RoundTripper that logs each request's method, URL,
+// status and duration at verbose level
+type Transport struct {
+	Base http.RoundTripper // nil means http.DefaultTransport
+}
+
+// NewTransport wraps base (or http.DefaultTransport if nil) with request logging
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		Verbosef("%s %s failed after %s: %v", req.Method, req.URL, elapsed, err)
+		return nil, err
+	}
+	Verbosef("%s %s -> %d (%s)", req.Method, req.URL, resp.StatusCode, elapsed)
+	return resp, nil
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureOutput redirects log output for the duration of a test
+func captureOutput(t *testing.T, l Level) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	SetLevel(l)
+	t.Cleanup(func() {
+		SetOutput(nil)
+		SetLevel(LevelQuiet)
+	})
+	return &buf
+}
+
+func TestLevels(t *testing.T) {
+	tests := []struct {
+		level       Level
+		wantVerbose bool
+		wantDebug   bool
+	}{
+		{LevelQuiet, false, false},
+		{LevelVerbose, true, false},
+		{LevelDebug, true, true},
+	}
+
+	for _, tt := range tests {
+		buf := captureOutput(t, tt.level)
+		Verbosef("verbose %d", 1)
+		Debugf("debug %d", 2)
+
+		out := buf.String()
+		if got := strings.Contains(out, "[verbose] verbose 1"); got != tt.wantVerbose {
+			t.Errorf("level %d: verbose output = %v, want %v (%q)", tt.level, got, tt.wantVerbose, out)
+		}
+		if got := strings.Contains(out, "[debug] debug 2"); got != tt.wantDebug {
+			t.Errorf("level %d: debug output = %v, want %v (%q)", tt.level, got, tt.wantDebug, out)
+		}
+	}
+}
+
+func TestWarnfAlwaysWrites(t *testing.T) {
+	buf := captureOutput(t, LevelQuiet)
+	Warnf("unknown source '%s'", "bitbucket")
+	if got := buf.String(); got != "Warning: unknown source 'bitbucket'\n" {
+		t.Errorf("Warnf() wrote %q", got)
+	}
+}
+
+func TestTransportLogsRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		fmt.Fprint(w, "short and stout")
+	}))
+	defer server.Close()
+
+	buf := captureOutput(t, LevelVerbose)
+	client := &http.Client{Transport: NewTransport(nil)}
+	resp, err := client.Get(server.URL + "/pot")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	resp.Body.Close()
+
+	out := buf.String()
+	if !strings.Contains(out, "GET "+server.URL+"/pot -> 418") {
+		t.Errorf("expected request to be logged with status, got %q", out)
+	}
+}
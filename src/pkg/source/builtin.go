@@ -0,0 +1,35 @@
+package source
+
+import (
+	"embed"
+	"io/fs"
+	"strings"
+)
+
+// BuiltinSourceName is the name of the templates embedded in the binary
+const BuiltinSourceName = "builtin"
+
+// builtinTemplates is a snapshot of the most common GitHub templates, so
+// that add and list still work when no other source can be reached
+//
+//go:embed builtin
+var builtinTemplates embed.FS
+
+// NewBuiltinSource returns a source serving the templates embedded in the
+// binary, laid out like the GitHub repository (e.g. "Go", "Global/macOS")
+func NewBuiltinSource() *MemorySource {
+	templates := make(map[string]string)
+	_ = fs.WalkDir(builtinTemplates, "builtin", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".gitignore") {
+			return err
+		}
+		content, err := builtinTemplates.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "builtin/"), ".gitignore")
+		templates[name] = string(content)
+		return nil
+	})
+	return NewMemorySource(BuiltinSourceName, templates)
+}
@@ -0,0 +1,258 @@
+// Package source provides abstraction for different gitignore template sources
+package source
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheIndexFile holds a cached template listing within a source's cache
+// directory; template content, if cached, is stored under cacheContentDir
+const (
+	cacheIndexFile  = "index.json"
+	cacheContentDir = "templates"
+)
+
+// CacheFetchWorkers bounds how many templates Refresh downloads at once
+const CacheFetchWorkers = 4
+
+// Cache stores template listings, and optionally template content, fetched
+// from remote sources, so they can be used offline (see WithCacheDir)
+// Each source gets its own subdirectory, named after its source name and
+// repository, e.g. "github-github-gitignore" or "toptal"
+type Cache struct {
+	dir string
+}
+
+// cacheIndex is the on-disk form of a cached listing
+type cacheIndex struct {
+	Source    string         `json:"source"`
+	Repo      string         `json:"repo,omitempty"`
+	FetchedAt time.Time      `json:"fetched_at"`
+	Templates []TemplateFile `json:"templates"`
+}
+
+// CacheResult reports what Refresh stored for one source
+type CacheResult struct {
+	Templates int     // templates in the listing
+	Contents  int     // templates whose content was cached
+	Failures  []error // templates whose content could not be fetched
+}
+
+// NewCache creates a cache rooted at dir
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Refresh replaces the cached listing for source with a fresh one and, with
+// full, the content of every listed template
+// The source's own in-memory caches are invalidated first (see Refresher), so
+// the listing is always fetched anew
+// Failing to list the source is an error and leaves the previous cache in
+// place; templates whose content can't be fetched are reported in the
+// result's Failures and are simply not cached
+func (c *Cache) Refresh(source Source, full bool) (CacheResult, error) {
+	Refresh(source)
+	files, err := source.List()
+	if err != nil {
+		return CacheResult{}, err
+	}
+	if err := c.store(source, files); err != nil {
+		return CacheResult{}, err
+	}
+	result := CacheResult{Templates: len(files)}
+	if !full {
+		return result, nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, CacheFetchWorkers)
+	for _, file := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(file TemplateFile) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			name := templateFileName(file)
+			_, content, err := source.Get(name)
+			if err == nil {
+				err = c.storeContent(source, file, content)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failures = append(result.Failures, fmt.Errorf("%s: %w", name, err))
+				return
+			}
+			result.Contents++
+		}(file)
+	}
+	wg.Wait()
+	return result, nil
+}
+
+// Load returns a source serving the cached listing for source, or an error
+// wrapping os.ErrNotExist if nothing has been cached for it
+func (c *Cache) Load(source Source) (*CachedSource, error) {
+	dir := c.sourceDir(source)
+	data, err := os.ReadFile(filepath.Join(dir, cacheIndexFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template cache: %w", err)
+	}
+	var index cacheIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse template cache %s: %w", dir, err)
+	}
+	return &CachedSource{
+		name:      source.Name(),
+		repo:      index.Repo,
+		dir:       dir,
+		files:     index.Templates,
+		fetchedAt: index.FetchedAt,
+	}, nil
+}
+
+// store writes a fresh listing for source, dropping any content cached for
+// the previous one
+func (c *Cache) store(source Source, files []TemplateFile) error {
+	dir := c.sourceDir(source)
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear template cache: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create template cache: %w", err)
+	}
+
+	index := cacheIndex{Source: source.Name(), FetchedAt: time.Now().UTC(), Templates: files}
+	if repo, ok := source.(interface{ Repo() string }); ok {
+		index.Repo = repo.Repo()
+	}
+	if index.Templates == nil {
+		index.Templates = []TemplateFile{}
+	}
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, cacheIndexFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write template cache: %w", err)
+	}
+	return nil
+}
+
+// storeContent writes the content of one cached template
+func (c *Cache) storeContent(source Source, file TemplateFile, content string) error {
+	path := contentPath(c.sourceDir(source), file)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create template cache: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write template cache: %w", err)
+	}
+	return nil
+}
+
+// sourceDir returns the cache subdirectory for source
+func (c *Cache) sourceDir(source Source) string {
+	name := source.Name()
+	if repo, ok := source.(interface{ Repo() string }); ok && repo.Repo() != "" {
+		name += "-" + strings.ReplaceAll(repo.Repo(), "/", "-")
+	}
+	return filepath.Join(c.dir, name)
+}
+
+// contentPath returns where the content of file is cached under dir; the
+// template path is cleaned so it can't point outside the directory
+func contentPath(dir string, file TemplateFile) string {
+	clean := strings.TrimPrefix(path.Clean("/"+file.Path), "/")
+	return filepath.Join(dir, cacheContentDir, filepath.FromSlash(clean))
+}
+
+// templateFileName returns the name that looks file up unambiguously,
+// category/name when it has a category
+func templateFileName(file TemplateFile) string {
+	if file.Category == "" {
+		return file.Name
+	}
+	return file.Category + "/" + file.Name
+}
+
+// CachedSource serves a remote source's templates from the cache while
+// offline; it keeps the remote source's name, so "github/go" still resolves
+// Templates refreshed without content are listed but can't be fetched
+type CachedSource struct {
+	name      string
+	repo      string
+	dir       string
+	files     []TemplateFile
+	fetchedAt time.Time
+}
+
+// Name returns the name of the cached source
+func (s *CachedSource) Name() string {
+	return s.name
+}
+
+// Repo returns the cached source's repository ("owner/repo"), or "" for a
+// source without one
+func (s *CachedSource) Repo() string {
+	return s.repo
+}
+
+// FetchedAt returns when the listing was cached
+func (s *CachedSource) FetchedAt() time.Time {
+	return s.fetchedAt
+}
+
+// List returns the cached templates
+func (s *CachedSource) List() ([]TemplateFile, error) {
+	return append([]TemplateFile(nil), s.files...), nil
+}
+
+// Get returns the cached content of a template by name
+func (s *CachedSource) Get(name string) (*TemplateFile, string, error) {
+	file, err := s.Find(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	content, err := os.ReadFile(contentPath(s.dir, *file))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", fmt.Errorf("%w: %s template '%s' is cached without content (run 'gitignore refresh-cache --full')", ErrOffline, s.name, name)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read template cache: %w", err)
+	}
+	return file, string(content), nil
+}
+
+// Find finds a template by name or category/name (case-insensitive)
+func (s *CachedSource) Find(name string) (*TemplateFile, error) {
+	nameLower := strings.ToLower(name)
+	for _, file := range s.files {
+		if strings.ToLower(file.Name) == nameLower {
+			return &file, nil
+		}
+	}
+	for _, file := range s.files {
+		if file.Category != "" && strings.ToLower(file.Category+"/"+file.Name) == nameLower {
+			return &file, nil
+		}
+	}
+	return nil, fmt.Errorf("%s template '%s' not found in cache", s.name, name)
+}
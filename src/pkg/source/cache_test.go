@@ -0,0 +1,132 @@
+package source
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheRefresh(t *testing.T) {
+	dir := t.TempDir()
+	remote := NewMemorySource("toptal", map[string]string{
+		"rust":         "target/\n",
+		"Global/macOS": ".DS_Store\n",
+	})
+
+	cache := NewCache(dir)
+	result, err := cache.Refresh(remote, true)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if result.Templates != 2 || result.Contents != 2 || len(result.Failures) != 0 {
+		t.Errorf("Refresh() = %+v, want 2 templates with content", result)
+	}
+
+	cached, err := cache.Load(remote)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cached.Name() != "toptal" || cached.FetchedAt().IsZero() {
+		t.Errorf("Load() = %s fetched %v", cached.Name(), cached.FetchedAt())
+	}
+	for name, want := range map[string]string{"rust": "target/\n", "global/macos": ".DS_Store\n"} {
+		_, content, err := cached.Get(name)
+		if err != nil || content != want {
+			t.Errorf("Get(%q) = %q, %v, want %q", name, content, err, want)
+		}
+	}
+
+	// A listing-only refresh drops previously cached content
+	if _, err := cache.Refresh(remote, false); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	cached, _ = cache.Load(remote)
+	if _, err := cached.Find("rust"); err != nil {
+		t.Errorf("Find() error = %v", err)
+	}
+	if _, _, err := cached.Get("rust"); !errors.Is(err, ErrOffline) {
+		t.Errorf("Get() without cached content error = %v, want ErrOffline", err)
+	}
+
+	if _, err := NewCache(t.TempDir()).Load(remote); err == nil {
+		t.Error("Load() of an empty cache should fail")
+	}
+}
+
+func TestSourceManagerOfflineCache(t *testing.T) {
+	dir := t.TempDir()
+	remote := NewMemorySource("toptal", map[string]string{"rust": "target/\n"})
+	if _, err := NewCache(dir).Refresh(remote, true); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	sm, err := NewSourceManager(t.TempDir(), "", true, WithOffline(true), WithCacheDir(dir))
+	if err != nil {
+		t.Fatalf("NewSourceManager() error = %v", err)
+	}
+	if _, ok := sm.RemoteSources()[0].(*CachedSource); !ok {
+		t.Fatalf("remote source is %T, want *CachedSource", sm.RemoteSources()[0])
+	}
+	file, content, err := sm.GetAny("toptal/rust")
+	if err != nil || content != "target/\n" || file.Source != "toptal" {
+		t.Errorf("GetAny() = %+v %q, %v", file, content, err)
+	}
+
+	// Without a cached listing the source is skipped as before
+	sm, err = NewSourceManager(t.TempDir(), "", true, WithOffline(true), WithCacheDir(t.TempDir()))
+	if err != nil {
+		t.Fatalf("NewSourceManager() error = %v", err)
+	}
+	if _, _, err := sm.Get("rust"); !errors.Is(err, ErrOffline) {
+		t.Errorf("Get() error = %v, want ErrOffline", err)
+	}
+}
+
+// datedSource is a MemorySource that reports a fixed last-commit date and
+// counts its lookups
+type datedSource struct {
+	*MemorySource
+	mu      sync.Mutex
+	lookups int
+}
+
+func (s *datedSource) LastCommitDate(file TemplateFile) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	if file.Name == "broken" {
+		return time.Time{}, errors.New("status 403")
+	}
+	return time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), nil
+}
+
+func TestLastCommitDates(t *testing.T) {
+	remote := &datedSource{MemorySource: NewMemorySource("github", map[string]string{
+		"go":     "bin/\n",
+		"rust":   "target/\n",
+		"broken": "x\n",
+	})}
+	files, _ := remote.List()
+	cache := NewCache(t.TempDir())
+
+	dates, failures, err := LastCommitDates(remote, files, cache)
+	if err != nil {
+		t.Fatalf("LastCommitDates() error = %v", err)
+	}
+	if len(dates) != 2 || len(failures) != 1 || remote.lookups != 3 {
+		t.Errorf("LastCommitDates() = %v, %v after %d lookups, want 2 dates and 1 failure", dates, failures, remote.lookups)
+	}
+
+	// Cached dates are reused; only the failed template is looked up again
+	if _, _, err := LastCommitDates(remote, files, cache); err != nil {
+		t.Fatalf("LastCommitDates() error = %v", err)
+	}
+	if remote.lookups != 4 {
+		t.Errorf("lookups = %d, want cached dates to be reused", remote.lookups)
+	}
+
+	if _, _, err := LastCommitDates(NewMemorySource("toptal", nil), files, nil); err == nil {
+		t.Error("LastCommitDates() should fail for a source without commit dates")
+	}
+}
@@ -0,0 +1,141 @@
+// Package source provides abstraction for different gitignore template sources
+package source
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/polliard/gitignore/src/pkg/github"
+)
+
+// CloneSource reads templates from a local clone of a template repository,
+// such as github/gitignore, configured as gitignore.template.url = file:///path
+// It stands in for a GitHub source, so "github/go" resolves from the clone,
+// and needs no network access
+type CloneSource struct {
+	dir  string
+	root string // subdirectory holding the templates, see SetRoot
+}
+
+// IsFileURL reports whether a template URL names a local clone (file://)
+func IsFileURL(repoURL string) bool {
+	return strings.HasPrefix(strings.ToLower(repoURL), "file://")
+}
+
+// NewCloneSource creates a clone source from a file:// URL
+func NewCloneSource(repoURL string) (*CloneSource, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Path == "" {
+		return nil, fmt.Errorf("invalid file URL: %s", repoURL)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, fmt.Errorf("invalid file URL: %s (use file:///absolute/path)", repoURL)
+	}
+	return &CloneSource{dir: filepath.FromSlash(u.Path)}, nil
+}
+
+// SetRoot limits templates to a subdirectory of the clone, like
+// github.Client.SetRoot
+func (c *CloneSource) SetRoot(root string) {
+	c.root = strings.Trim(root, "/")
+}
+
+// Name returns the source name
+func (c *CloneSource) Name() string {
+	return "github"
+}
+
+// Dir returns the clone directory
+func (c *CloneSource) Dir() string {
+	return c.dir
+}
+
+// URL returns the clone as a file:// URL
+func (c *CloneSource) URL() string {
+	return "file://" + filepath.ToSlash(c.dir)
+}
+
+// Repo returns the clone's directory name, used to tell it apart from other
+// GitHub sources
+func (c *CloneSource) Repo() string {
+	return filepath.Base(c.dir)
+}
+
+// List returns all templates in the clone (or its root subdirectory),
+// categorized by directory like a GitHub repository; the .git directory is
+// skipped
+func (c *CloneSource) List() ([]TemplateFile, error) {
+	var files []TemplateFile
+	base := filepath.Join(c.dir, filepath.FromSlash(c.root))
+	err := filepath.WalkDir(base, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(strings.ToLower(entry.Name()), ".gitignore") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		file := github.ParseGitignorePath(filepath.ToSlash(rel))
+		files = append(files, TemplateFile{
+			Name:     file.Name,
+			Path:     filepath.ToSlash(filepath.Join(c.root, rel)),
+			Category: file.Category,
+			Source:   "github",
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template clone: %w", err)
+	}
+	return files, nil
+}
+
+// Get returns the content of a template by name
+func (c *CloneSource) Get(name string) (*TemplateFile, string, error) {
+	file, err := c.Find(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	content, err := os.ReadFile(filepath.Join(c.dir, filepath.FromSlash(file.Path)))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read template: %w", err)
+	}
+
+	return file, string(content), nil
+}
+
+// Find finds a template by name or category/name (case-insensitive)
+func (c *CloneSource) Find(name string) (*TemplateFile, error) {
+	files, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	nameLower := strings.ToLower(name)
+	for _, file := range files {
+		if strings.ToLower(file.Name) == nameLower {
+			return &file, nil
+		}
+	}
+	for _, file := range files {
+		if file.Category != "" && strings.ToLower(file.Category+"/"+file.Name) == nameLower {
+			return &file, nil
+		}
+	}
+	return nil, fmt.Errorf("gitignore template '%s' not found", name)
+}
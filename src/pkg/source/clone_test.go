@@ -0,0 +1,147 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// writeClone creates a directory laid out like a github/gitignore clone
+func writeClone(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"Go.gitignore":                    "*.exe\n",
+		"Global/macOS.gitignore":          ".DS_Store\n",
+		"community/Golang/Hugo.gitignore": "public/\n",
+		"README.md":                       "# templates\n",
+		".git/info/Fake.gitignore":        "not a template\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestCloneSourceList(t *testing.T) {
+	dir := writeClone(t)
+	clone, err := NewCloneSource("file://" + filepath.ToSlash(dir))
+	if err != nil {
+		t.Fatalf("NewCloneSource() error = %v", err)
+	}
+
+	files, err := clone.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	var got []string
+	for _, f := range files {
+		if f.Source != "github" {
+			t.Errorf("file %s has source %q, want github", f.Path, f.Source)
+		}
+		got = append(got, f.Category+"|"+f.Name+"|"+f.Path)
+	}
+	sort.Strings(got)
+	want := []string{
+		"Global|macOS|Global/macOS.gitignore",
+		"community/Golang|Hugo|community/Golang/Hugo.gitignore",
+		"|Go|Go.gitignore",
+	}
+	sort.Strings(want)
+	if len(got) != len(want) {
+		t.Fatalf("List() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("List()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCloneSourceGet(t *testing.T) {
+	clone, err := NewCloneSource("file://" + filepath.ToSlash(writeClone(t)))
+	if err != nil {
+		t.Fatalf("NewCloneSource() error = %v", err)
+	}
+
+	for name, want := range map[string]string{"go": "*.exe\n", "global/macos": ".DS_Store\n", "Hugo": "public/\n"} {
+		_, content, err := clone.Get(name)
+		if err != nil {
+			t.Errorf("Get(%q) error = %v", name, err)
+			continue
+		}
+		if content != want {
+			t.Errorf("Get(%q) content = %q, want %q", name, content, want)
+		}
+	}
+
+	if _, _, err := clone.Get("Fake"); err == nil {
+		t.Error("Get() should not find templates inside .git")
+	}
+}
+
+func TestCloneSourceRoot(t *testing.T) {
+	dir := writeClone(t)
+	clone, err := NewCloneSource("file://" + filepath.ToSlash(dir))
+	if err != nil {
+		t.Fatalf("NewCloneSource() error = %v", err)
+	}
+	clone.SetRoot("community")
+
+	files, err := clone.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(files) != 1 || files[0].Category != "Golang" || files[0].Name != "Hugo" {
+		t.Fatalf("List() = %+v, want only Golang/Hugo", files)
+	}
+
+	_, content, err := clone.Get("golang/hugo")
+	if err != nil || content != "public/\n" {
+		t.Errorf("Get(golang/hugo) = %q, %v", content, err)
+	}
+	if _, _, err := clone.Get("go"); err == nil {
+		t.Error("Get() should not find templates outside the root")
+	}
+}
+
+func TestNewCloneSourceInvalid(t *testing.T) {
+	for _, u := range []string{"file://", "file://host/path"} {
+		if _, err := NewCloneSource(u); err == nil {
+			t.Errorf("NewCloneSource(%q) should fail", u)
+		}
+	}
+}
+
+func TestNewSourceManager_FileURL(t *testing.T) {
+	dir := writeClone(t)
+	sm, err := NewSourceManager(t.TempDir(), "file://"+filepath.ToSlash(dir), false, WithOffline(true))
+	if err != nil {
+		t.Fatalf("NewSourceManager() error = %v", err)
+	}
+
+	remote := sm.RemoteSources()
+	if len(remote) != 1 {
+		t.Fatalf("expected 1 remote source, got %d", len(remote))
+	}
+	if _, ok := remote[0].(*CloneSource); !ok {
+		t.Fatalf("expected a CloneSource, got %T", remote[0])
+	}
+
+	// A clone is read from disk, so it works offline
+	file, content, err := sm.GetAny("github/global/macos")
+	if err != nil {
+		t.Fatalf("GetAny() error = %v", err)
+	}
+	if file.Name != "macOS" || content != ".DS_Store\n" {
+		t.Errorf("GetAny() = %s %q, want macOS template", file.Name, content)
+	}
+}
@@ -0,0 +1,129 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CommitDateTTL is how long a cached last-commit date is reused before it is
+// looked up again
+const CommitDateTTL = 24 * time.Hour
+
+// cacheCommitsFile holds the last-commit dates looked up for a source,
+// within its cache directory
+const cacheCommitsFile = "commits.json"
+
+// CommitDater is implemented by sources that can tell when a template last
+// changed upstream, such as GitHub repositories
+type CommitDater interface {
+	LastCommitDate(file TemplateFile) (time.Time, error)
+}
+
+// commitDate is the on-disk form of one cached last-commit date
+type commitDate struct {
+	Date      time.Time `json:"date"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// LastCommitDates returns when each of files last changed in source, keyed
+// by template path, looking up CacheFetchWorkers templates at a time
+// With a non-nil cache, dates looked up within CommitDateTTL are reused and
+// new ones are saved for next time
+// A source that isn't a CommitDater is an error; templates whose date can't
+// be fetched are left out of the map and reported in failures
+func LastCommitDates(source Source, files []TemplateFile, cache *Cache) (dates map[string]time.Time, failures []error, err error) {
+	dater, ok := source.(CommitDater)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s does not report when templates changed", source.Name())
+	}
+
+	cached := make(map[string]commitDate)
+	if cache != nil {
+		cached = cache.loadCommitDates(source)
+	}
+
+	now := time.Now().UTC()
+	dates = make(map[string]time.Time)
+	var stale []TemplateFile
+	for _, file := range files {
+		if entry, ok := cached[commitDateKey(file)]; ok && now.Sub(entry.CheckedAt) < CommitDateTTL {
+			dates[file.Path] = entry.Date
+		} else {
+			stale = append(stale, file)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, CacheFetchWorkers)
+	for _, file := range stale {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(file TemplateFile) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			date, err := dater.LastCommitDate(file)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures = append(failures, fmt.Errorf("%s: %w", templateFileName(file), err))
+				return
+			}
+			dates[file.Path] = date
+			cached[commitDateKey(file)] = commitDate{Date: date, CheckedAt: now}
+		}(file)
+	}
+	wg.Wait()
+
+	if cache != nil && len(stale) > 0 {
+		if err := cache.storeCommitDates(source, cached); err != nil {
+			failures = append(failures, err)
+		}
+	}
+	return dates, failures, nil
+}
+
+// commitDateKey identifies a template's cached date; templates from a pinned
+// ref are kept apart from those on the default branch
+func commitDateKey(file TemplateFile) string {
+	if file.Ref == "" {
+		return file.Path
+	}
+	return file.Path + "@" + file.Ref
+}
+
+// loadCommitDates returns the last-commit dates cached for source; a missing
+// or unreadable file is treated as empty
+func (c *Cache) loadCommitDates(source Source) map[string]commitDate {
+	dates := make(map[string]commitDate)
+	data, err := os.ReadFile(filepath.Join(c.sourceDir(source), cacheCommitsFile))
+	if err != nil {
+		return dates
+	}
+	if err := json.Unmarshal(data, &dates); err != nil {
+		return make(map[string]commitDate)
+	}
+	return dates
+}
+
+// storeCommitDates writes the last-commit dates cached for source
+func (c *Cache) storeCommitDates(source Source, dates map[string]commitDate) error {
+	dir := c.sourceDir(source)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create template cache: %w", err)
+	}
+	data, err := json.MarshalIndent(dates, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, cacheCommitsFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write template cache: %w", err)
+	}
+	return nil
+}
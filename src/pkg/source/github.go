@@ -2,6 +2,10 @@
 package source
 
 import (
+	"context"
+	"net/url"
+	"time"
+
 	"github.com/polliard/gitignore/src/pkg/github"
 )
 
@@ -24,6 +28,34 @@ func NewGitHubSource(repoURL string) (*GitHubSource, error) {
 	}, nil
 }
 
+// SetUserAgent sets the User-Agent header sent with every request; an empty
+// value leaves it unchanged
+func (g *GitHubSource) SetUserAgent(userAgent string) {
+	g.client.SetUserAgent(userAgent)
+}
+
+// SetProxy routes requests through proxy; nil uses the environment's proxy
+// settings
+func (g *GitHubSource) SetProxy(proxy *url.URL) {
+	g.client.SetProxy(proxy)
+}
+
+// SetContext makes requests run under ctx, so canceling it aborts them
+func (g *GitHubSource) SetContext(ctx context.Context) {
+	g.client.SetContext(ctx)
+}
+
+// Pending reports whether a request is in flight
+func (g *GitHubSource) Pending() bool {
+	return g.client.Pending()
+}
+
+// Refresh drops the cached listing, so the next lookup fetches the tree
+// again (see github.Client.Refresh)
+func (g *GitHubSource) Refresh() {
+	g.client.Refresh()
+}
+
 // Name returns the source name
 func (g *GitHubSource) Name() string {
 	return "github"
@@ -34,6 +66,16 @@ func (g *GitHubSource) URL() string {
 	return g.url
 }
 
+// Repo returns the repository as "owner/repo"
+func (g *GitHubSource) Repo() string {
+	return g.client.Owner() + "/" + g.client.Repo()
+}
+
+// Ref returns the pinned branch, tag or commit, or "" for the default branch
+func (g *GitHubSource) Ref() string {
+	return g.client.Ref()
+}
+
 // List returns all available templates from GitHub
 func (g *GitHubSource) List() ([]TemplateFile, error) {
 	files, err := g.client.ListGitignoreFiles()
@@ -48,6 +90,7 @@ func (g *GitHubSource) List() ([]TemplateFile, error) {
 			Path:     f.Path,
 			Category: f.Category,
 			Source:   "github",
+			Ref:      g.client.Ref(),
 		})
 	}
 
@@ -71,6 +114,7 @@ func (g *GitHubSource) Get(name string) (*TemplateFile, string, error) {
 		Path:     file.Path,
 		Category: file.Category,
 		Source:   "github",
+		Ref:      g.client.Ref(),
 	}, content, nil
 }
 
@@ -86,5 +130,12 @@ func (g *GitHubSource) Find(name string) (*TemplateFile, error) {
 		Path:     file.Path,
 		Category: file.Category,
 		Source:   "github",
+		Ref:      g.client.Ref(),
 	}, nil
 }
+
+// LastCommitDate returns when a template last changed upstream, from the
+// newest commit touching its path
+func (g *GitHubSource) LastCommitDate(file TemplateFile) (time.Time, error) {
+	return g.client.LastCommitDate(github.GitignoreFile{Name: file.Name, Path: file.Path, Category: file.Category})
+}
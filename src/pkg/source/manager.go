@@ -2,78 +2,372 @@
 package source
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/polliard/gitignore/src/pkg/logging"
 )
 
 // SourceManager manages multiple template sources with priority ordering
 type SourceManager struct {
 	local   *LocalSource
+	custom  []Source // caller-supplied sources, consulted after local
 	remote  []Source
-	sources []Source // all sources in order (local first, then remote)
+	builtin Source   // templates embedded in the binary, consulted last
+	sources []Source // all sources in order (local, custom, remote, then builtin)
+	offline bool     // skip remote sources entirely
+
+	githubContentAPI bool              // prefer the GitHub Contents API over raw URLs
+	aliases          map[string]string // lowercase alias -> template type, see WithAliases
+	templateRef      string            // ref for GitHub sources whose URL names none
+	templatePath     string            // repository subdirectory holding the templates
+	userAgent        string            // User-Agent for HTTP requests ("" = library default)
+	proxy            *url.URL          // proxy for HTTP requests (nil = environment)
+	ctx              context.Context   // context network requests run under (nil = none)
+	cacheDir         string            // refresh-cache directory used while offline ("" = none)
+	blocklist        []string          // lowercase path.Match patterns of templates never served
+}
+
+// Option configures optional SourceManager behavior
+type Option func(*SourceManager)
+
+// WithOffline makes the manager use only the local source
+// Remote sources are still known (so "github/go" is recognized as a prefix)
+// but are never queried; lookups that would need them fail immediately
+func WithOffline(offline bool) Option {
+	return func(sm *SourceManager) {
+		sm.offline = offline
+	}
+}
+
+// WithSources adds custom sources, such as a MemorySource, to the manager
+// They are consulted after the local directory and before any remote source,
+// in the order given, and are still used when offline
+func WithSources(sources ...Source) Option {
+	return func(sm *SourceManager) {
+		sm.custom = append(sm.custom, sources...)
+	}
+}
+
+// WithGitHubContentAPI makes GitHub sources fetch template content through
+// api.github.com first, falling back to raw.githubusercontent.com
+func WithGitHubContentAPI(prefer bool) Option {
+	return func(sm *SourceManager) {
+		sm.githubContentAPI = prefer
+	}
+}
+
+// WithTemplateRef pins GitHub sources to a branch, tag or commit SHA
+// A ref given in a repository URL (.../tree/<ref>) takes precedence
+func WithTemplateRef(ref string) Option {
+	return func(sm *SourceManager) {
+		sm.templateRef = ref
+	}
+}
+
+// WithTemplatePath makes GitHub sources (and local clones) read templates
+// from a subdirectory of the repository, such as "templates", instead of its
+// root; categories are relative to that directory
+func WithTemplatePath(path string) Option {
+	return func(sm *SourceManager) {
+		sm.templatePath = path
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent by the GitHub and Toptal
+// sources; an empty value keeps github.DefaultUserAgent
+func WithUserAgent(userAgent string) Option {
+	return func(sm *SourceManager) {
+		sm.userAgent = userAgent
+	}
+}
+
+// WithHTTPProxy routes the GitHub and Toptal sources' requests through proxy
+// instead of the proxy named by HTTPS_PROXY/HTTP_PROXY; nil keeps the
+// environment's settings
+func WithHTTPProxy(proxy *url.URL) Option {
+	return func(sm *SourceManager) {
+		sm.proxy = proxy
+	}
+}
+
+// WithContext makes the sources' network requests run under ctx, so that
+// canceling it, or its deadline passing, aborts them; lookups then fail
+// with the context's error instead of falling back to the next source
+// See Pending for the sources that were cut short
+func WithContext(ctx context.Context) Option {
+	return func(sm *SourceManager) {
+		sm.ctx = ctx
+	}
+}
+
+// WithCacheDir makes an offline manager serve remote sources from listings
+// saved in dir by Cache.Refresh instead of skipping them; sources with no
+// cached listing are still skipped
+func WithCacheDir(dir string) Option {
+	return func(sm *SourceManager) {
+		sm.cacheDir = dir
+	}
+}
+
+// WithBlocklist refuses templates matching any of patterns, which use
+// path.Match syntax against the template's source-qualified path, e.g.
+// "toptal/*" or "github/community/*"
+// A pattern also matches everything beneath a path it matches, so
+// "github/community/*" covers github/community/golang/hugo
+// Blocked templates are left out of listings, and lookups skip them and move
+// on to the next source; if no other source has the template, the error
+// wraps ErrBlocked
+func WithBlocklist(patterns []string) Option {
+	return func(sm *SourceManager) {
+		sm.blocklist = nil
+		for _, pattern := range patterns {
+			sm.blocklist = append(sm.blocklist, strings.ToLower(pattern))
+		}
+	}
 }
 
+// WithAliases makes GetAny expand template aliases, e.g.
+// "vscode" -> "github/global/visualstudiocode", before resolving a name
+// Aliases are matched case-insensitively and expanded once (not recursively)
+func WithAliases(aliases map[string]string) Option {
+	return func(sm *SourceManager) {
+		sm.aliases = make(map[string]string, len(aliases))
+		for alias, target := range aliases {
+			sm.aliases[strings.ToLower(alias)] = target
+		}
+	}
+}
+
+// ErrOffline is wrapped by errors for lookups that need a remote source
+// while offline
+var ErrOffline = errors.New("offline")
+
+// ErrBlocked is wrapped by errors for templates refused by WithBlocklist
+var ErrBlocked = errors.New("blocked")
+
+// SourceError is the error one source returned for a lookup or listing
+type SourceError struct {
+	Source string // source key, see SourceKey
+	Err    error
+}
+
+func (e SourceError) Error() string { return e.Source + ": " + e.Err.Error() }
+
+func (e SourceError) Unwrap() error { return e.Err }
+
+// SourcesError is returned when a template could not be found or listed in
+// any source; Errors holds what each source that was tried returned, so
+// callers can tell a missing template from a failing source
+type SourcesError struct {
+	Err    error // summary, e.g. "template 'x' not found in any source"
+	Errors []SourceError
+}
+
+func (e *SourcesError) Error() string { return e.Err.Error() }
+
+func (e *SourcesError) Unwrap() error { return e.Err }
+
 // NewSourceManager creates a new source manager
-// Priority order: local -> GitHub -> Toptal (if enabled)
-func NewSourceManager(localPath, templateURL string, enableToptal bool) (*SourceManager, error) {
+// Priority order: local -> custom (see WithSources) -> GitHub -> Toptal (if enabled)
+// templateURL may be a comma-separated list of repositories; each becomes its
+// own GitHub source, with earlier repositories taking precedence
+func NewSourceManager(localPath, templateURL string, enableToptal bool, opts ...Option) (*SourceManager, error) {
 	local := NewLocalSourceWithDir(localPath)
 
 	sm := &SourceManager{
 		local:  local,
 		remote: []Source{},
 	}
+	for _, opt := range opts {
+		opt(sm)
+	}
 
-	// Local source is always first
+	// Local source is always first, followed by any custom sources
 	sm.sources = append(sm.sources, local)
+	sm.sources = append(sm.sources, sm.custom...)
 
-	// Add GitHub source
-	githubSource, err := NewGitHubSource(templateURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create GitHub source: %w", err)
+	// Add one GitHub source per configured repository; a file:// URL names a
+	// local clone, read from disk instead of through the GitHub API
+	for _, repoURL := range SplitTemplateURLs(templateURL) {
+		if IsFileURL(repoURL) {
+			clone, err := NewCloneSource(repoURL)
+			if err != nil {
+				return nil, err
+			}
+			clone.SetRoot(sm.templatePath)
+			sm.remote = append(sm.remote, clone)
+			sm.sources = append(sm.sources, clone)
+			continue
+		}
+		githubSource, err := NewGitHubSource(repoURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GitHub source: %w", err)
+		}
+		githubSource.client.SetPreferContentAPI(sm.githubContentAPI)
+		githubSource.client.SetUserAgent(sm.userAgent)
+		githubSource.client.SetRoot(sm.templatePath)
+		if sm.proxy != nil {
+			githubSource.client.SetProxy(sm.proxy)
+		}
+		if githubSource.Ref() == "" {
+			githubSource.client.SetRef(sm.templateRef)
+		}
+		sm.remote = append(sm.remote, githubSource)
+		sm.sources = append(sm.sources, githubSource)
 	}
-	sm.remote = append(sm.remote, githubSource)
-	sm.sources = append(sm.sources, githubSource)
 
 	// Add Toptal source if enabled
 	if enableToptal {
 		toptalSource := NewToptalSource()
+		toptalSource.SetUserAgent(sm.userAgent)
+		if sm.proxy != nil {
+			toptalSource.SetProxy(sm.proxy)
+		}
 		sm.remote = append(sm.remote, toptalSource)
 		sm.sources = append(sm.sources, toptalSource)
 	}
 
+	// The embedded templates come last, as a fallback for every other source
+	sm.builtin = NewBuiltinSource()
+	sm.sources = append(sm.sources, sm.builtin)
+
+	if sm.ctx != nil {
+		for _, source := range sm.sources {
+			if c, ok := source.(Canceler); ok {
+				c.SetContext(sm.ctx)
+			}
+		}
+	}
+
+	if sm.offline && sm.cacheDir != "" {
+		sm.useCache(NewCache(sm.cacheDir))
+	}
+
 	return sm, nil
 }
 
-// List returns all templates from all sources, local templates first
-func (sm *SourceManager) List() ([]TemplateFile, error) {
-	var allFiles []TemplateFile
-	localNames := make(map[string]bool)
+// useCache replaces the remote sources that would be skipped offline with
+// their cached listings, where the cache has one
+func (sm *SourceManager) useCache(cache *Cache) {
+	for i, source := range sm.remote {
+		if !sm.skipRemote(source) {
+			continue
+		}
+		cached, err := cache.Load(source)
+		if err != nil {
+			logging.Debugf("%s: no cached listing: %v", source.Name(), err)
+			continue
+		}
+		logging.Verbosef("offline: using %s listing cached %s", source.Name(), cached.FetchedAt().Local().Format("2006-01-02 15:04"))
+		sm.remote[i] = cached
+		for j := range sm.sources {
+			if sm.sources[j] == source {
+				sm.sources[j] = cached
+			}
+		}
+	}
+}
 
-	// First, get local templates
-	localFiles, err := sm.local.List()
+// NewSourceManagerWithOrder creates a source manager whose sources are
+// consulted in the given order, e.g. []string{"local", "toptal", "github"}
+// Sources missing from order keep their default relative order after the
+// listed ones; names that match no known source are ignored with a warning
+func NewSourceManagerWithOrder(localPath, templateURL string, enableToptal bool, order []string, opts ...Option) (*SourceManager, error) {
+	sm, err := NewSourceManager(localPath, templateURL, enableToptal, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list local templates: %w", err)
+		return nil, err
+	}
+	sm.applyOrder(order)
+	return sm, nil
+}
+
+// applyOrder stably reorders sources (and remote sources) by their position
+// in order
+func (sm *SourceManager) applyOrder(order []string) {
+	if len(order) == 0 {
+		return
 	}
-	for _, f := range localFiles {
-		allFiles = append(allFiles, f)
-		localNames[strings.ToLower(f.Name)] = true
+
+	known := []string{"local", "github", "toptal", BuiltinSourceName}
+	for _, source := range sm.custom {
+		known = append(known, source.Name())
+	}
+	for _, name := range order {
+		if !containsName(known, name, name) {
+			logging.Warnf("ignoring unknown source '%s' in source priority", name)
+		}
 	}
 
-	// Then get remote templates (mark duplicates)
-	for _, source := range sm.remote {
+	rank := func(source Source) int {
+		for i, name := range order {
+			if strings.EqualFold(name, source.Name()) {
+				return i
+			}
+		}
+		return len(order)
+	}
+	for _, list := range [][]Source{sm.sources, sm.remote} {
+		sort.SliceStable(list, func(i, j int) bool {
+			return rank(list[i]) < rank(list[j])
+		})
+	}
+}
+
+// SplitTemplateURLs parses a comma-separated list of repository URLs
+func SplitTemplateURLs(value string) []string {
+	var urls []string
+	for _, u := range strings.Split(value, ",") {
+		u = strings.TrimSpace(u)
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// List returns all templates from all sources in priority order
+// A template is dropped when an earlier source already listed one with the
+// same category and name (ignoring case), so each name appears once, from the
+// source Get would use
+// A source that fails to list is skipped, and all such failures are reported
+// in a single warning; use ListBySource for per-source errors
+func (sm *SourceManager) List() ([]TemplateFile, error) {
+	var allFiles []TemplateFile
+	var failures []string
+	seen := make(map[string]bool)
+
+	for _, source := range sm.ordered() {
+		if err := sm.canceled(); err != nil {
+			return nil, err
+		}
+		if sm.skipRemote(source) {
+			continue
+		}
 		files, err := source.List()
 		if err != nil {
-			// Log warning but continue with other sources
+			failures = append(failures, fmt.Sprintf("%s: %v", sm.SourceKey(source), err))
 			continue
 		}
-		for _, f := range files {
-			// Don't add if already exists locally (local takes precedence)
-			if !localNames[strings.ToLower(f.Name)] {
-				allFiles = append(allFiles, f)
+		for _, f := range sm.allowed(source, files) {
+			key := strings.ToLower(path.Join(f.Category, f.Name))
+			if seen[key] {
+				continue
 			}
+			seen[key] = true
+			allFiles = append(allFiles, f)
 		}
 	}
 
+	if len(failures) > 0 {
+		logging.Warnf("some template sources could not be listed: %s", strings.Join(failures, "; "))
+	}
 	return allFiles, nil
 }
 
@@ -83,60 +377,184 @@ type SourceResult struct {
 	Error error
 }
 
-// ListBySource returns templates grouped by source
+// ListBySource returns templates grouped by source key (see SourceKey)
 // Sources that fail to list are included with an empty slice (graceful degradation)
 func (sm *SourceManager) ListBySource() (map[string]SourceResult, error) {
+	return sm.ListBySourceFrom(nil)
+}
+
+// ListBySourceFrom is like ListBySource but only queries the named sources
+// Names may be source names ("github") or source keys ("github:owner/repo");
+// a nil or empty slice queries every source
+func (sm *SourceManager) ListBySourceFrom(names []string) (map[string]SourceResult, error) {
 	result := make(map[string]SourceResult)
 
 	for _, source := range sm.sources {
+		key := sm.SourceKey(source)
+		if len(names) > 0 && !containsName(names, source.Name(), key) {
+			continue
+		}
+		if err := sm.canceled(); err != nil {
+			return nil, err
+		}
+		if sm.skipRemote(source) {
+			continue
+		}
 		files, err := source.List()
 		if err != nil {
 			// Include the source with error to indicate what went wrong
-			result[source.Name()] = SourceResult{Files: []TemplateFile{}, Error: err}
+			result[key] = SourceResult{Files: []TemplateFile{}, Error: err}
 			continue
 		}
-		result[source.Name()] = SourceResult{Files: files, Error: nil}
+		result[key] = SourceResult{Files: sm.allowed(source, files), Error: nil}
 	}
 
 	return result, nil
 }
 
-// Get retrieves a template by name, checking local first then remote sources
+// Get retrieves a template by name, trying each source in priority order
+// (local first by default)
 func (sm *SourceManager) Get(name string) (*TemplateFile, string, error) {
-	// Always try local first
-	file, content, err := sm.local.Get(name)
-	if err == nil {
-		return file, content, nil
-	}
-
-	// Try remote sources in order
-	var lastErr error
-	for _, source := range sm.remote {
+	var failures []SourceError
+	skipped := false
+	for _, source := range sm.ordered() {
+		if err := sm.canceled(); err != nil {
+			return nil, "", err
+		}
+		if sm.skipRemote(source) {
+			skipped = true
+			continue
+		}
 		file, content, err := source.Get(name)
 		if err == nil {
+			err = sm.blocked(source, file)
+		}
+		if err == nil {
+			logging.Verbosef("resolved '%s' from %s (%s)", name, sm.SourceKey(source), file.Path)
 			return file, content, nil
 		}
-		lastErr = err
+		logging.Debugf("%s: %v", sm.SourceKey(source), err)
+		failures = append(failures, SourceError{Source: sm.SourceKey(source), Err: err})
 	}
 
-	if lastErr != nil {
-		return nil, "", fmt.Errorf("template '%s' not found in any source", name)
+	if err := blockedFailure(failures); err != nil {
+		return nil, "", &SourcesError{Err: err, Errors: failures}
+	}
+
+	if skipped {
+		return nil, "", &SourcesError{
+			Err:    fmt.Errorf("%w: template '%s' not available locally", ErrOffline, name),
+			Errors: failures,
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, "", &SourcesError{Err: fmt.Errorf("template '%s' not found in any source", name), Errors: failures}
 	}
 
 	return nil, "", fmt.Errorf("template '%s' not found", name)
 }
 
 // GetFromSource retrieves a template from a specific source
-// sourceName should be "local", "github", or "toptal"
+// sourceName should be "local", "github", or "toptal", or a repository-qualified
+// GitHub key such as "github:owner/repo" (see SourceKey)
+// When several sources share a name (multiple GitHub repositories), they are
+// tried in priority order and the first match wins
 func (sm *SourceManager) GetFromSource(sourceName, templateName string) (*TemplateFile, string, error) {
+	var failures []SourceError
 	for _, source := range sm.sources {
-		if source.Name() == sourceName {
-			return source.Get(templateName)
+		if source.Name() != sourceName && sm.SourceKey(source) != sourceName {
+			continue
+		}
+		if err := sm.canceled(); err != nil {
+			return nil, "", err
 		}
+		if sm.skipRemote(source) {
+			return nil, "", fmt.Errorf("%w: template '%s' not available locally", ErrOffline, templateName)
+		}
+		file, content, err := source.Get(templateName)
+		if err == nil {
+			err = sm.blocked(source, file)
+		}
+		if err == nil {
+			return file, content, nil
+		}
+		failures = append(failures, SourceError{Source: sm.SourceKey(source), Err: err})
+	}
+	if err := blockedFailure(failures); err != nil {
+		return nil, "", &SourcesError{Err: err, Errors: failures}
+	}
+	if len(failures) > 0 {
+		return nil, "", &SourcesError{Err: failures[len(failures)-1].Err, Errors: failures}
 	}
 	return nil, "", fmt.Errorf("unknown source: %s", sourceName)
 }
 
+// HasSource reports whether a source with the given name or key is configured
+func (sm *SourceManager) HasSource(name string) bool {
+	for _, source := range sm.sources {
+		if containsName([]string{name}, source.Name(), sm.SourceKey(source)) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsName reports whether names includes the source name or key
+func containsName(names []string, name, key string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) || strings.EqualFold(n, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// SourceKey returns a unique identifier for a source within this manager
+// This is the source name, except when multiple GitHub repositories are
+// configured, in which case each is qualified as "github:owner/repo" (or
+// "github:<directory>" for a local clone)
+func (sm *SourceManager) SourceKey(source Source) string {
+	repo, ok := source.(interface{ Repo() string })
+	if !ok || sm.countNamed(source.Name()) < 2 {
+		return source.Name()
+	}
+	return fmt.Sprintf("%s:%s", source.Name(), repo.Repo())
+}
+
+// Pending returns the keys (see SourceKey) of the sources with a network
+// request in flight, in priority order; after the context passed to
+// WithContext is canceled, these are the sources it cut short
+func (sm *SourceManager) Pending() []string {
+	var pending []string
+	for _, source := range sm.sources {
+		if c, ok := source.(Canceler); ok && c.Pending() {
+			pending = append(pending, sm.SourceKey(source))
+		}
+	}
+	return pending
+}
+
+// canceled returns the error of the WithContext context once it is done, so
+// that no further source is consulted
+func (sm *SourceManager) canceled() error {
+	if sm.ctx == nil {
+		return nil
+	}
+	return sm.ctx.Err()
+}
+
+// countNamed returns how many configured sources share the given name
+func (sm *SourceManager) countNamed(name string) int {
+	count := 0
+	for _, source := range sm.sources {
+		if source.Name() == name {
+			count++
+		}
+	}
+	return count
+}
+
 // SourceNames returns the names of all configured sources
 func (sm *SourceManager) SourceNames() []string {
 	names := make([]string, len(sm.sources))
@@ -149,6 +567,7 @@ func (sm *SourceManager) SourceNames() []string {
 // ParseSourcePrefix checks if the template type starts with a known source name
 // Returns (sourceName, templateName, hasPrefix)
 // e.g., "github/global/macos" -> ("github", "global/macos", true)
+// e.g., "github:acme/gitignore/go" -> ("github:acme/gitignore", "go", true)
 // e.g., "go" -> ("", "go", false)
 func (sm *SourceManager) ParseSourcePrefix(templateType string) (string, string, bool) {
 	parts := strings.SplitN(templateType, "/", 2)
@@ -157,17 +576,38 @@ func (sm *SourceManager) ParseSourcePrefix(templateType string) (string, string,
 	}
 	potentialSource := parts[0]
 	for _, source := range sm.sources {
-		if source.Name() == potentialSource {
-			return potentialSource, parts[1], true
+		if strings.EqualFold(source.Name(), potentialSource) {
+			return source.Name(), parts[1], true
+		}
+	}
+
+	// Repository-qualified keys contain a slash ("github:owner/repo")
+	for _, source := range sm.sources {
+		key := sm.SourceKey(source)
+		if key == source.Name() || len(templateType) <= len(key)+1 {
+			continue
+		}
+		if strings.EqualFold(templateType[:len(key)+1], key+"/") {
+			return key, templateType[len(key)+1:], true
 		}
 	}
 	return "", templateType, false
 }
 
 // GetAny retrieves a template, handling source prefixes automatically
-// If templateType has a source prefix (e.g., "github/rust"), fetches from that source
-// Otherwise, uses priority order (local -> GitHub -> Toptal)
+// If templateType starts with a known source name (matched case-insensitively),
+// the prefix is stripped and the remainder is fetched from that source only:
+//
+//	"github/rust"         -> GetFromSource("github", "rust")
+//	"github/global/macos" -> GetFromSource("github", "global/macos")
+//	"toptal/python"       -> GetFromSource("toptal", "python")
+//	"local/foo"           -> GetFromSource("local", "foo")
+//
+// Anything else, including category paths without a source prefix such as
+// "global/macos", falls through to Get and uses priority order
+// (local -> GitHub -> Toptal)
 func (sm *SourceManager) GetAny(templateType string) (*TemplateFile, string, error) {
+	templateType = sm.ResolveAlias(templateType)
 	sourceName, templateName, hasPrefix := sm.ParseSourcePrefix(templateType)
 	if hasPrefix {
 		return sm.GetFromSource(sourceName, templateName)
@@ -175,23 +615,216 @@ func (sm *SourceManager) GetAny(templateType string) (*TemplateFile, string, err
 	return sm.Get(templateType)
 }
 
-// Find finds a template by name, checking local first
+// ResolveAlias returns the target of an alias configured with WithAliases,
+// or the name unchanged if it is not an alias
+func (sm *SourceManager) ResolveAlias(name string) string {
+	if target, ok := sm.aliases[strings.ToLower(name)]; ok {
+		logging.Verbosef("expanding alias '%s' to '%s'", name, target)
+		return target
+	}
+	return name
+}
+
+// Find finds a template by name without fetching its content, trying each
+// source in priority order and stopping at the first match
+// Sources look the name up in their listings, which remote sources cache
+// (see Refresher), so repeated lookups don't refetch them
+// A source prefix limits the lookup to that source, and the rest may be
+// category-qualified: "github/global/macos" finds GitHub's Global/macOS
 func (sm *SourceManager) Find(name string) (*TemplateFile, error) {
-	// Always try local first
-	file, err := sm.local.Find(name)
-	if err == nil {
-		return file, nil
+	sourceName, templateName, hasPrefix := sm.ParseSourcePrefix(name)
+	skipped := false
+	for _, source := range sm.ordered() {
+		if hasPrefix && source.Name() != sourceName && sm.SourceKey(source) != sourceName {
+			continue
+		}
+		if err := sm.canceled(); err != nil {
+			return nil, err
+		}
+		if sm.skipRemote(source) {
+			skipped = true
+			continue
+		}
+		if file, err := source.Find(templateName); err == nil && sm.blocked(source, file) == nil {
+			return file, nil
+		}
+	}
+
+	if skipped {
+		return nil, fmt.Errorf("%w: template '%s' not available locally", ErrOffline, name)
 	}
 
-	// Try remote sources in order
-	for _, source := range sm.remote {
+	return nil, fmt.Errorf("template '%s' not found in any source", name)
+}
+
+// Which reports the template and source GetAny would use, resolving aliases
+// and source prefixes the same way, but looks templates up with Find so no
+// content is fetched
+// If no source has it, the error is a *SourcesError listing every source
+// checked; sources skipped while offline are recorded with ErrOffline
+func (sm *SourceManager) Which(templateType string) (*TemplateFile, Source, error) {
+	templateType = sm.ResolveAlias(templateType)
+	sourceName, name, hasPrefix := sm.ParseSourcePrefix(templateType)
+
+	var failures []SourceError
+	skipped := false
+	for _, source := range sm.ordered() {
+		key := sm.SourceKey(source)
+		if hasPrefix && source.Name() != sourceName && key != sourceName {
+			continue
+		}
+		if err := sm.canceled(); err != nil {
+			return nil, nil, err
+		}
+		if sm.skipRemote(source) {
+			skipped = true
+			failures = append(failures, SourceError{Source: key, Err: fmt.Errorf("%w: skipped", ErrOffline)})
+			continue
+		}
 		file, err := source.Find(name)
 		if err == nil {
-			return file, nil
+			err = sm.blocked(source, file)
+		}
+		if err == nil {
+			return file, source, nil
 		}
+		failures = append(failures, SourceError{Source: key, Err: err})
 	}
 
-	return nil, fmt.Errorf("template '%s' not found in any source", name)
+	switch blocked := blockedFailure(failures); {
+	case len(failures) == 0:
+		return nil, nil, fmt.Errorf("unknown source: %s", sourceName)
+	case blocked != nil:
+		return nil, nil, &SourcesError{Err: blocked, Errors: failures}
+	case skipped:
+		return nil, nil, &SourcesError{
+			Err:    fmt.Errorf("%w: template '%s' not available locally", ErrOffline, name),
+			Errors: failures,
+		}
+	}
+	return nil, nil, &SourcesError{Err: fmt.Errorf("template '%s' not found in any source", name), Errors: failures}
+}
+
+// blocked returns an error wrapping ErrBlocked if file, from source, matches
+// a WithBlocklist pattern
+func (sm *SourceManager) blocked(source Source, file *TemplateFile) error {
+	if len(sm.blocklist) == 0 {
+		return nil
+	}
+	rel := strings.ToLower(file.Name)
+	if file.Category != "" {
+		rel = strings.ToLower(file.Category) + "/" + rel
+	}
+	for _, prefix := range []string{strings.ToLower(source.Name()), strings.ToLower(sm.SourceKey(source))} {
+		full := prefix + "/" + rel
+		for _, pattern := range sm.blocklist {
+			if matchesPathOrParent(pattern, full) {
+				return fmt.Errorf("%w: template '%s' matches '%s' in gitignore.blocklist", ErrBlocked, full, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// allowed returns files without the templates blocked by WithBlocklist
+func (sm *SourceManager) allowed(source Source, files []TemplateFile) []TemplateFile {
+	if len(sm.blocklist) == 0 {
+		return files
+	}
+	kept := make([]TemplateFile, 0, len(files))
+	for i := range files {
+		if sm.blocked(source, &files[i]) == nil {
+			kept = append(kept, files[i])
+		}
+	}
+	return kept
+}
+
+// matchesPathOrParent reports whether pattern (path.Match syntax) matches p
+// or one of its parent directories
+func matchesPathOrParent(pattern, p string) bool {
+	for {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+		i := strings.LastIndex(p, "/")
+		if i < 0 {
+			return false
+		}
+		p = p[:i]
+	}
+}
+
+// blockedFailure returns the first failure that wraps ErrBlocked, if any
+func blockedFailure(failures []SourceError) error {
+	for _, failure := range failures {
+		if errors.Is(failure.Err, ErrBlocked) {
+			return failure.Err
+		}
+	}
+	return nil
+}
+
+// PatchMarker separates upstream template content from local additions
+// appended by ApplyPatch
+const PatchMarker = "# --- Local additions from %s ---"
+
+// ApplyPatch appends the local patch for file (see LocalSource.Patch), if one
+// exists, to the template content, separated by a PatchMarker comment
+func (sm *SourceManager) ApplyPatch(file *TemplateFile, content string) (string, error) {
+	patch, path, err := sm.local.Patch(file.Name)
+	if err != nil || path == "" {
+		return content, err
+	}
+	logging.Verbosef("applying local patch %s", path)
+
+	marker := fmt.Sprintf(PatchMarker, filepath.Base(path))
+	return strings.TrimRight(content, "\n") + "\n\n" + marker + "\n" + strings.TrimSpace(patch) + "\n", nil
+}
+
+// UserAgent returns the User-Agent set with WithUserAgent, or "" for the
+// library default
+func (sm *SourceManager) UserAgent() string {
+	return sm.userAgent
+}
+
+// Offline reports whether remote sources are skipped
+func (sm *SourceManager) Offline() bool {
+	return sm.offline
+}
+
+// ordered returns the sources in priority order, falling back to
+// local -> custom -> remote for managers built without a sources list
+func (sm *SourceManager) ordered() []Source {
+	if len(sm.sources) > 0 {
+		return sm.sources
+	}
+	var sources []Source
+	if sm.local != nil {
+		sources = append(sources, sm.local)
+	}
+	sources = append(sources, sm.custom...)
+	return append(sources, sm.remote...)
+}
+
+// skipRemote reports whether a source must not be queried because the
+// manager is offline
+// Local clones (see CloneSource), cached listings (see WithCacheDir) and the
+// built-in templates need no network, so they are never skipped
+func (sm *SourceManager) skipRemote(source Source) bool {
+	if !sm.offline || source == Source(sm.local) || source == Source(sm.builtin) {
+		return false
+	}
+	switch source.(type) {
+	case *CloneSource, *CachedSource:
+		return false
+	}
+	for _, custom := range sm.custom {
+		if source == custom {
+			return false
+		}
+	}
+	return true
 }
 
 // LocalSource returns the local source
@@ -199,6 +832,11 @@ func (sm *SourceManager) LocalSource() *LocalSource {
 	return sm.local
 }
 
+// CustomSources returns the sources added with WithSources
+func (sm *SourceManager) CustomSources() []Source {
+	return sm.custom
+}
+
 // RemoteSources returns the remote sources
 func (sm *SourceManager) RemoteSources() []Source {
 	return sm.remote
@@ -1,8 +1,19 @@
 package source
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/polliard/gitignore/src/pkg/logging"
 )
 
 // mockSource is a test source that can be configured to fail
@@ -33,6 +44,12 @@ func (m *mockSource) Get(name string) (*TemplateFile, string, error) {
 			return &f, m.content[name], nil
 		}
 	}
+	// Mirror GitHub's full-name matching for category paths
+	for _, f := range m.files {
+		if f.Category != "" && f.Category+"/"+f.Name == name {
+			return &f, m.content[name], nil
+		}
+	}
 	return nil, "", errors.New("not found")
 }
 
@@ -45,6 +62,11 @@ func (m *mockSource) Find(query string) (*TemplateFile, error) {
 			return &f, nil
 		}
 	}
+	for _, f := range m.files {
+		if f.Category != "" && strings.EqualFold(f.Category+"/"+f.Name, query) {
+			return &f, nil
+		}
+	}
 	return nil, errors.New("not found")
 }
 
@@ -225,6 +247,107 @@ func TestGet_FallbackOnError(t *testing.T) {
 	}
 }
 
+func TestGet_SourcesError(t *testing.T) {
+	sm := &SourceManager{
+		local: NewLocalSourceWithDir(t.TempDir()),
+		remote: []Source{
+			&mockSource{name: "github", getErr: errors.New("network error")},
+			&mockSource{name: "toptal"},
+		},
+	}
+
+	_, _, err := sm.Get("Go")
+	var sourcesErr *SourcesError
+	if !errors.As(err, &sourcesErr) {
+		t.Fatalf("Get() error = %v (%T), want a *SourcesError", err, err)
+	}
+	if err.Error() != "template 'Go' not found in any source" {
+		t.Errorf("Get() error = %q", err.Error())
+	}
+	var sources []string
+	for _, failure := range sourcesErr.Errors {
+		sources = append(sources, failure.Error())
+	}
+	if len(sources) != 3 || sources[1] != "github: network error" || sources[2] != "toptal: not found" {
+		t.Errorf("Errors = %q, want local, github and toptal errors", sources)
+	}
+
+	// Offline lookups still wrap ErrOffline
+	sm.offline = true
+	if _, _, err := sm.Get("Go"); !errors.Is(err, ErrOffline) || !errors.As(err, &sourcesErr) {
+		t.Errorf("offline Get() error = %v, want ErrOffline in a *SourcesError", err)
+	}
+}
+
+func TestWhich(t *testing.T) {
+	github := NewMemorySource("github", map[string]string{"Go": "# github go", "Rust": "# github rust"})
+	toptal := NewMemorySource("toptal", map[string]string{"rust": "# toptal rust"})
+	sm, err := NewSourceManagerWithOrder(t.TempDir(), "", false, []string{"local", "toptal"},
+		WithSources(github, toptal), WithAliases(map[string]string{"rs": "rust"}))
+	if err != nil {
+		t.Fatalf("NewSourceManagerWithOrder() error: %v", err)
+	}
+
+	tests := map[string]string{
+		"rust":        "toptal",
+		"rs":          "toptal",
+		"github/rust": "github",
+		"go":          "github",
+	}
+	for name, want := range tests {
+		file, src, err := sm.Which(name)
+		if err != nil {
+			t.Errorf("Which(%q) error: %v", name, err)
+			continue
+		}
+		if src.Name() != want || file.Source != want {
+			t.Errorf("Which(%q) = %s from %s, want %s", name, file.Name, src.Name(), want)
+		}
+	}
+
+	_, _, err = sm.Which("haskell")
+	var sourcesErr *SourcesError
+	if !errors.As(err, &sourcesErr) || len(sourcesErr.Errors) != 4 {
+		t.Fatalf("Which(haskell) error = %v, want a *SourcesError for local, toptal, github and builtin", err)
+	}
+	if sourcesErr.Errors[1].Source != "toptal" {
+		t.Errorf("Which(haskell) checked %v, want priority order", sourcesErr.Errors)
+	}
+}
+
+func TestFindSourcePrefix(t *testing.T) {
+	// Find never fetches content, so a failing Get must not matter
+	noContent := errors.New("content fetched")
+	github := &mockSource{name: "github", getErr: noContent, files: []TemplateFile{
+		{Name: "macOS", Category: "Global", Source: "github"},
+		{Name: "Go", Source: "github"},
+	}}
+	toptal := &mockSource{name: "toptal", getErr: noContent, files: []TemplateFile{
+		{Name: "macos", Category: "Global", Source: "toptal"},
+	}}
+	sm := &SourceManager{
+		remote:  []Source{toptal, github},
+		sources: []Source{toptal, github},
+	}
+
+	tests := map[string]string{
+		"github/global/macos": "github",
+		"github/Global/macOS": "github",
+		"global/macos":        "toptal", // no prefix: priority order
+		"toptal/global/macos": "toptal",
+		"Go":                  "github",
+	}
+	for name, want := range tests {
+		file, err := sm.Find(name)
+		if err != nil || file.Source != want {
+			t.Errorf("Find(%q) = %+v, %v, want the %s template", name, file, err, want)
+		}
+	}
+	if _, err := sm.Find("toptal/go"); err == nil {
+		t.Error("Find(toptal/go) should not fall back to another source")
+	}
+}
+
 func TestGetFromSource(t *testing.T) {
 	// Test that GetFromSource retrieves from a specific source
 	sm := &SourceManager{
@@ -272,3 +395,664 @@ func TestGetFromSource(t *testing.T) {
 		t.Error("expected error for unknown source")
 	}
 }
+
+func TestSplitTemplateURLs(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"https://github.com/github/gitignore", []string{"https://github.com/github/gitignore"}},
+		{"https://github.com/acme/gitignore, https://github.com/github/gitignore", []string{"https://github.com/acme/gitignore", "https://github.com/github/gitignore"}},
+		{" , https://github.com/a/b ,", []string{"https://github.com/a/b"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		got := SplitTemplateURLs(tt.value)
+		if len(got) != len(tt.want) {
+			t.Errorf("SplitTemplateURLs(%q) = %v, want %v", tt.value, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("SplitTemplateURLs(%q)[%d] = %q, want %q", tt.value, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestNewSourceManager_MultipleGitHubRepos(t *testing.T) {
+	sm, err := NewSourceManager(t.TempDir(), "https://github.com/acme/gitignore, https://github.com/github/gitignore", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	remote := sm.RemoteSources()
+	if len(remote) != 2 {
+		t.Fatalf("expected 2 remote sources, got %d", len(remote))
+	}
+
+	// Sources keep the configured priority order
+	wantKeys := []string{"github:acme/gitignore", "github:github/gitignore"}
+	for i, src := range remote {
+		if src.Name() != "github" {
+			t.Errorf("remote[%d].Name() = %q, want github", i, src.Name())
+		}
+		if key := sm.SourceKey(src); key != wantKeys[i] {
+			t.Errorf("SourceKey(remote[%d]) = %q, want %q", i, key, wantKeys[i])
+		}
+	}
+}
+
+func TestSourceKey_SingleGitHubRepo(t *testing.T) {
+	sm, err := NewSourceManager(t.TempDir(), "https://github.com/github/gitignore", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key := sm.SourceKey(sm.RemoteSources()[0]); key != "github" {
+		t.Errorf("SourceKey() = %q, want github", key)
+	}
+}
+
+func TestParseSourcePrefix_RepoQualified(t *testing.T) {
+	sm, err := NewSourceManager(t.TempDir(), "https://github.com/acme/gitignore, https://github.com/github/gitignore", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sourceName, templateName, ok := sm.ParseSourcePrefix("github:acme/gitignore/global/macos")
+	if !ok {
+		t.Fatal("expected repo-qualified prefix to be recognized")
+	}
+	if sourceName != "github:acme/gitignore" {
+		t.Errorf("sourceName = %q, want github:acme/gitignore", sourceName)
+	}
+	if templateName != "global/macos" {
+		t.Errorf("templateName = %q, want global/macos", templateName)
+	}
+
+	// Plain "github/" still resolves across all repositories
+	sourceName, templateName, ok = sm.ParseSourcePrefix("github/go")
+	if !ok || sourceName != "github" || templateName != "go" {
+		t.Errorf("ParseSourcePrefix(github/go) = (%q, %q, %v)", sourceName, templateName, ok)
+	}
+}
+
+func TestGetFromSource_EarlierRepoWins(t *testing.T) {
+	sm := &SourceManager{
+		sources: []Source{
+			&mockSource{
+				name:    "github",
+				files:   []TemplateFile{{Name: "Go", Source: "github"}},
+				content: map[string]string{"Go": "# Company Go"},
+			},
+			&mockSource{
+				name:    "github",
+				files:   []TemplateFile{{Name: "Go", Source: "github"}, {Name: "Rust", Source: "github"}},
+				content: map[string]string{"Go": "# Public Go", "Rust": "# Public Rust"},
+			},
+		},
+	}
+
+	_, content, err := sm.GetFromSource("github", "Go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content != "# Company Go" {
+		t.Errorf("expected first repository to win, got %q", content)
+	}
+
+	// Falls through to later repositories when the first lacks the template
+	_, content, err = sm.GetFromSource("github", "Rust")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if content != "# Public Rust" {
+		t.Errorf("expected fallback to second repository, got %q", content)
+	}
+}
+
+func TestGetAny(t *testing.T) {
+	localDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(localDir, "foo.gitignore"), []byte("# Local foo"), 0644); err != nil {
+		t.Fatalf("failed to create local template: %v", err)
+	}
+	local := NewLocalSourceWithDir(localDir)
+
+	github := &mockSource{
+		name: "github",
+		files: []TemplateFile{
+			{Name: "rust", Source: "github"},
+			{Name: "python", Source: "github"},
+			{Name: "macos", Category: "global", Source: "github"},
+		},
+		content: map[string]string{
+			"rust":         "# GitHub rust",
+			"python":       "# GitHub python",
+			"global/macos": "# GitHub macos",
+		},
+	}
+	toptal := &mockSource{
+		name:    "toptal",
+		files:   []TemplateFile{{Name: "python", Source: "toptal"}, {Name: "foo", Source: "toptal"}},
+		content: map[string]string{"python": "# Toptal python", "foo": "# Toptal foo"},
+	}
+
+	sm := &SourceManager{
+		local:   local,
+		remote:  []Source{github, toptal},
+		sources: []Source{local, github, toptal},
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		wantContent string
+		wantErr     bool
+	}{
+		{name: "github prefix", input: "github/rust", wantContent: "# GitHub rust"},
+		{name: "toptal prefix", input: "toptal/python", wantContent: "# Toptal python"},
+		{name: "local prefix", input: "local/foo", wantContent: "# Local foo"},
+		{name: "github category path", input: "github/global/macos", wantContent: "# GitHub macos"},
+		{name: "prefix is case-insensitive", input: "GitHub/rust", wantContent: "# GitHub rust"},
+		{name: "no prefix uses priority", input: "python", wantContent: "# GitHub python"},
+		{name: "no prefix prefers local", input: "foo", wantContent: "# Local foo"},
+		{name: "category path without prefix", input: "global/macos", wantContent: "# GitHub macos"},
+		{name: "prefix does not fall back to other sources", input: "toptal/rust", wantErr: true},
+		{name: "unknown prefix is part of the name", input: "nosuch/rust", wantErr: true},
+		{name: "missing everywhere", input: "cobol", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, content, err := sm.GetAny(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("GetAny(%q) expected error, got content %q", tt.input, content)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAny(%q) unexpected error: %v", tt.input, err)
+			}
+			if content != tt.wantContent {
+				t.Errorf("GetAny(%q) content = %q, want %q", tt.input, content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestParseSourcePrefix(t *testing.T) {
+	sm := &SourceManager{
+		sources: []Source{
+			&mockSource{name: "local"},
+			&mockSource{name: "github"},
+			&mockSource{name: "toptal"},
+		},
+	}
+
+	tests := []struct {
+		input      string
+		wantSource string
+		wantName   string
+		wantPrefix bool
+	}{
+		{"github/rust", "github", "rust", true},
+		{"github/global/macos", "github", "global/macos", true},
+		{"toptal/python", "toptal", "python", true},
+		{"local/foo", "local", "foo", true},
+		{"Toptal/python", "toptal", "python", true},
+		{"go", "", "go", false},
+		{"global/macos", "", "global/macos", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			source, name, hasPrefix := sm.ParseSourcePrefix(tt.input)
+			if source != tt.wantSource || name != tt.wantName || hasPrefix != tt.wantPrefix {
+				t.Errorf("ParseSourcePrefix(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.input, source, name, hasPrefix, tt.wantSource, tt.wantName, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestListBySourceFrom_OnlyQueriesNamedSources(t *testing.T) {
+	// The github source would fail if queried; scoping to local must not touch it
+	sm := &SourceManager{
+		sources: []Source{
+			&mockSource{name: "local", files: []TemplateFile{{Name: "Custom"}}},
+			&mockSource{name: "github", listErr: errors.New("network unreachable")},
+			&mockSource{name: "toptal", files: []TemplateFile{{Name: "Node"}}},
+		},
+	}
+
+	result, err := sm.ListBySourceFrom([]string{"local"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 1 {
+		t.Fatalf("expected only 1 source queried, got %d", len(result))
+	}
+	if _, ok := result["local"]; !ok {
+		t.Error("expected local source in result")
+	}
+
+	result, err = sm.ListBySourceFrom([]string{"github", "toptal"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := result["local"]; ok {
+		t.Error("local source should not be queried")
+	}
+	if len(result) != 2 {
+		t.Errorf("expected 2 sources queried, got %d", len(result))
+	}
+
+	// nil queries everything, like ListBySource
+	result, err = sm.ListBySourceFrom(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 3 {
+		t.Errorf("expected 3 sources queried, got %d", len(result))
+	}
+}
+
+func TestHasSource(t *testing.T) {
+	sm := &SourceManager{
+		sources: []Source{&mockSource{name: "local"}, &mockSource{name: "github"}},
+	}
+	if !sm.HasSource("github") {
+		t.Error("expected github to be configured")
+	}
+	if !sm.HasSource("GitHub") {
+		t.Error("expected source lookup to be case-insensitive")
+	}
+	if sm.HasSource("toptal") {
+		t.Error("toptal should not be configured")
+	}
+}
+
+func TestOffline_SkipsRemoteSources(t *testing.T) {
+	localDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(localDir, "Custom.gitignore"), []byte("# Custom"), 0644); err != nil {
+		t.Fatalf("failed to create local template: %v", err)
+	}
+	local := NewLocalSourceWithDir(localDir)
+
+	// The remote would succeed if queried; offline must never reach it
+	remote := &mockSource{
+		name:    "github",
+		files:   []TemplateFile{{Name: "Go", Source: "github"}},
+		content: map[string]string{"Go": "# Go"},
+	}
+	sm := &SourceManager{
+		local:   local,
+		remote:  []Source{remote},
+		sources: []Source{local, remote},
+	}
+	WithOffline(true)(sm)
+
+	result, err := sm.ListBySource()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := result["github"]; ok {
+		t.Error("remote source should not be listed while offline")
+	}
+	if len(result["local"].Files) != 1 {
+		t.Errorf("expected local templates while offline, got %v", result["local"])
+	}
+
+	if _, _, err := sm.Get("Custom"); err != nil {
+		t.Errorf("local template should resolve offline: %v", err)
+	}
+
+	for _, name := range []string{"Go", "github/Go"} {
+		_, _, err := sm.GetAny(name)
+		if !errors.Is(err, ErrOffline) {
+			t.Errorf("GetAny(%q) error = %v, want ErrOffline", name, err)
+		}
+	}
+
+	if _, err := sm.Find("Go"); !errors.Is(err, ErrOffline) {
+		t.Errorf("Find() error = %v, want ErrOffline", err)
+	}
+}
+
+func TestNewSourceManagerWithOrder(t *testing.T) {
+	localDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(localDir, "Go.gitignore"), []byte("# Local Go"), 0644); err != nil {
+		t.Fatalf("failed to create local template: %v", err)
+	}
+	first := NewMemorySource("first", map[string]string{"Go": "# First Go", "Node": "# First Node"})
+	second := NewMemorySource("second", map[string]string{"Node": "# Second Node"})
+
+	var warnings bytes.Buffer
+	logging.SetOutput(&warnings)
+	defer logging.SetOutput(nil)
+
+	sm, err := NewSourceManagerWithOrder(localDir, "https://github.com/github/gitignore", true,
+		[]string{"second", "BitBucket", "local"}, WithSources(first, second))
+	if err != nil {
+		t.Fatalf("NewSourceManagerWithOrder() error: %v", err)
+	}
+
+	// Listed sources first, then the rest in default order
+	want := "second local first github toptal builtin"
+	if got := strings.Join(sm.SourceNames(), " "); got != want {
+		t.Errorf("SourceNames() = %q, want %q", got, want)
+	}
+	if remote := sm.RemoteSources(); remote[0].Name() != "github" || remote[1].Name() != "toptal" {
+		t.Errorf("unexpected remote order: %v, %v", remote[0].Name(), remote[1].Name())
+	}
+	if !strings.Contains(warnings.String(), "BitBucket") || strings.Count(warnings.String(), "Warning") != 1 {
+		t.Errorf("expected one warning for the unknown source, got %q", warnings.String())
+	}
+
+	if _, content, _ := sm.Get("Node"); content != "# Second Node" {
+		t.Errorf("Get(Node) = %q, want second source to win", content)
+	}
+	if _, content, _ := sm.Get("Go"); content != "# Local Go" {
+		t.Errorf("Get(Go) = %q, want local to win over first", content)
+	}
+	if file, _ := sm.Find("node"); file == nil || file.Source != "second" {
+		t.Errorf("Find(node) = %+v, want second source", file)
+	}
+}
+
+func TestListRespectsOrder(t *testing.T) {
+	local := NewLocalSourceWithDir(t.TempDir())
+	github := &mockSource{name: "github", files: []TemplateFile{{Name: "Go", Source: "github"}}}
+	toptal := &mockSource{name: "toptal", files: []TemplateFile{{Name: "go", Source: "toptal"}}}
+	sm := &SourceManager{
+		local:   local,
+		remote:  []Source{github, toptal},
+		sources: []Source{local, github, toptal},
+	}
+	sm.applyOrder([]string{"toptal"})
+
+	files, err := sm.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(files) != 1 || files[0].Source != "toptal" {
+		t.Errorf("List() = %+v, want only toptal's go, listed before github's", files)
+	}
+}
+
+func TestListDedupesAcrossSources(t *testing.T) {
+	localDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(localDir, "Go.gitignore"), []byte("# local"), 0644); err != nil {
+		t.Fatalf("failed to create local template: %v", err)
+	}
+	local := NewLocalSourceWithDir(localDir)
+	github := &mockSource{name: "github", files: []TemplateFile{
+		{Name: "Go", Source: "github"},
+		{Name: "macOS", Category: "Global", Source: "github"},
+	}}
+	toptal := &mockSource{name: "toptal", files: []TemplateFile{{Name: "macos", Source: "toptal"}}}
+	sm := &SourceManager{
+		local:   local,
+		remote:  []Source{github, toptal},
+		sources: []Source{local, github, toptal},
+	}
+	sm.applyOrder([]string{"github", "local"})
+
+	files, err := sm.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	var got []string
+	for _, f := range files {
+		got = append(got, f.Source+":"+path.Join(f.Category, f.Name))
+	}
+	// Local's Go is dropped in favour of github's; a different category is kept
+	if want := "github:Go github:Global/macOS toptal:macos"; strings.Join(got, " ") != want {
+		t.Errorf("List() = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestListPartialResults(t *testing.T) {
+	// A file in place of the local directory makes the local source fail
+	notDir := filepath.Join(t.TempDir(), "templates")
+	if err := os.WriteFile(notDir, []byte(""), 0644); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	local := NewLocalSourceWithDir(notDir)
+	github := &mockSource{name: "github", listErr: errors.New("rate limited")}
+	toptal := &mockSource{name: "toptal", files: []TemplateFile{{Name: "Go", Source: "toptal"}}}
+	sm := &SourceManager{
+		local:   local,
+		remote:  []Source{github, toptal},
+		sources: []Source{local, github, toptal},
+	}
+
+	var warnings bytes.Buffer
+	logging.SetOutput(&warnings)
+	defer logging.SetOutput(nil)
+
+	files, err := sm.List()
+	if err != nil {
+		t.Fatalf("List() should not fail when some sources fail, got: %v", err)
+	}
+	if len(files) != 1 || files[0].Source != "toptal" {
+		t.Errorf("List() = %+v, want only the toptal template", files)
+	}
+	got := warnings.String()
+	if strings.Count(got, "Warning") != 1 || !strings.Contains(got, "local:") || !strings.Contains(got, "github: rate limited") {
+		t.Errorf("expected one aggregated warning naming both failures, got %q", got)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	localDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(localDir, "Go.patch.gitignore"), []byte("/local-bin/\n"), 0644); err != nil {
+		t.Fatalf("failed to create patch: %v", err)
+	}
+	sm, err := NewSourceManager(localDir, "", false)
+	if err != nil {
+		t.Fatalf("NewSourceManager() error: %v", err)
+	}
+
+	got, err := sm.ApplyPatch(&TemplateFile{Name: "Go", Source: "github"}, "*.exe\n")
+	if err != nil {
+		t.Fatalf("ApplyPatch() error: %v", err)
+	}
+	want := "*.exe\n\n# --- Local additions from Go.patch.gitignore ---\n/local-bin/\n"
+	if got != want {
+		t.Errorf("ApplyPatch() = %q, want %q", got, want)
+	}
+
+	got, err = sm.ApplyPatch(&TemplateFile{Name: "Node", Source: "github"}, "node_modules/\n")
+	if err != nil || got != "node_modules/\n" {
+		t.Errorf("ApplyPatch() without a patch = %q, %v; want content unchanged", got, err)
+	}
+}
+
+func TestWithAliases(t *testing.T) {
+	mem := NewMemorySource("embedded", map[string]string{
+		"Global/VisualStudioCode": "# VS Code",
+		"Go":                      "# Go",
+	})
+	sm, err := NewSourceManager(t.TempDir(), "", false, WithSources(mem),
+		WithAliases(map[string]string{"VSCode": "embedded/Global/VisualStudioCode", "golang": "go"}))
+	if err != nil {
+		t.Fatalf("NewSourceManager() error: %v", err)
+	}
+
+	file, content, err := sm.GetAny("vscode")
+	if err != nil || content != "# VS Code" || file.Category != "Global" {
+		t.Errorf("GetAny(vscode) = %+v, %q, %v", file, content, err)
+	}
+	if _, content, err := sm.GetAny("golang"); err != nil || content != "# Go" {
+		t.Errorf("GetAny(golang) = %q, %v", content, err)
+	}
+	if got := sm.ResolveAlias("python"); got != "python" {
+		t.Errorf("ResolveAlias(python) = %q, want unchanged", got)
+	}
+}
+
+func TestWithSources(t *testing.T) {
+	localDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(localDir, "Go.gitignore"), []byte("# Local Go"), 0644); err != nil {
+		t.Fatalf("failed to create local template: %v", err)
+	}
+
+	mem := NewMemorySource("embedded", map[string]string{
+		"Go":     "# Embedded Go",
+		"Python": "# Embedded Python",
+	})
+	sm, err := NewSourceManager(localDir, "", false, WithSources(mem), WithOffline(true))
+	if err != nil {
+		t.Fatalf("NewSourceManager() error: %v", err)
+	}
+
+	names := sm.SourceNames()
+	if len(names) != 3 || names[0] != "local" || names[1] != "embedded" || names[2] != BuiltinSourceName {
+		t.Errorf("SourceNames() = %v, want [local embedded builtin]", names)
+	}
+
+	// Local still takes precedence, and custom sources work offline
+	if _, content, _ := sm.Get("Go"); content != "# Local Go" {
+		t.Errorf("Get(Go) = %q, want local content", content)
+	}
+	if _, content, err := sm.Get("Python"); err != nil || content != "# Embedded Python" {
+		t.Errorf("Get(Python) = %q, %v", content, err)
+	}
+	if _, content, err := sm.GetAny("embedded/Go"); err != nil || content != "# Embedded Go" {
+		t.Errorf("GetAny(embedded/Go) = %q, %v", content, err)
+	}
+	if _, err := sm.Find("python"); err != nil {
+		t.Errorf("Find(python) error: %v", err)
+	}
+
+	result, _ := sm.ListBySource()
+	if len(result["embedded"].Files) != 2 {
+		t.Errorf("expected embedded templates to be listed, got %v", result["embedded"])
+	}
+}
+
+func TestBuiltinSource(t *testing.T) {
+	down := errors.New("network is down")
+	github := &mockSource{name: "github", listErr: down, getErr: down, findErr: down}
+	sm, err := NewSourceManager(t.TempDir(), "", false, WithSources(github))
+	if err != nil {
+		t.Fatalf("NewSourceManager() error: %v", err)
+	}
+
+	// With every other source failing, the embedded templates still serve
+	for _, name := range []string{"go", "Node", "python", "macos", "Global/Windows", "visualstudiocode"} {
+		file, content, err := sm.Get(name)
+		if err != nil {
+			t.Errorf("Get(%s) error: %v", name, err)
+			continue
+		}
+		if file.Source != BuiltinSourceName || content == "" {
+			t.Errorf("Get(%s) = %s from %s, want builtin content", name, file.Path, file.Source)
+		}
+	}
+	if file, _, err := sm.Get("macos"); err == nil && file.Category != "Global" {
+		t.Errorf("Get(macos) category = %q, want Global", file.Category)
+	}
+
+	files, err := sm.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(files) != 6 {
+		t.Errorf("List() returned %d templates, want the 6 built-in ones", len(files))
+	}
+
+	// Offline, the embedded templates are still used
+	sm.offline = true
+	if _, _, err := sm.Get("go"); err != nil {
+		t.Errorf("offline Get(go) error: %v", err)
+	}
+}
+
+func TestBlocklist(t *testing.T) {
+	corp := NewMemorySource("corp", map[string]string{
+		"Go":                    "# corp go",
+		"community/Golang/Hugo": "public/\n",
+	})
+	public := NewMemorySource("public", map[string]string{"Go": "# public go", "Rust": "target/\n"})
+	sm, err := NewSourceManager(t.TempDir(), "", false, WithSources(corp, public),
+		WithBlocklist([]string{"corp/community/*", "CORP/go", "public/r?st"}))
+	if err != nil {
+		t.Fatalf("NewSourceManager() error: %v", err)
+	}
+
+	// A blocked template is skipped in favor of the next source
+	if _, content, err := sm.Get("go"); err != nil || content != "# public go" {
+		t.Errorf("Get(go) = %q, %v, want the public template", content, err)
+	}
+	if file, err := sm.Find("go"); err != nil || file.Source != "public" {
+		t.Errorf("Find(go) = %+v, %v, want the public template", file, err)
+	}
+
+	for _, name := range []string{"rust", "corp/go", "corp/community/golang/hugo"} {
+		_, _, err := sm.GetAny(name)
+		if !errors.Is(err, ErrBlocked) || !strings.Contains(err.Error(), "gitignore.blocklist") {
+			t.Errorf("GetAny(%s) error = %v, want ErrBlocked", name, err)
+		}
+		if _, _, err := sm.Which(name); !errors.Is(err, ErrBlocked) {
+			t.Errorf("Which(%s) error = %v, want ErrBlocked", name, err)
+		}
+	}
+
+	result, _ := sm.ListBySource()
+	if files := result["corp"].Files; len(files) != 0 {
+		t.Errorf("expected blocked corp templates to be hidden, got %+v", files)
+	}
+	if files := result["public"].Files; len(files) != 1 || files[0].Name != "Go" {
+		t.Errorf("expected only public/go to be listed, got %+v", files)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	slow := NewToptalSourceWithURL(server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sm, err := NewSourceManager(t.TempDir(), "", false, WithSources(slow), WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := slow.List()
+		done <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(sm.Pending()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := sm.Pending(); len(got) != 1 || got[0] != "toptal" {
+		t.Fatalf("Pending() = %v, want [toptal]", got)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("List() after cancel error = %v, want context.Canceled", err)
+	}
+	// The request was cut short, so the source is still reported
+	if got := sm.Pending(); len(got) != 1 || got[0] != "toptal" {
+		t.Errorf("Pending() after cancel = %v, want [toptal]", got)
+	}
+	// and no other source is consulted
+	if _, _, err := sm.Get("go"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Get() after cancel error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,85 @@
+// Package source provides abstraction for different gitignore template sources
+package source
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MemorySource serves templates from an in-memory map
+// It is useful for tests and for programs that embed their own template set
+type MemorySource struct {
+	name      string
+	templates map[string]string
+}
+
+// NewMemorySource creates a source named name from a map of template paths to
+// content. A path may include a category, e.g. "Global/macOS"
+// The map is copied, so later changes to it are not seen by the source
+func NewMemorySource(name string, templates map[string]string) *MemorySource {
+	copied := make(map[string]string, len(templates))
+	for path, content := range templates {
+		copied[path] = content
+	}
+	return &MemorySource{name: name, templates: copied}
+}
+
+// Name returns the source name
+func (s *MemorySource) Name() string {
+	return s.name
+}
+
+// List returns all templates, sorted by path
+func (s *MemorySource) List() ([]TemplateFile, error) {
+	paths := make([]string, 0, len(s.templates))
+	for path := range s.templates {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	files := make([]TemplateFile, 0, len(paths))
+	for _, path := range paths {
+		files = append(files, s.templateFile(path))
+	}
+	return files, nil
+}
+
+// Get returns the content of a template by name
+func (s *MemorySource) Get(name string) (*TemplateFile, string, error) {
+	file, err := s.Find(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return file, s.templates[file.Path], nil
+}
+
+// Find finds a template by name or category/name (case-insensitive)
+// A bare name match is preferred over a category path match
+func (s *MemorySource) Find(name string) (*TemplateFile, error) {
+	files, _ := s.List()
+
+	nameLower := strings.ToLower(name)
+	for _, file := range files {
+		if strings.ToLower(file.Name) == nameLower {
+			return &file, nil
+		}
+	}
+	for _, file := range files {
+		if strings.ToLower(file.Path) == nameLower {
+			return &file, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%s template '%s' not found", s.name, name)
+}
+
+// templateFile describes the template stored under path
+func (s *MemorySource) templateFile(path string) TemplateFile {
+	file := TemplateFile{Name: path, Path: path, Source: s.name}
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		file.Category = path[:i]
+		file.Name = path[i+1:]
+	}
+	return file
+}
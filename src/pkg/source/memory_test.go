@@ -0,0 +1,48 @@
+package source
+
+import (
+	"testing"
+)
+
+func TestMemorySource(t *testing.T) {
+	templates := map[string]string{
+		"Go":           "*.exe\n",
+		"Global/macOS": ".DS_Store\n",
+	}
+	mem := NewMemorySource("embedded", templates)
+	templates["Go"] = "changed"
+
+	var _ Source = mem
+	if mem.Name() != "embedded" {
+		t.Errorf("Name() = %q, want embedded", mem.Name())
+	}
+
+	files, err := mem.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 templates, got %d", len(files))
+	}
+	if files[0].Name != "macOS" || files[0].Category != "Global" || files[0].Source != "embedded" {
+		t.Errorf("unexpected category template: %+v", files[0])
+	}
+
+	file, content, err := mem.Get("go")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if file.Name != "Go" || content != "*.exe\n" {
+		t.Errorf("Get(go) = %+v %q, want the original Go content", file, content)
+	}
+
+	for _, name := range []string{"macos", "global/macos"} {
+		if _, content, err := mem.Get(name); err != nil || content != ".DS_Store\n" {
+			t.Errorf("Get(%q) = %q, %v", name, content, err)
+		}
+	}
+
+	if _, err := mem.Find("rust"); err == nil {
+		t.Error("Find() expected error for missing template")
+	}
+}
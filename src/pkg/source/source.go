@@ -2,10 +2,15 @@
 package source
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/polliard/gitignore/src/pkg/github"
 )
 
 // TemplateFile represents a gitignore template
@@ -14,6 +19,7 @@ type TemplateFile struct {
 	Path     string
 	Category string
 	Source   string // identifies which source this came from (local, github, toptal)
+	Ref      string // pinned branch, tag or commit for GitHub templates ("" = default branch)
 }
 
 // Source is the interface that all template sources must implement
@@ -28,6 +34,67 @@ type Source interface {
 	Find(name string) (*TemplateFile, error)
 }
 
+// Refresher is implemented by sources that cache what they fetch, such as
+// the Toptal template list; Refresh drops the cached data so the next List or
+// Get fetches it again, whatever its TTL
+type Refresher interface {
+	Refresh()
+}
+
+// Canceler is implemented by sources that make network requests, such as
+// GitHub and Toptal; SetContext sets the context their requests run under
+// and Pending reports whether one is still in flight
+type Canceler interface {
+	SetContext(ctx context.Context)
+	Pending() bool
+}
+
+// Refresh invalidates the caches of source if it has any (see Refresher);
+// for other sources it does nothing
+func Refresh(source Source) {
+	if r, ok := source.(Refresher); ok {
+		r.Refresh()
+	}
+}
+
+// Categories returns the distinct non-empty categories of files, sorted
+// case-insensitively. Categories differing only in case are reported once,
+// using the first spelling seen
+func Categories(files []TemplateFile) []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, file := range files {
+		key := strings.ToLower(file.Category)
+		if file.Category == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		categories = append(categories, file.Category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return strings.ToLower(categories[i]) < strings.ToLower(categories[j])
+	})
+	return categories
+}
+
+// FilterCategory returns the files in category (case-insensitive), including
+// those in nested categories such as "community/Golang" for "community"
+func FilterCategory(files []TemplateFile, category string) []TemplateFile {
+	category = strings.ToLower(strings.Trim(category, "/"))
+	var filtered []TemplateFile
+	for _, file := range files {
+		c := strings.ToLower(file.Category)
+		if c == category || strings.HasPrefix(c, category+"/") {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
+
+// PatchSuffix marks a local file that extends a template rather than
+// replacing it, e.g. Go.patch.gitignore is appended to the Go template
+const PatchSuffix = ".patch.gitignore"
+
 // LocalSource handles templates from ~/.config/gitignore/
 type LocalSource struct {
 	dir string
@@ -59,35 +126,53 @@ func (l *LocalSource) Dir() string {
 	return l.dir
 }
 
-// List returns all local templates
+// List returns all local templates, including those in subdirectories, whose
+// relative directory becomes the category (web/react.gitignore is "react" in
+// category "web"); hidden directories such as .git are skipped
 func (l *LocalSource) List() ([]TemplateFile, error) {
 	var files []TemplateFile
 
-	entries, err := os.ReadDir(l.dir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return files, nil // Return empty list if directory doesn't exist
+	err := filepath.WalkDir(l.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if path == l.dir && !entry.IsDir() {
+			return fmt.Errorf("%s is not a directory", path)
 		}
-		return nil, fmt.Errorf("failed to read local templates directory: %w", err)
-	}
-
-	for _, entry := range entries {
 		if entry.IsDir() {
-			continue
+			if path != l.dir && strings.HasPrefix(name, ".") {
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
-		name := entry.Name()
 		if !strings.HasSuffix(strings.ToLower(name), ".gitignore") {
-			continue
+			return nil
+		}
+		// Patch files extend other templates and are not templates themselves
+		if strings.HasSuffix(strings.ToLower(name), PatchSuffix) {
+			return nil
 		}
 
-		templateName := strings.TrimSuffix(name, ".gitignore")
+		rel, err := filepath.Rel(l.dir, path)
+		if err != nil {
+			return err
+		}
+		file := github.ParseGitignorePath(filepath.ToSlash(rel))
 		files = append(files, TemplateFile{
-			Name:     templateName,
-			Path:     filepath.Join(l.dir, name),
-			Category: "",
+			Name:     file.Name,
+			Path:     path,
+			Category: file.Category,
 			Source:   "local",
 		})
+		return nil
+	})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return files, nil // Return empty list if directory doesn't exist
+		}
+		return nil, fmt.Errorf("failed to read local templates directory: %w", err)
 	}
 
 	return files, nil
@@ -108,7 +193,9 @@ func (l *LocalSource) Get(name string) (*TemplateFile, string, error) {
 	return file, string(content), nil
 }
 
-// Find finds a template by name (case-insensitive)
+// Find finds a template by name or category/name (case-insensitive)
+// A bare name prefers a template at the top of the directory over one in a
+// subdirectory
 func (l *LocalSource) Find(name string) (*TemplateFile, error) {
 	files, err := l.List()
 	if err != nil {
@@ -116,15 +203,52 @@ func (l *LocalSource) Find(name string) (*TemplateFile, error) {
 	}
 
 	nameLower := strings.ToLower(name)
+	for _, file := range files {
+		if file.Category == "" && strings.ToLower(file.Name) == nameLower {
+			return &file, nil
+		}
+	}
 	for _, file := range files {
 		if strings.ToLower(file.Name) == nameLower {
 			return &file, nil
 		}
 	}
+	for _, file := range files {
+		if file.Category != "" && strings.ToLower(file.Category+"/"+file.Name) == nameLower {
+			return &file, nil
+		}
+	}
 
 	return nil, fmt.Errorf("local template '%s' not found", name)
 }
 
+// Patch returns the content of the local patch file for a template name
+// (<name>.patch.gitignore, matched case-insensitively) and its path
+// An empty path means there is no patch
+func (l *LocalSource) Patch(name string) (content, path string, err error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("failed to read local templates directory: %w", err)
+	}
+
+	want := strings.ToLower(name + PatchSuffix)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.ToLower(entry.Name()) != want {
+			continue
+		}
+		path = filepath.Join(l.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read local patch: %w", err)
+		}
+		return string(data), path, nil
+	}
+	return "", "", nil
+}
+
 // Exists checks if the local templates directory exists
 func (l *LocalSource) Exists() bool {
 	_, err := os.Stat(l.dir)
@@ -135,3 +259,37 @@ func (l *LocalSource) Exists() bool {
 func (l *LocalSource) EnsureDir() error {
 	return os.MkdirAll(l.dir, 0755)
 }
+
+// Create writes a new template <name>.gitignore, creating the directory if
+// needed, and returns its path
+// It fails if a template of that name exists (case-insensitive) unless force
+// is set, in which case the existing file is overwritten
+func (l *LocalSource) Create(name, content string, force bool) (string, error) {
+	name = strings.TrimSpace(name)
+	if strings.HasSuffix(strings.ToLower(name), ".gitignore") {
+		name = name[:len(name)-len(".gitignore")]
+	}
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid template name '%s'", name)
+	}
+	if strings.HasSuffix(strings.ToLower(name), ".patch") {
+		return "", fmt.Errorf("invalid template name '%s': %s files are patches", name, PatchSuffix)
+	}
+
+	if err := l.EnsureDir(); err != nil {
+		return "", fmt.Errorf("failed to create local templates directory: %w", err)
+	}
+
+	path := filepath.Join(l.dir, name+".gitignore")
+	if existing, err := l.Find(name); err == nil {
+		if !force {
+			return "", fmt.Errorf("local template '%s' already exists: %s", existing.Name, existing.Path)
+		}
+		path = existing.Path
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write local template: %w", err)
+	}
+	return path, nil
+}
@@ -160,6 +160,66 @@ func TestLocalSourceFind(t *testing.T) {
 	}
 }
 
+func TestLocalSourceNested(t *testing.T) {
+	tmpDir := t.TempDir()
+	templates := map[string]string{
+		"React.gitignore":              "# top-level React\n",
+		"web/react.gitignore":          "node_modules/\n",
+		"web/frameworks/Vue.gitignore": "dist/\n",
+		"web/Go.patch.gitignore":       "not a template\n",
+		".git/objects/Fake.gitignore":  "not a template\n",
+		"infra/terraform.gitignore":    ".terraform/\n",
+		"infra/README.md":              "# notes\n",
+	}
+	for name, content := range templates {
+		path := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	local := NewLocalSourceWithDir(tmpDir)
+	files, err := local.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	got := make(map[string]bool)
+	for _, f := range files {
+		got[f.Category+"|"+f.Name] = true
+	}
+	for _, want := range []string{"|React", "web|react", "web/frameworks|Vue", "infra|terraform"} {
+		if !got[want] {
+			t.Errorf("List() is missing %q, got %v", want, got)
+		}
+	}
+	if len(files) != 4 {
+		t.Errorf("expected 4 templates, got %d: %v", len(files), got)
+	}
+
+	for name, want := range map[string]string{
+		"web/react":          "node_modules/\n",
+		"WEB/Frameworks/vue": "dist/\n",
+		"terraform":          ".terraform/\n",
+		"react":              "# top-level React\n", // the flat template wins
+	} {
+		_, content, err := local.Get(name)
+		if err != nil {
+			t.Errorf("Get(%q) error: %v", name, err)
+			continue
+		}
+		if content != want {
+			t.Errorf("Get(%q) = %q, want %q", name, content, want)
+		}
+	}
+
+	if _, err := local.Find("frameworks/vue"); err == nil {
+		t.Error("Find() should require the full category path")
+	}
+}
+
 func TestLocalSourceName(t *testing.T) {
 	local := NewLocalSourceWithDir("/tmp")
 	if local.Name() != "local" {
@@ -167,6 +227,76 @@ func TestLocalSourceName(t *testing.T) {
 	}
 }
 
+func TestCategories(t *testing.T) {
+	files := []TemplateFile{
+		{Name: "Go"},
+		{Name: "macOS", Category: "Global"},
+		{Name: "Linux", Category: "global"},
+		{Name: "Hugo", Category: "community/Golang"},
+		{Name: "Ansible", Category: "community"},
+	}
+
+	got := Categories(files)
+	want := []string{"community", "community/Golang", "Global"}
+	if len(got) != len(want) {
+		t.Fatalf("Categories() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Categories()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if global := FilterCategory(files, "GLOBAL"); len(global) != 2 {
+		t.Errorf("FilterCategory(GLOBAL) = %v, want macOS and Linux", global)
+	}
+	if community := FilterCategory(files, "community/"); len(community) != 2 {
+		t.Errorf("FilterCategory(community) = %v, want nested categories included", community)
+	}
+	if none := FilterCategory(files, "Glob"); len(none) != 0 {
+		t.Errorf("FilterCategory(Glob) = %v, want no partial matches", none)
+	}
+}
+
+func TestLocalSourcePatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"Go.patch.gitignore": "# mine\n/vendor-local/\n",
+		"Custom.gitignore":   "# Custom\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+	}
+
+	source := NewLocalSourceWithDir(tmpDir)
+
+	// Patch files are not listed as templates
+	templates, err := source.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(templates) != 1 || templates[0].Name != "Custom" {
+		t.Errorf("List() = %v, want only Custom", templates)
+	}
+
+	content, path, err := source.Patch("go")
+	if err != nil {
+		t.Fatalf("Patch() error: %v", err)
+	}
+	if path != filepath.Join(tmpDir, "Go.patch.gitignore") || content != files["Go.patch.gitignore"] {
+		t.Errorf("Patch(go) = %q, %q", content, path)
+	}
+
+	if _, path, err := source.Patch("Custom"); err != nil || path != "" {
+		t.Errorf("Patch(Custom) = %q, %v; want no patch", path, err)
+	}
+	if _, path, err := NewLocalSourceWithDir(filepath.Join(tmpDir, "missing")).Patch("Go"); err != nil || path != "" {
+		t.Errorf("Patch() in missing dir = %q, %v; want no patch", path, err)
+	}
+}
+
 func TestLocalSourceDir(t *testing.T) {
 	dir := "/some/test/path"
 	local := NewLocalSourceWithDir(dir)
@@ -206,3 +336,40 @@ func TestLocalSourceEnsureDir(t *testing.T) {
 		t.Error("directory should exist after EnsureDir()")
 	}
 }
+
+func TestLocalSourceCreate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "templates")
+	local := NewLocalSourceWithDir(dir)
+
+	path, err := local.Create("Foo", "# Foo\n", false)
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if path != filepath.Join(dir, "Foo.gitignore") {
+		t.Errorf("Create() path = %s", path)
+	}
+	if _, content, err := local.Get("foo"); err != nil || content != "# Foo\n" {
+		t.Errorf("Get() after Create() = %q, %v", content, err)
+	}
+
+	// An existing template, in any case, needs force
+	if _, err := local.Create("foo", "# other\n", false); err == nil {
+		t.Error("Create() should fail for an existing template")
+	}
+	path, err = local.Create("foo.gitignore", "# other\n", true)
+	if err != nil {
+		t.Fatalf("Create() with force error: %v", err)
+	}
+	if filepath.Base(path) != "Foo.gitignore" {
+		t.Errorf("Create() with force should overwrite Foo.gitignore, wrote %s", path)
+	}
+	if _, content, _ := local.Get("Foo"); content != "# other\n" {
+		t.Errorf("content after overwrite = %q", content)
+	}
+
+	for _, name := range []string{"", "a/b", "..", "Go.patch"} {
+		if _, err := local.Create(name, "x", true); err == nil {
+			t.Errorf("Create(%q) should fail", name)
+		}
+	}
+}
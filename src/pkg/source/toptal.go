@@ -2,34 +2,148 @@
 package source
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
+
+	"github.com/polliard/gitignore/src/pkg/github"
+	"github.com/polliard/gitignore/src/pkg/logging"
 )
 
+// DefaultToptalListTTL is how long a fetched template list is reused
+const DefaultToptalListTTL = 5 * time.Minute
+
+// ToptalCategories assigns categories to Toptal templates, which the API
+// lists without any, keyed by lowercase template name
+// Entries mirror GitHub's Global/ folder so that list output and --category
+// agree across sources; add to it as needed
+var ToptalCategories = map[string]string{
+	"archives":         "Global",
+	"backup":           "Global",
+	"diff":             "Global",
+	"dropbox":          "Global",
+	"eclipse":          "Global",
+	"emacs":            "Global",
+	"jetbrains":        "Global",
+	"kate":             "Global",
+	"libreoffice":      "Global",
+	"linux":            "Global",
+	"macos":            "Global",
+	"mercurial":        "Global",
+	"microsoftoffice":  "Global",
+	"netbeans":         "Global",
+	"notepadpp":        "Global",
+	"patch":            "Global",
+	"sublimetext":      "Global",
+	"svn":              "Global",
+	"tags":             "Global",
+	"textmate":         "Global",
+	"tortoisegit":      "Global",
+	"vagrant":          "Global",
+	"vim":              "Global",
+	"virtualenv":       "Global",
+	"visualstudiocode": "Global",
+	"windows":          "Global",
+	"xcode":            "Global",
+}
+
 // ToptalSource handles templates from the Toptal gitignore API
 type ToptalSource struct {
 	httpClient *http.Client
 	baseURL    string
+	userAgent  string
+	ctx        context.Context
+	pending    github.Pending
+
+	// The template list is memoized per instance so that Find and Get
+	// within one process don't refetch it on every lookup
+	mu       sync.Mutex
+	listTTL  time.Duration
+	cached   []TemplateFile
+	cachedAt time.Time
 }
 
 // NewToptalSource creates a new Toptal source with the default URL
 func NewToptalSource() *ToptalSource {
 	return &ToptalSource{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: github.NewHTTPClient(nil),
 		baseURL:    "https://www.toptal.com/developers/gitignore/api",
+		userAgent:  github.DefaultUserAgent,
+		listTTL:    DefaultToptalListTTL,
+		ctx:        context.Background(),
 	}
 }
 
 // NewToptalSourceWithURL creates a Toptal source with a custom base URL
 func NewToptalSourceWithURL(baseURL string) *ToptalSource {
 	return &ToptalSource{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: github.NewHTTPClient(nil),
 		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		userAgent:  github.DefaultUserAgent,
+		listTTL:    DefaultToptalListTTL,
+		ctx:        context.Background(),
+	}
+}
+
+// SetListTTL sets how long the fetched template list is reused
+// A TTL of zero or less disables caching
+func (t *ToptalSource) SetListTTL(ttl time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.listTTL = ttl
+	t.cached = nil
+}
+
+// SetUserAgent sets the User-Agent header sent with every request; an empty
+// value leaves it unchanged
+func (t *ToptalSource) SetUserAgent(userAgent string) {
+	if userAgent != "" {
+		t.userAgent = userAgent
+	}
+}
+
+// SetProxy routes requests through proxy; nil uses the environment's proxy
+// settings
+func (t *ToptalSource) SetProxy(proxy *url.URL) {
+	t.httpClient = github.NewHTTPClient(proxy)
+}
+
+// Refresh drops the cached template list, so the next lookup refetches it
+// even if the list TTL has not expired
+func (t *ToptalSource) Refresh() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cached = nil
+}
+
+// SetContext makes requests run under ctx, so canceling it aborts them; a
+// nil ctx restores context.Background
+func (t *ToptalSource) SetContext(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
 	}
+	t.ctx = ctx
+}
+
+// Pending reports whether a request is in flight
+func (t *ToptalSource) Pending() bool {
+	return t.pending.InFlight()
+}
+
+// get issues a GET request with the source's User-Agent and context
+func (t *ToptalSource) get(rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.pending.Do(t.httpClient, req)
 }
 
 // Name returns the source name
@@ -43,9 +157,31 @@ func (t *ToptalSource) BaseURL() string {
 }
 
 // List returns all available templates from Toptal
+// The result is cached for the source's list TTL
 func (t *ToptalSource) List() ([]TemplateFile, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cached != nil && time.Since(t.cachedAt) < t.listTTL {
+		logging.Debugf("toptal: using cached template list (%d entries)", len(t.cached))
+		return append([]TemplateFile(nil), t.cached...), nil
+	}
+
+	files, err := t.fetchList()
+	if err != nil {
+		return nil, err
+	}
+	if t.listTTL > 0 {
+		t.cached = files
+		t.cachedAt = time.Now()
+	}
+	return append([]TemplateFile(nil), files...), nil
+}
+
+// fetchList retrieves and parses the template list from the API
+func (t *ToptalSource) fetchList() ([]TemplateFile, error) {
 	listURL := fmt.Sprintf("%s/list", t.baseURL)
-	resp, err := t.httpClient.Get(listURL)
+	resp, err := t.get(listURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch Toptal template list: %w", err)
 	}
@@ -61,27 +197,28 @@ func (t *ToptalSource) List() ([]TemplateFile, error) {
 		return nil, fmt.Errorf("failed to read Toptal response: %w", err)
 	}
 
-	// Toptal returns a comma-separated or newline-separated list
-	content := string(body)
-	// Replace newlines with commas for consistent parsing
-	content = strings.ReplaceAll(content, "\n", ",")
-	content = strings.ReplaceAll(content, "\r", "")
+	return parseToptalList(string(body)), nil
+}
+
+// parseToptalList extracts template names from a list response
+// The API packs several names per line, separated by commas and/or padded
+// with spaces into columns, so any run of whitespace or commas is a separator
+func parseToptalList(body string) []TemplateFile {
+	names := strings.FieldsFunc(body, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
 
 	var files []TemplateFile
-	for _, name := range strings.Split(content, ",") {
-		name = strings.TrimSpace(name)
-		if name == "" {
-			continue
-		}
+	for _, name := range names {
 		files = append(files, TemplateFile{
 			Name:     name,
 			Path:     name,
-			Category: "",
+			Category: ToptalCategories[strings.ToLower(name)],
 			Source:   "toptal",
 		})
 	}
 
-	return files, nil
+	return files
 }
 
 // Get returns the content of a template by name
@@ -91,8 +228,8 @@ func (t *ToptalSource) Get(name string) (*TemplateFile, string, error) {
 		return nil, "", err
 	}
 
-	contentURL := fmt.Sprintf("%s/%s", t.baseURL, url.PathEscape(name))
-	resp, err := t.httpClient.Get(contentURL)
+	contentURL := fmt.Sprintf("%s/%s", t.baseURL, url.PathEscape(file.Path))
+	resp, err := t.get(contentURL)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to fetch Toptal template content: %w", err)
 	}
@@ -110,11 +247,14 @@ func (t *ToptalSource) Get(name string) (*TemplateFile, string, error) {
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to read Toptal template: %w", err)
 	}
+	if err := github.CheckTemplateContent(resp.Header.Get("Content-Type"), content); err != nil {
+		return nil, "", fmt.Errorf("failed to fetch Toptal template '%s': %w", name, err)
+	}
 
 	return file, string(content), nil
 }
 
-// Find finds a template by name (case-insensitive)
+// Find finds a template by name or category/name (case-insensitive)
 func (t *ToptalSource) Find(name string) (*TemplateFile, error) {
 	files, err := t.List()
 	if err != nil {
@@ -127,6 +267,22 @@ func (t *ToptalSource) Find(name string) (*TemplateFile, error) {
 			return &file, nil
 		}
 	}
+	for _, file := range files {
+		if file.Category != "" && strings.ToLower(file.Category+"/"+file.Name) == nameLower {
+			return &file, nil
+		}
+	}
+	// GitHub's Global/ folder holds more templates than ToptalCategories
+	// lists; when Toptal is consulted first, "global/ansible" should still
+	// resolve to the flat "ansible" instead of falling through to GitHub
+	if category, base, ok := strings.Cut(nameLower, "/"); ok && category == "global" {
+		for _, file := range files {
+			if file.Category == "" && strings.ToLower(file.Name) == base {
+				file.Category = "Global"
+				return &file, nil
+			}
+		}
+	}
 
 	return nil, fmt.Errorf("Toptal template '%s' not found", name)
 }
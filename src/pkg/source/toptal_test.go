@@ -0,0 +1,296 @@
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newToptalTestServer serves a fake Toptal API and counts list requests
+func newToptalTestServer(t *testing.T, listBody string, templates map[string]string) (*httptest.Server, *int32) {
+	t.Helper()
+	var listHits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		if name == "list" {
+			atomic.AddInt32(&listHits, 1)
+			fmt.Fprint(w, listBody)
+			return
+		}
+		content, ok := templates[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	t.Cleanup(server.Close)
+	return server, &listHits
+}
+
+func TestToptalSourceListCached(t *testing.T) {
+	server, listHits := newToptalTestServer(t, "go,node\nrust", map[string]string{"go": "# go"})
+	toptal := NewToptalSourceWithURL(server.URL)
+
+	for i := 0; i < 3; i++ {
+		files, err := toptal.List()
+		if err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		if len(files) != 3 {
+			t.Fatalf("expected 3 templates, got %d", len(files))
+		}
+	}
+	if _, err := toptal.Find("node"); err != nil {
+		t.Fatalf("Find() error: %v", err)
+	}
+	if _, _, err := toptal.Get("go"); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+
+	if hits := atomic.LoadInt32(listHits); hits != 1 {
+		t.Errorf("expected list endpoint to be fetched once, got %d", hits)
+	}
+}
+
+func TestToptalSourceListReturnsCopy(t *testing.T) {
+	server, _ := newToptalTestServer(t, "go,node", nil)
+	toptal := NewToptalSourceWithURL(server.URL)
+
+	// Changing the first (cache-miss) result must not change the cache
+	files, err := toptal.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	files[0].Name = "changed"
+
+	files, err = toptal.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if files[0].Name == "changed" {
+		t.Error("List() returned the cached slice itself")
+	}
+}
+
+func TestToptalSourceRefresh(t *testing.T) {
+	server, listHits := newToptalTestServer(t, "go", nil)
+	toptal := NewToptalSourceWithURL(server.URL)
+
+	for i := 0; i < 2; i++ {
+		if _, err := toptal.List(); err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+		Refresh(toptal)
+	}
+	if hits := atomic.LoadInt32(listHits); hits != 2 {
+		t.Errorf("expected Refresh to force a refetch, got %d list fetches", hits)
+	}
+
+	// Sources without caches are left alone
+	Refresh(NewMemorySource("memory", nil))
+}
+
+func TestToptalSourceListCachePerInstance(t *testing.T) {
+	server, listHits := newToptalTestServer(t, "go", nil)
+
+	for i := 0; i < 2; i++ {
+		if _, err := NewToptalSourceWithURL(server.URL).List(); err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+	}
+
+	if hits := atomic.LoadInt32(listHits); hits != 2 {
+		t.Errorf("expected each instance to fetch its own list, got %d fetches", hits)
+	}
+}
+
+func TestToptalSourceListCacheDisabled(t *testing.T) {
+	server, listHits := newToptalTestServer(t, "go", nil)
+	toptal := NewToptalSourceWithURL(server.URL)
+	toptal.SetListTTL(0)
+
+	for i := 0; i < 2; i++ {
+		if _, err := toptal.List(); err != nil {
+			t.Fatalf("List() error: %v", err)
+		}
+	}
+
+	if hits := atomic.LoadInt32(listHits); hits != 2 {
+		t.Errorf("expected list to be refetched with caching disabled, got %d fetches", hits)
+	}
+}
+
+// toptalListSample is a captured excerpt of the real /list response
+const toptalListSample = `1c,1c-bitrix,a-frame,actionscript,ada
+adobe,advancedinstaller,adventuregamestudio,agda,al
+alteraquartusii,altium,amplify,android,androidstudio
+angular,anjuta,ansible,ansibletower,apachecordova
+go,godot,gpg,gradle,grails
+node,nodechakracore,nohup,notepadpp,nuxtjs
+visualstudio,visualstudiocode,vue,vvvv,waf
+`
+
+func TestParseToptalList(t *testing.T) {
+	files := parseToptalList(toptalListSample)
+	if len(files) != 35 {
+		t.Fatalf("expected 35 templates, got %d", len(files))
+	}
+
+	names := make(map[string]bool)
+	for _, f := range files {
+		if f.Name == "" || strings.ContainsAny(f.Name, ", \t\r\n") {
+			t.Errorf("malformed template name %q", f.Name)
+		}
+		if f.Source != "toptal" || f.Path != f.Name {
+			t.Errorf("unexpected template file %+v", f)
+		}
+		names[f.Name] = true
+	}
+	for _, want := range []string{"go", "node", "visualstudiocode", "1c-bitrix"} {
+		if !names[want] {
+			t.Errorf("expected %q in parsed list", want)
+		}
+	}
+}
+
+func TestToptalGlobalCategory(t *testing.T) {
+	server, _ := newToptalTestServer(t, "go,macOS,windows", map[string]string{"macOS": "# macOS\n.DS_Store\n"})
+	source := NewToptalSourceWithURL(server.URL)
+
+	files, err := source.List()
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	for _, f := range files {
+		want := "Global"
+		if f.Name == "go" {
+			want = ""
+		}
+		if f.Category != want {
+			t.Errorf("%s: Category = %q, want %q", f.Name, f.Category, want)
+		}
+	}
+	if got := FilterCategory(files, "global"); len(got) != 2 {
+		t.Errorf("FilterCategory(global) = %+v, want macOS and windows", got)
+	}
+
+	// A category path resolves to the API name
+	file, content, err := source.Get("global/macos")
+	if err != nil {
+		t.Fatalf("Get(global/macos) error: %v", err)
+	}
+	if file.Name != "macOS" || !strings.Contains(content, ".DS_Store") {
+		t.Errorf("Get(global/macos) = %+v, %q", file, content)
+	}
+}
+
+func TestToptalFirstResolution(t *testing.T) {
+	server, _ := newToptalTestServer(t, "go,macos,ansible,hugo", map[string]string{
+		"go":      "# toptal go",
+		"macos":   "# toptal macos",
+		"ansible": "# toptal ansible",
+		"hugo":    "# toptal hugo",
+	})
+	github := NewMemorySource("github", map[string]string{
+		"Go":                    "# github go",
+		"Rust":                  "# github rust",
+		"Global/macOS":          "# github macos",
+		"Global/Ansible":        "# github ansible",
+		"community/Golang/Hugo": "# github hugo",
+	})
+	local := NewLocalSourceWithDir(t.TempDir())
+	toptal := NewToptalSourceWithURL(server.URL)
+	sm := &SourceManager{
+		local:   local,
+		remote:  []Source{github, toptal},
+		sources: []Source{local, github, toptal},
+	}
+	sm.applyOrder([]string{"local", "toptal", "github"})
+
+	tests := []struct {
+		name     string
+		source   string
+		category string
+		content  string
+	}{
+		{"go", "toptal", "", "# toptal go"},
+		{"macos", "toptal", "Global", "# toptal macos"},
+		{"Global/macOS", "toptal", "Global", "# toptal macos"},
+		// Not in ToptalCategories, but GitHub files it under Global/
+		{"global/ansible", "toptal", "Global", "# toptal ansible"},
+		// Other category paths are GitHub's own, as are names Toptal lacks
+		{"community/golang/hugo", "github", "community/Golang", "# github hugo"},
+		{"rust", "github", "", "# github rust"},
+	}
+	for _, tt := range tests {
+		file, content, err := sm.GetAny(tt.name)
+		if err != nil {
+			t.Errorf("GetAny(%q) error: %v", tt.name, err)
+			continue
+		}
+		if file.Source != tt.source || file.Category != tt.category || content != tt.content {
+			t.Errorf("GetAny(%q) = %s %s/%s %q, want %s %s %q",
+				tt.name, file.Source, file.Category, file.Name, content, tt.source, tt.category, tt.content)
+		}
+	}
+
+	// An explicit source prefix still bypasses the priority order
+	if file, _, err := sm.GetAny("github/global/macos"); err != nil || file.Source != "github" {
+		t.Errorf("GetAny(github/global/macos) = %+v, %v; want the GitHub template", file, err)
+	}
+}
+
+func TestToptalSourceRejectsHTML(t *testing.T) {
+	server, _ := newToptalTestServer(t, "go,node", map[string]string{
+		"go":   "<html><body>Proxy authentication required</body></html>",
+		"node": "# node\nnode_modules/\n",
+	})
+	source := NewToptalSourceWithURL(server.URL)
+
+	if _, _, err := source.Get("go"); err == nil || !strings.Contains(err.Error(), "HTML") {
+		t.Errorf("Get(go) error = %v, want an HTML page error", err)
+	}
+	if _, content, err := source.Get("node"); err != nil || !strings.Contains(content, "node_modules/") {
+		t.Errorf("Get(node) = %q, %v", content, err)
+	}
+}
+
+func TestParseToptalListColumns(t *testing.T) {
+	body := "go              node            rust\r\n  visualstudiocode,  vim ,\n\n"
+	files := parseToptalList(body)
+
+	var got []string
+	for _, f := range files {
+		got = append(got, f.Name)
+	}
+	want := "go node rust visualstudiocode vim"
+	if strings.Join(got, " ") != want {
+		t.Errorf("parseToptalList() = %v, want %s", got, want)
+	}
+}
+
+func TestToptalSourceUserAgent(t *testing.T) {
+	var agents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		if r.URL.Path == "/list" {
+			fmt.Fprint(w, "go")
+			return
+		}
+		fmt.Fprint(w, "# go")
+	}))
+	defer server.Close()
+
+	toptal := NewToptalSourceWithURL(server.URL)
+	toptal.SetUserAgent("gitignore/1.2.3")
+	if _, _, err := toptal.Get("go"); err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if len(agents) != 2 || agents[0] != "gitignore/1.2.3" || agents[1] != "gitignore/1.2.3" {
+		t.Errorf("User-Agent headers = %v, want gitignore/1.2.3 on list and content", agents)
+	}
+}
@@ -0,0 +1,88 @@
+// Package source provides abstraction for different gitignore template sources
+package source
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/polliard/gitignore/src/pkg/github"
+)
+
+// MaxURLTemplateSize limits how much FetchURL downloads; real templates are
+// a few kilobytes, so anything larger is almost certainly not one
+const MaxURLTemplateSize = 1 << 20
+
+// FetchURL downloads a template from a raw http(s) URL, outside of any
+// configured source, sending userAgent (github.DefaultUserAgent if empty)
+// through proxy (the environment's proxy settings if nil); canceling ctx
+// aborts the download
+// The content must be non-empty text: empty bodies, binary data, HTML pages
+// and files over MaxURLTemplateSize are rejected
+func FetchURL(ctx context.Context, rawURL, userAgent string, proxy *url.URL) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid template URL '%s': must be an http or https URL", rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid template URL '%s': %w", rawURL, err)
+	}
+	if userAgent == "" {
+		userAgent = github.DefaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := github.NewHTTPClient(proxy).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch %s (status %d)", rawURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxURLTemplateSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+	if len(data) > MaxURLTemplateSize {
+		return "", fmt.Errorf("%s is larger than %d bytes; not a gitignore template", rawURL, MaxURLTemplateSize)
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return "", fmt.Errorf("%s is not a text file", rawURL)
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return "", fmt.Errorf("%s is empty", rawURL)
+	}
+	// Any Content-Type is accepted, since plenty of servers send
+	// application/octet-stream for .gitignore files, but not an HTML page
+	if err := github.CheckTemplateContent("", data); err != nil {
+		return "", fmt.Errorf("%s: %w", rawURL, err)
+	}
+	return string(data), nil
+}
+
+// TemplateNameFromURL derives a template name from the last element of a
+// URL path, e.g. "Foo" from ".../Foo.gitignore", or "" if there is none
+func TemplateNameFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	if strings.HasSuffix(strings.ToLower(name), ".gitignore") {
+		name = name[:len(name)-len(".gitignore")]
+	}
+	return name
+}
@@ -0,0 +1,82 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/Foo.gitignore":
+			w.Write([]byte("# Foo\n*.foo\n"))
+		case "/empty.gitignore":
+			w.Write([]byte("\n  \n"))
+		case "/binary.gitignore":
+			w.Write([]byte{0x7f, 'E', 'L', 'F', 0x00, 0x01})
+		case "/portal.gitignore":
+			w.Write([]byte("<!DOCTYPE html>\n<title>Sign in to Wi-Fi</title>\n"))
+		case "/large.gitignore":
+			w.Write([]byte(strings.Repeat("a", MaxURLTemplateSize+1)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	content, err := FetchURL(context.Background(), server.URL+"/Foo.gitignore", "", nil)
+	if err != nil {
+		t.Fatalf("FetchURL() error = %v", err)
+	}
+	if content != "# Foo\n*.foo\n" {
+		t.Errorf("FetchURL() = %q", content)
+	}
+
+	for _, p := range []string{"/empty.gitignore", "/binary.gitignore", "/portal.gitignore", "/large.gitignore", "/missing.gitignore"} {
+		if _, err := FetchURL(context.Background(), server.URL+p, "", nil); err == nil {
+			t.Errorf("FetchURL(%s) should fail", p)
+		}
+	}
+
+	for _, u := range []string{"ftp://example.com/Foo.gitignore", "Foo.gitignore", "https://"} {
+		if _, err := FetchURL(context.Background(), u, "", nil); err == nil || !strings.Contains(err.Error(), "invalid template URL") {
+			t.Errorf("FetchURL(%q) error = %v, want invalid URL", u, err)
+		}
+	}
+}
+
+func TestFetchURLUserAgent(t *testing.T) {
+	var agents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.UserAgent())
+		w.Write([]byte("*.foo\n"))
+	}))
+	defer server.Close()
+
+	for _, ua := range []string{"", "corp-proxy-ok/1.0"} {
+		if _, err := FetchURL(context.Background(), server.URL+"/Foo.gitignore", ua, nil); err != nil {
+			t.Fatalf("FetchURL() error = %v", err)
+		}
+	}
+	if strings.Join(agents, ",") != "gitignore,corp-proxy-ok/1.0" {
+		t.Errorf("User-Agent headers = %v", agents)
+	}
+}
+
+func TestTemplateNameFromURL(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/templates/Foo.gitignore":     "Foo",
+		"https://example.com/templates/Bar.GITIGNORE?x=1": "Bar",
+		"https://example.com/templates/node":              "node",
+		"https://example.com/":                            "",
+		"https://example.com":                             "",
+	}
+	for u, want := range tests {
+		if got := TemplateNameFromURL(u); got != want {
+			t.Errorf("TemplateNameFromURL(%q) = %q, want %q", u, got, want)
+		}
+	}
+}